@@ -0,0 +1,195 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"github.com/Noah-Huppert/crime-map/config"
+	"github.com/Noah-Huppert/crime-map/dstore"
+	"github.com/Noah-Huppert/crime-map/models"
+)
+
+// Exit codes returned by commands
+const (
+	// ExitOK indicates that a command succeeded
+	ExitOK int = 0
+
+	// ExitErr indicates that a command failed while running
+	ExitErr int = 1
+
+	// ExitUsage indicates that a command was invoked incorrectly
+	ExitUsage int = 2
+)
+
+// Command is a crime-map sub command
+type Command struct {
+	// Name is the argument used to invoke the command
+	Name string
+
+	// Usage describes the command's arguments, shown after the command
+	// name in help text
+	Usage string
+
+	// Summary is a one line description of what the command does
+	Summary string
+
+	// Flags defines the command's flags. May be nil if the command has
+	// no flags of its own.
+	Flags func(f *pflag.FlagSet)
+
+	// Run executes the command with any positional arguments left after
+	// flags were parsed. The configuration has already been loaded. An
+	// exit code is returned.
+	Run func(ctx context.Context, c *config.Config, args []string) int
+}
+
+// commands holds all available sub commands, in the order they are listed in
+// help text
+var commands []Command = []Command{
+	migrateCmd,
+	ingestCmd,
+	geocodeCmd,
+	reparseCmd,
+	fixTimesCmd,
+	incidentsCmd,
+	categorizeCmd,
+	exportCmd,
+	serveCmd,
+}
+
+// Run parses the command line arguments, without the program name, and
+// executes the matching sub command. An exit code is returned.
+func Run(args []string) int {
+	// Check command provided
+	if len(args) == 0 {
+		printUsage()
+		return ExitUsage
+	}
+
+	name := args[0]
+
+	// Check for help
+	if name == "help" || name == "-h" || name == "--help" {
+		printUsage()
+		return ExitOK
+	}
+
+	// Find command
+	for _, cmd := range commands {
+		if cmd.Name == name {
+			return cmd.exec(args[1:])
+		}
+	}
+
+	// If none found
+	fmt.Fprintf(os.Stderr, "unknown command: %s\n\n", name)
+	printUsage()
+
+	return ExitUsage
+}
+
+// exec parses the command's flags, loads the configuration and runs the
+// command. An exit code is returned.
+func (cmd Command) exec(args []string) int {
+	// Setup flags
+	f := pflag.NewFlagSet(cmd.Name, pflag.ContinueOnError)
+	f.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: crime-map %s %s\n\n%s\n\n",
+			cmd.Name, cmd.Usage, cmd.Summary)
+		fmt.Fprintf(os.Stderr, "flags:\n%s", f.FlagUsages())
+	}
+
+	if cmd.Flags != nil {
+		cmd.Flags(f)
+	}
+
+	f.AddFlagSet(config.Flags)
+
+	// Parse
+	if err := f.Parse(args); err == pflag.ErrHelp {
+		return ExitOK
+	} else if err != nil {
+		fmt.Fprintf(os.Stderr, "error parsing flags: %s\n", err.Error())
+		return ExitUsage
+	}
+
+	// Load configuration
+	c, err := config.NewConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error loading configuration: %s\n",
+			err.Error())
+		return ExitErr
+	}
+
+	fmt.Fprintf(os.Stderr, "environment: %s\n", c.Env)
+
+	// Cancel context on interrupt
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt)
+	defer signal.Stop(sigs)
+
+	go func() {
+		select {
+		case <-sigs:
+			fmt.Fprintln(os.Stderr, "interrupted, stopping")
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	// Run
+	return cmd.Run(ctx, c, f.Args())
+}
+
+// newStore connects to the configured database and returns a Store which
+// saves models in it. An error is returned if one occurs, nil on success.
+func newStore(c *config.Config) (models.Store, error) {
+	db, err := dstore.NewDB(c.DB)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to database: %s",
+			err.Error())
+	}
+
+	return models.NewPgStore(db, c.DB.QueryTimeout), nil
+}
+
+// usageErr prints a usage error for a command. ExitUsage is returned so it
+// can be used as a command's return value.
+func usageErr(cmd string, format string, args ...interface{}) int {
+	fmt.Fprintf(os.Stderr, "%s: %s\n", cmd, fmt.Sprintf(format, args...))
+	fmt.Fprintf(os.Stderr, "run 'crime-map %s --help' for usage\n", cmd)
+
+	return ExitUsage
+}
+
+// runErr prints an error which occurred while running a command. ExitErr is
+// returned so it can be used as a command's return value.
+func runErr(format string, args ...interface{}) int {
+	fmt.Fprintf(os.Stderr, "%s\n", fmt.Sprintf(format, args...))
+
+	return ExitErr
+}
+
+// printUsage outputs a list of commands
+func printUsage() {
+	lines := []string{"usage: crime-map <command> [flags] [args]", "",
+		"commands:"}
+
+	for _, cmd := range commands {
+		lines = append(lines, fmt.Sprintf("  %-8s %s", cmd.Name,
+			cmd.Summary))
+	}
+
+	lines = append(lines, "", "run 'crime-map <command> --help' for "+
+		"command flags")
+
+	fmt.Fprintln(os.Stderr, strings.Join(lines, "\n"))
+}
@@ -0,0 +1,188 @@
+package cli
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"github.com/Noah-Huppert/crime-map/config"
+	"github.com/Noah-Huppert/crime-map/models"
+)
+
+// exportPageSize is the number of crimes queried from the database at once
+const exportPageSize uint = 500
+
+// Export formats
+const (
+	// exportFormatJSON outputs crimes as a JSON array
+	exportFormatJSON string = "json"
+
+	// exportFormatCSV outputs crimes as CSV rows with a header
+	exportFormatCSV string = "csv"
+)
+
+// exportFormat holds the value of the export command's --format flag
+var exportFormat string
+
+// exportOut holds the value of the export command's --out flag
+var exportOut string
+
+// exportOrderBy holds the value of the export command's --order-by flag
+var exportOrderBy string
+
+// exportCmd writes all crimes in the database to a file
+var exportCmd Command = Command{
+	Name:    "export",
+	Usage:   "",
+	Summary: "write all crimes to a JSON or CSV file",
+	Flags: func(f *pflag.FlagSet) {
+		f.StringVar(&exportFormat, "format", exportFormatJSON,
+			"output format (json, csv)")
+		f.StringVarP(&exportOut, "out", "o", "", "file to write to, "+
+			"standard output if empty")
+		f.StringVar(&exportOrderBy, "order-by",
+			string(models.OrderByReported), "field to order crimes "+
+				"by (date_reported, date_occurred)")
+	},
+	Run: runExport,
+}
+
+// runExport implements the export command
+func runExport(ctx context.Context, c *config.Config, args []string) int {
+	// Check args
+	if len(args) != 0 {
+		return usageErr("export", "expected no arguments, got %d",
+			len(args))
+	}
+
+	if exportFormat != exportFormatJSON && exportFormat != exportFormatCSV {
+		return usageErr("export", "unknown format: %s", exportFormat)
+	}
+
+	orderBy, err := models.NewOrderByType(exportOrderBy)
+	if err != nil {
+		return usageErr("export", "invalid --order-by value: %s",
+			err.Error())
+	}
+
+	// Connect to database
+	store, err := newStore(c)
+	if err != nil {
+		return runErr("%s", err.Error())
+	}
+
+	// Query crimes
+	crimes := []*models.Crime{}
+	var offset uint = 0
+
+	for {
+		// Check if interrupted
+		if ctx.Err() != nil {
+			return runErr("export interrupted")
+		}
+
+		page, err := store.QueryAllCrimes(ctx, offset, exportPageSize,
+			orderBy)
+		if err != nil {
+			return runErr("error querying crimes: %s", err.Error())
+		}
+
+		crimes = append(crimes, page...)
+		offset += uint(len(page))
+
+		// Check if last page
+		if uint(len(page)) < exportPageSize {
+			break
+		}
+	}
+
+	// Open output
+	var out io.Writer = os.Stdout
+
+	if len(exportOut) > 0 {
+		file, err := os.Create(exportOut)
+		if err != nil {
+			return runErr("error creating output file: %s",
+				err.Error())
+		}
+		defer file.Close()
+
+		out = file
+	}
+
+	// Write
+	if exportFormat == exportFormatJSON {
+		err = writeCrimesJSON(out, crimes)
+	} else {
+		err = writeCrimesCSV(out, crimes)
+	}
+
+	if err != nil {
+		return runErr("error writing crimes: %s", err.Error())
+	}
+
+	fmt.Fprintf(os.Stderr, "exported %d crimes\n", len(crimes))
+
+	return ExitOK
+}
+
+// writeCrimesJSON writes crimes as an indented JSON array. An error is
+// returned if one occurs, nil on success.
+func writeCrimesJSON(w io.Writer, crimes []*models.Crime) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(crimes)
+}
+
+// writeCrimesCSV writes crimes as CSV rows, preceded by a header row. Fields
+// with multiple values are joined with semicolons. An error is returned if one
+// occurs, nil on success.
+func writeCrimesCSV(w io.Writer, crimes []*models.Crime) error {
+	csvW := csv.NewWriter(w)
+
+	// Header
+	err := csvW.Write([]string{"id", "report_id", "page",
+		"date_reported", "date_occurred_start", "date_occurred_end",
+		"report_number", "geo_loc_id", "incidents", "descriptions",
+		"remediation", "categories"})
+	if err != nil {
+		return fmt.Errorf("error writing header: %s", err.Error())
+	}
+
+	// Rows
+	for _, crime := range crimes {
+		err = csvW.Write([]string{
+			strconv.Itoa(crime.ID),
+			strconv.Itoa(crime.ReportID),
+			strconv.Itoa(crime.Page),
+			crime.DateReported.Format(time.RFC3339),
+			crime.DateOccurredStart.Format(time.RFC3339),
+			crime.DateOccurredEnd.Format(time.RFC3339),
+			fmt.Sprintf("%d-%d", crime.ReportSuperID,
+				crime.ReportSubID),
+			strconv.Itoa(crime.GeoLocID),
+			strings.Join(crime.Incidents, ";"),
+			strings.Join(crime.Descriptions, ";"),
+			crime.Remediation,
+			strings.Join(crime.Categories, ";"),
+		})
+		if err != nil {
+			return fmt.Errorf("error writing crime row, crime: %s, "+
+				"err: %s", crime, err.Error())
+		}
+	}
+
+	// Flush
+	csvW.Flush()
+
+	return csvW.Error()
+}
@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Noah-Huppert/crime-map/config"
+	"github.com/Noah-Huppert/crime-map/models"
+)
+
+// fixTimesCmd corrects the times of reports parsed before report time zones
+// were recorded
+var fixTimesCmd Command = Command{
+	Name:    "fix-times",
+	Summary: "correct times of reports parsed before time zones were recorded",
+	Run:     runFixTimes,
+}
+
+// runFixTimes implements the fix-times command. Each report's times were
+// written in its university's time zone, but saved as if written in UTC. Only
+// reports without a recorded time zone are corrected, so the command can be
+// run again safely.
+func runFixTimes(ctx context.Context, c *config.Config, args []string) int {
+	// Check args
+	if len(args) != 0 {
+		return usageErr("fix-times", "expected no arguments, got %d",
+			len(args))
+	}
+
+	// Connect to database
+	store, err := newStore(c)
+	if err != nil {
+		return runErr("%s", err.Error())
+	}
+
+	// Find reports without time zones
+	reports, err := store.QueryAllReports(ctx)
+	if err != nil {
+		return runErr("error querying for reports: %s", err.Error())
+	}
+
+	fixed := 0
+
+	for _, report := range reports {
+		if len(report.TimeZone) > 0 {
+			continue
+		}
+
+		loc, err := report.University.Location()
+		if err != nil {
+			return runErr("error finding report %d's time zone: %s",
+				report.ID, err.Error())
+		}
+
+		// Correct each report in its own transaction, so reports
+		// corrected before an error are not corrected twice
+		err = store.Tx(ctx, func(tx models.Store) error {
+			return tx.LocalizeReport(ctx, report, loc)
+		})
+		if err != nil {
+			return runErr("error correcting report %d's times: %s",
+				report.ID, err.Error())
+		}
+
+		fmt.Printf("corrected report %d (%s), %d crimes, now in %s\n",
+			report.ID, report.FileName, report.CrimesCount,
+			report.TimeZone)
+		fixed++
+	}
+
+	fmt.Printf("corrected %d reports\n", fixed)
+
+	return ExitOK
+}
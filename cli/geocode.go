@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Noah-Huppert/crime-map/config"
+	"github.com/Noah-Huppert/crime-map/geo"
+)
+
+// geocodeCmd locates GeoLoc models which have not been located yet
+var geocodeCmd Command = Command{
+	Name:    "geocode",
+	Usage:   "pending",
+	Summary: "locate crime locations which have not been geocoded yet",
+	Run:     runGeocode,
+}
+
+// runGeocode implements the geocode command
+func runGeocode(ctx context.Context, c *config.Config, args []string) int {
+	// Check action provided
+	if len(args) != 1 || args[0] != "pending" {
+		return usageErr("geocode", "expected 'pending' argument")
+	}
+
+	// Connect to database
+	store, err := newStore(c)
+	if err != nil {
+		return runErr("%s", err.Error())
+	}
+
+	// Find unlocated GeoLocs
+	fmt.Println("querying for unlocated GeoLoc models")
+	unlocated, err := store.QueryUnlocatedGeoLocs(ctx)
+	if err != nil {
+		return runErr("error querying for unlocated GeoLocs: %s",
+			err.Error())
+	}
+
+	// Locate
+	fmt.Printf("locating %d unlocated GeoLoc models\n", len(unlocated))
+	geocoder, err := geo.NewGeocoder(c)
+	if err != nil {
+		return runErr("error retrieving geocoder: %s", err.Error())
+	}
+
+	locater := geo.NewLocater(store, geocoder, c.Geo)
+	errs := geo.LocateAll(ctx, locater, unlocated)
+
+	if len(errs) != 0 {
+		// Combine errors into string
+		errsArr := []string{}
+		for _, err := range errs {
+			errsArr = append(errsArr, err.Error())
+		}
+
+		return runErr("error locating GeoLoc models: %s",
+			strings.Join(errsArr, ", "))
+	}
+
+	// Save locations
+	for _, loc := range unlocated {
+		if c.Log.Verbose {
+			fmt.Printf("saving GeoLoc:\n%s\n", loc)
+		}
+
+		if err = store.UpdateGeoLoc(ctx, *loc); err != nil {
+			return runErr("error updating GeoLoc model, loc: %s, "+
+				"err: %s", loc, err.Error())
+		}
+	}
+
+	return ExitOK
+}
@@ -0,0 +1,214 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/pflag"
+
+	"github.com/Noah-Huppert/crime-map/config"
+	"github.com/Noah-Huppert/crime-map/models"
+	"github.com/Noah-Huppert/crime-map/parsers"
+)
+
+// categorizePageSize is the number of crimes categorized at once
+const categorizePageSize uint = 500
+
+// incidentsAll holds the value of the incidents command's --all flag
+var incidentsAll bool
+
+// incidentsCmd lists incidents in the database which have no category mapping
+var incidentsCmd Command = Command{
+	Name:    "incidents",
+	Usage:   "",
+	Summary: "list saved incidents which have no category mapping",
+	Flags: func(f *pflag.FlagSet) {
+		f.BoolVar(&incidentsAll, "all", false, "list every incident "+
+			"with its categories, instead of only unmapped incidents")
+	},
+	Run: runIncidents,
+}
+
+// categorizeCmd assigns incident categories to saved crimes using the current
+// incident mapping
+var categorizeCmd Command = Command{
+	Name:    "categorize",
+	Usage:   "",
+	Summary: "assign incident categories to saved crimes again",
+	Run:     runCategorize,
+}
+
+// loadIncidentMapping loads the configured incident mapping file. An error is
+// returned if none is configured, or it can not be loaded. Nil on success.
+func loadIncidentMapping(c *config.Config) (*parsers.IncidentMapping, error) {
+	if len(c.Incident.Mapping) == 0 {
+		return nil, errors.New("incident.mapping must be set")
+	}
+
+	mapping, err := parsers.LoadIncidentMapping(c.Incident.Mapping)
+	if err != nil {
+		return nil, fmt.Errorf("error loading incident mapping: %s",
+			err.Error())
+	}
+
+	return mapping, nil
+}
+
+// runIncidents implements the incidents command. Incidents are listed with the
+// number of crimes they were recorded for, most common first.
+func runIncidents(ctx context.Context, c *config.Config, args []string) int {
+	// Check args
+	if len(args) != 0 {
+		return usageErr("incidents", "expected no arguments, got %d",
+			len(args))
+	}
+
+	mapping, err := loadIncidentMapping(c)
+	if err != nil {
+		return runErr("%s", err.Error())
+	}
+
+	// Connect to database
+	store, err := newStore(c)
+	if err != nil {
+		return runErr("%s", err.Error())
+	}
+
+	// Count incidents
+	counts, err := store.QueryIncidentCounts(ctx)
+	if err != nil {
+		return runErr("error counting incidents: %s", err.Error())
+	}
+
+	incidents := []string{}
+	for incident := range counts {
+		incidents = append(incidents, incident)
+	}
+
+	sort.Slice(incidents, func(i, j int) bool {
+		a, b := incidents[i], incidents[j]
+
+		if counts[a] != counts[b] {
+			return counts[a] > counts[b]
+		}
+
+		return a < b
+	})
+
+	// Output
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, "CRIMES\tINCIDENT\tCATEGORIES")
+
+	unmapped := 0
+
+	for _, incident := range incidents {
+		categories, missing := mapping.Categorize([]string{incident})
+
+		if len(missing) > 0 {
+			unmapped++
+		} else if !incidentsAll {
+			continue
+		}
+
+		categoriesStr := strings.Join(categories, ", ")
+		if len(missing) > 0 {
+			categoriesStr = "unmapped"
+		} else if len(categories) == 0 {
+			categoriesStr = "none"
+		}
+
+		fmt.Fprintf(w, "%d\t%s\t%s\n", counts[incident], incident,
+			categoriesStr)
+	}
+
+	w.Flush()
+
+	fmt.Printf("%d of %d incidents have no category mapping\n", unmapped,
+		len(incidents))
+
+	return ExitOK
+}
+
+// runCategorize implements the categorize command. Crimes are categorized in
+// one transaction, so a failure leaves every crime's categories unchanged.
+// Crimes from superseded reports are not categorized.
+func runCategorize(ctx context.Context, c *config.Config, args []string) int {
+	// Check args
+	if len(args) != 0 {
+		return usageErr("categorize", "expected no arguments, got %d",
+			len(args))
+	}
+
+	mapping, err := loadIncidentMapping(c)
+	if err != nil {
+		return runErr("%s", err.Error())
+	}
+
+	// Connect to database
+	store, err := newStore(c)
+	if err != nil {
+		return runErr("%s", err.Error())
+	}
+
+	// Categorize
+	categorized := 0
+	unmapped := map[string]bool{}
+
+	err = store.Tx(ctx, func(tx models.Store) error {
+		var offset uint = 0
+
+		for {
+			page, err := tx.QueryAllCrimes(ctx, offset,
+				categorizePageSize, models.OrderByReported)
+			if err != nil {
+				return fmt.Errorf("error querying crimes: %s",
+					err.Error())
+			}
+
+			crimes := []models.Crime{}
+
+			for _, crime := range page {
+				categories, missing := mapping.Categorize(
+					crime.Incidents)
+				crime.Categories = categories
+				crimes = append(crimes, *crime)
+
+				for _, incident := range missing {
+					unmapped[incident] = true
+				}
+			}
+
+			if err = tx.SetCrimeCategories(ctx, crimes); err != nil {
+				return fmt.Errorf("error saving crimes' "+
+					"categories: %s", err.Error())
+			}
+
+			categorized += len(crimes)
+
+			offset += uint(len(page))
+
+			// Check if last page
+			if uint(len(page)) < categorizePageSize {
+				return nil
+			}
+		}
+	})
+	if err != nil {
+		return runErr("error categorizing crimes: %s", err.Error())
+	}
+
+	fmt.Printf("categorized %d crimes\n", categorized)
+
+	if len(unmapped) > 0 {
+		fmt.Fprintf(os.Stderr, "%d incidents have no category mapping, "+
+			"run the incidents command to list them\n", len(unmapped))
+	}
+
+	return ExitOK
+}
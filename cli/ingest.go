@@ -0,0 +1,273 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"github.com/Noah-Huppert/crime-map/config"
+	"github.com/Noah-Huppert/crime-map/ingest"
+	"github.com/Noah-Huppert/crime-map/parsers"
+)
+
+// summaryDateFmt is the format report range dates are displayed in
+const summaryDateFmt string = "2006-01-02"
+
+// ingestJobs holds the value of the ingest command's --jobs flag
+var ingestJobs uint
+
+// ingestParser holds the value of the ingest and reparse commands' --parser
+// flag
+var ingestParser string
+
+// ingestLenient holds the value of the ingest and reparse commands' --lenient
+// flag
+var ingestLenient bool
+
+// ingestCmd parses crime report files and saves their crimes
+var ingestCmd Command = Command{
+	Name:    "ingest",
+	Usage:   "<files, dirs or globs>...",
+	Summary: "parse crime report files and save their crimes",
+	Flags: func(f *pflag.FlagSet) {
+		f.UintVarP(&ingestJobs, "jobs", "j", 4, "maximum number of "+
+			"files to ingest at the same time")
+		parseFlags(f)
+	},
+	Run: runIngest,
+}
+
+// runIngest implements the ingest command
+func runIngest(ctx context.Context, c *config.Config, args []string) int {
+	// Check paths provided
+	if len(args) == 0 {
+		return usageErr("ingest", "at least 1 file, directory or glob "+
+			"must be provided")
+	}
+
+	// Find files
+	files, err := ingest.ExpandPaths(args)
+	if err != nil {
+		return runErr("error finding report files: %s", err.Error())
+	}
+
+	// Check parser exists
+	if status := checkParserFlag("ingest"); status != ExitOK {
+		return status
+	}
+
+	// Load table and incident mappings
+	opts, err := ingestOptions(c)
+	if err != nil {
+		return runErr("%s", err.Error())
+	}
+
+	fmt.Printf("ingesting %d reports\n", len(files))
+
+	// Connect to database
+	store, err := newStore(c)
+	if err != nil {
+		return runErr("%s", err.Error())
+	}
+
+	// Ingest
+	batch := ingest.NewBatch(store, ingestJobs, opts)
+	summaries := batch.Ingest(ctx, files)
+
+	// Output summary
+	failed := printSummaries(summaries)
+
+	if failed > 0 {
+		return runErr("failed to ingest %d of %d reports", failed,
+			len(summaries))
+	}
+
+	return ExitOK
+}
+
+// parseFlags defines the --parser and --lenient flags, shared by commands which
+// parse reports
+func parseFlags(f *pflag.FlagSet) {
+	f.StringVar(&ingestParser, "parser", "", "name of parser to use for "+
+		"every report, instead of detecting it ("+
+		strings.Join(parsers.RegisteredNames(), ", ")+")")
+	f.BoolVar(&ingestLenient, "lenient", false, "skip parts of reports "+
+		"which can not be parsed and record them as parse errors, "+
+		"instead of failing")
+}
+
+// checkParserFlag outputs a usage error if the --parser flag does not name a
+// registered parser. ExitUsage is returned if so, ExitOK if the flag is valid.
+func checkParserFlag(cmd string) int {
+	if len(ingestParser) == 0 {
+		return ExitOK
+	}
+
+	if _, err := parsers.LookupParser(ingestParser); err != nil {
+		return usageErr(cmd, "invalid --parser: %s", err.Error())
+	}
+
+	return ExitOK
+}
+
+// ingestOptions creates the options reports are ingested with, from the
+// configuration and command line flags. The table and incident mapping files
+// are loaded if configured. An error is returned if one occurs, nil on success.
+func ingestOptions(c *config.Config) (ingest.Options, error) {
+	opts := ingest.Options{
+		Verbose:   c.Log.Verbose,
+		Passwords: c.PDF.Passwords,
+		Parser:    ingestParser,
+		Mode:      parsers.ModeStrict,
+	}
+
+	if ingestLenient {
+		opts.Mode = parsers.ModeLenient
+	}
+
+	if len(c.Table.Mapping) > 0 {
+		mapping, err := parsers.LoadTableMapping(c.Table.Mapping)
+		if err != nil {
+			return opts, fmt.Errorf("error loading table mapping: %s",
+				err.Error())
+		}
+
+		opts.Mapping = mapping
+	}
+
+	if len(c.Incident.Mapping) > 0 {
+		incidents, err := parsers.LoadIncidentMapping(c.Incident.Mapping)
+		if err != nil {
+			return opts, fmt.Errorf("error loading incident mapping: "+
+				"%s", err.Error())
+		}
+
+		opts.Incidents = incidents
+	}
+
+	return opts, nil
+}
+
+// printSummaries outputs a table with a row for each ingested file. Followed
+// by the error for each file which failed. The number of files which failed
+// is returned.
+func printSummaries(summaries []ingest.Summary) int {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, "FILE\tUNIVERSITY\tRANGE\tCRIMES\tPARSE ERRORS\tSTATUS")
+
+	failed := 0
+
+	for _, s := range summaries {
+		// Determine status
+		status := "ingested"
+
+		if s.Err != nil {
+			status = "failed"
+			failed++
+		} else if s.Skipped {
+			status = "skipped, already ingested"
+		} else if s.SupersededID != 0 {
+			status = fmt.Sprintf("ingested, superseded report %d",
+				s.SupersededID)
+		}
+
+		// Note pages which could not be read
+		if s.Err == nil && !s.Skipped && s.Diagnostics.Partial() {
+			status += fmt.Sprintf(", %d pages failed",
+				len(s.Diagnostics.Failed))
+		}
+
+		// Note parts skipped when parsing leniently
+		if s.Err == nil && !s.Skipped && len(s.ReportParseErrors) > 0 {
+			status += ", partial"
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%s\n", s.File,
+			s.University, fmtRange(s.RangeStart, s.RangeEnd),
+			s.Crimes, s.ParseErrors, status)
+	}
+
+	w.Flush()
+
+	// Output errors
+	for _, s := range summaries {
+		if s.Err != nil {
+			fmt.Fprintf(os.Stderr, "error ingesting %s: %s\n", s.File,
+				s.Err.Error())
+		}
+
+		for _, pErr := range s.Diagnostics.Failed {
+			fmt.Fprintf(os.Stderr, "error reading %s %s\n", s.File,
+				pErr)
+		}
+
+		printReportParseErrors(s)
+	}
+
+	printUnmapped(summaries)
+
+	return failed
+}
+
+// printUnmapped outputs the incidents in any of the ingested reports which the
+// incident mapping has no rule for. So rules can be added for them.
+func printUnmapped(summaries []ingest.Summary) {
+	unmapped := map[string]bool{}
+
+	for _, s := range summaries {
+		for _, incident := range s.Unmapped {
+			unmapped[incident] = true
+		}
+	}
+
+	if len(unmapped) == 0 {
+		return
+	}
+
+	incidents := []string{}
+	for incident := range unmapped {
+		incidents = append(incidents, incident)
+	}
+	sort.Strings(incidents)
+
+	fmt.Fprintf(os.Stderr, "%d incidents have no category mapping, run "+
+		"the categorize command after adding rules for them:\n",
+		len(incidents))
+
+	for _, incident := range incidents {
+		fmt.Fprintf(os.Stderr, "  %s\n", incident)
+	}
+}
+
+// printReportParseErrors outputs the problems found in a report as a whole,
+// when parsing leniently
+func printReportParseErrors(s ingest.Summary) {
+	for _, pErr := range s.ReportParseErrors {
+		fmt.Fprintf(os.Stderr, "%s in %s, %s: \"%s\", %s\n", pErr.ErrType,
+			s.File, pErr.Field, pErr.Original, pErr.Corrected)
+	}
+}
+
+// fmtRange formats a report date range for display. Unknown dates are
+// displayed as question marks.
+func fmtRange(start *time.Time, end *time.Time) string {
+	startStr := "?"
+	endStr := "?"
+
+	if start != nil {
+		startStr = start.Format(summaryDateFmt)
+	}
+
+	if end != nil {
+		endStr = end.Format(summaryDateFmt)
+	}
+
+	return fmt.Sprintf("%s - %s", startStr, endStr)
+}
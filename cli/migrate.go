@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/pflag"
+
+	"github.com/Noah-Huppert/crime-map/config"
+	"github.com/Noah-Huppert/crime-map/dstore"
+	"github.com/Noah-Huppert/crime-map/models"
+)
+
+// migrateSteps holds the value of the migrate command's --steps flag
+var migrateSteps uint
+
+// migrateCmd applies, reverts and reports database migrations
+var migrateCmd Command = Command{
+	Name:    "migrate",
+	Usage:   "up|down|status",
+	Summary: "apply, revert or show the status of database migrations",
+	Flags: func(f *pflag.FlagSet) {
+		f.UintVar(&migrateSteps, "steps", 0, "number of migrations to "+
+			"revert when running down, 0 reverts all")
+	},
+	Run: runMigrate,
+}
+
+// runMigrate implements the migrate command
+func runMigrate(ctx context.Context, c *config.Config, args []string) int {
+	// Check action provided
+	if len(args) != 1 {
+		return usageErr("migrate", "expected 1 argument, got %d",
+			len(args))
+	}
+
+	action := args[0]
+	if action != "up" && action != "down" && action != "status" {
+		return usageErr("migrate", "unknown action: %s", action)
+	}
+
+	// Connect to database
+	db, err := dstore.NewDB(c.DB)
+	if err != nil {
+		return runErr("error connecting to database: %s", err.Error())
+	}
+
+	switch action {
+	case "up":
+		fmt.Println("migrating db up")
+
+		if err := models.Migrate(db); err != nil {
+			return runErr("error migrating db: %s", err.Error())
+		}
+	case "down":
+		fmt.Println("migrating db down")
+
+		if err := models.MigrateDown(db, migrateSteps); err != nil {
+			return runErr("error migrating db: %s", err.Error())
+		}
+	case "status":
+		version, dirty, err := models.MigrationVersion(db)
+		if err == models.ErrNoMigrations {
+			fmt.Println("no migrations applied")
+			return ExitOK
+		} else if err != nil {
+			return runErr("error retrieving migration status: %s",
+				err.Error())
+		}
+
+		fmt.Printf("version: %d\ndirty: %t\n", version, dirty)
+	}
+
+	return ExitOK
+}
@@ -0,0 +1,165 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/spf13/pflag"
+
+	"github.com/Noah-Huppert/crime-map/config"
+	"github.com/Noah-Huppert/crime-map/geo"
+	"github.com/Noah-Huppert/crime-map/ingest"
+	"github.com/Noah-Huppert/crime-map/models"
+	"github.com/Noah-Huppert/crime-map/parsers"
+)
+
+// reparseDir holds the value of the reparse command's --dir flag
+var reparseDir string
+
+// reparseCmd deletes a report's crimes and parses them again
+var reparseCmd Command = Command{
+	Name:    "reparse",
+	Usage:   "<report-id> [file]",
+	Summary: "delete a report's crimes and parse its file again",
+	Flags: func(f *pflag.FlagSet) {
+		f.StringVar(&reparseDir, "dir", "data", "directory to find the "+
+			"report's file in, if no file is provided")
+		parseFlags(f)
+	},
+	Run: runReparse,
+}
+
+// runReparse implements the reparse command. The report's file is found by
+// the name it was ingested with, unless a file is provided. The crimes are
+// deleted and parsed again in one transaction, so if parsing fails the
+// report's existing crimes are kept.
+func runReparse(ctx context.Context, c *config.Config, args []string) int {
+	// Check args
+	if len(args) < 1 || len(args) > 2 {
+		return usageErr("reparse", "expected 1 or 2 arguments, got %d",
+			len(args))
+	}
+
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		return usageErr("reparse", "report ID must be an integer: %s",
+			err.Error())
+	}
+
+	// Check parser exists
+	if status := checkParserFlag("reparse"); status != ExitOK {
+		return status
+	}
+
+	// Load table and incident mappings
+	opts, err := ingestOptions(c)
+	if err != nil {
+		return runErr("%s", err.Error())
+	}
+
+	// Connect to database
+	store, err := newStore(c)
+	if err != nil {
+		return runErr("%s", err.Error())
+	}
+
+	// Find report
+	report, err := store.QueryReport(ctx, id)
+	if err == sql.ErrNoRows {
+		return runErr("no report with ID: %d", id)
+	} else if err != nil {
+		return runErr("error querying for report: %s", err.Error())
+	}
+
+	// Find report file
+	var file string
+
+	if len(args) == 2 {
+		file = args[1]
+	} else if len(report.FileName) > 0 {
+		file = filepath.Join(reparseDir, report.FileName)
+	} else {
+		return usageErr("reparse", "report %d was ingested before file "+
+			"names were recorded, its file must be provided",
+			report.ID)
+	}
+
+	// Check file is the report's file
+	hash, _, err := parsers.HashFile(file)
+	if err != nil {
+		return runErr("error hashing report file: %s", err.Error())
+	}
+
+	if len(report.FileHash) > 0 && hash != report.FileHash {
+		return runErr("file is not report %d's file, expected "+
+			"contents of %s (sha256: %s)", report.ID,
+			report.FileName, report.FileHash)
+	}
+
+	// Delete and parse again in one transaction
+	var summary ingest.Summary
+
+	err = store.Tx(ctx, func(tx models.Store) error {
+		// Correct times saved before time zones were recorded. So
+		// the report's range matches the range parsed from its file.
+		if len(report.TimeZone) == 0 {
+			loc, err := report.University.Location()
+			if err != nil {
+				return fmt.Errorf("error finding report's time "+
+					"zone: %s", err.Error())
+			}
+
+			if err = tx.LocalizeReport(ctx, report, loc); err != nil {
+				return fmt.Errorf("error correcting report "+
+					"times: %s", err.Error())
+			}
+		}
+
+		// Delete existing crimes
+		fmt.Printf("deleting crimes for report %d\n", report.ID)
+		if err := tx.DeleteReportCrimes(ctx, report); err != nil {
+			return fmt.Errorf("error deleting report crimes: %s",
+				err.Error())
+		}
+
+		// Parse again
+		fmt.Printf("parsing report: %s\n", file)
+		summary = ingest.File(ctx, tx, geo.NewGeoCache(tx), file, opts)
+		if summary.Err != nil {
+			return fmt.Errorf("error ingesting report, file: %s, "+
+				"err: %s", file, summary.Err.Error())
+		}
+
+		// Check file was for the report. Only possible if report was
+		// parsed before file hashes were recorded.
+		if summary.ReportID != report.ID {
+			return fmt.Errorf("file is for report %d, not report %d",
+				summary.ReportID, report.ID)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return runErr("%s, report %d was not changed", err.Error(),
+			report.ID)
+	}
+
+	fmt.Printf("saved %d crimes\n", summary.Crimes)
+
+	// Note pages which could not be read
+	for _, pErr := range summary.Diagnostics.Failed {
+		fmt.Fprintf(os.Stderr, "error reading %s\n", pErr)
+	}
+
+	// Note parts skipped when parsing leniently
+	printReportParseErrors(summary)
+
+	// Note incidents which were not categorized
+	printUnmapped([]ingest.Summary{summary})
+
+	return ExitOK
+}
@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/Noah-Huppert/crime-map/config"
+	"github.com/Noah-Huppert/crime-map/http"
+)
+
+// serveCmd runs the HTTP API server
+var serveCmd Command = Command{
+	Name:    "serve",
+	Usage:   "",
+	Summary: "start the HTTP API server",
+	Run:     runServe,
+}
+
+// runServe implements the serve command
+func runServe(ctx context.Context, c *config.Config, args []string) int {
+	// Check no args
+	if len(args) != 0 {
+		return usageErr("serve", "expected no arguments, got %d",
+			len(args))
+	}
+
+	// Connect to database
+	store, err := newStore(c)
+	if err != nil {
+		return runErr("%s", err.Error())
+	}
+
+	// Start http server
+	server := http.NewServer(c, store)
+	if err = server.Serve(); err != nil {
+		return runErr("error starting http server: %s", err.Error())
+	}
+
+	return ExitOK
+}
@@ -0,0 +1,403 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cast"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// Configuration keys. Nested keys are separated by periods. When read from
+// the environment the periods are replaced with underscores and the key is
+// upper cased. Ex: db.conn_string => DB_CONN_STRING
+const (
+	// keyEnv holds the configuration key for the application environment
+	keyEnv string = "env"
+
+	// keyFile holds the configuration key for the path of a configuration
+	// file to load
+	keyFile string = "config"
+
+	// keyDebug holds the configuration key for Config.Debug
+	keyDebug string = "debug"
+
+	// keyDBConnString holds the configuration key for
+	// DBConfig.ConnString
+	keyDBConnString string = "db.conn_string"
+
+	// keyDBQueryTimeout holds the configuration key for
+	// DBConfig.QueryTimeout
+	keyDBQueryTimeout string = "db.query_timeout"
+
+	// keyGAPIAPIKey holds the configuration key for GAPIConfig.APIKey
+	keyGAPIAPIKey string = "gapi.api_key"
+
+	// keyGeoBoundsNeLat holds the configuration key for
+	// GeoConfig.BoundsNeLat
+	keyGeoBoundsNeLat string = "geo.bounds_ne_lat"
+
+	// keyGeoBoundsNeLong holds the configuration key for
+	// GeoConfig.BoundsNeLong
+	keyGeoBoundsNeLong string = "geo.bounds_ne_long"
+
+	// keyGeoBoundsSwLat holds the configuration key for
+	// GeoConfig.BoundsSwLat
+	keyGeoBoundsSwLat string = "geo.bounds_sw_lat"
+
+	// keyGeoBoundsSwLong holds the configuration key for
+	// GeoConfig.BoundsSwLong
+	keyGeoBoundsSwLong string = "geo.bounds_sw_long"
+
+	// keyGeoAddrPostfix holds the configuration key for
+	// GeoConfig.AddrPostfix
+	keyGeoAddrPostfix string = "geo.addr_postfix"
+
+	// keyGeoGeocoder holds the configuration key for GeoConfig.Geocoder
+	keyGeoGeocoder string = "geo.geocoder"
+
+	// keyHTTPPort holds the configuration key for HTTPConfig.Port
+	keyHTTPPort string = "http.port"
+
+	// keyHTTPRequestTimeout holds the configuration key for
+	// HTTPConfig.RequestTimeout
+	keyHTTPRequestTimeout string = "http.request_timeout"
+
+	// keyPDFPasswords holds the configuration key for
+	// PDFConfig.Passwords
+	keyPDFPasswords string = "pdf.passwords"
+
+	// keyTableMapping holds the configuration key for TableConfig.Mapping
+	keyTableMapping string = "table.mapping"
+
+	// keyIncidentMapping holds the configuration key for
+	// IncidentConfig.Mapping
+	keyIncidentMapping string = "incident.mapping"
+
+	// keyLogVerbose holds the configuration key for LogConfig.Verbose
+	keyLogVerbose string = "log.verbose"
+)
+
+// envAppEnv is the name of the environment variable which holds the
+// application environment. It is not named ENV to avoid clashing with common
+// shell variables.
+const envAppEnv string = "APP_ENV"
+
+// envConfigFile is the name of the environment variable which holds the path
+// of a configuration file to load
+const envConfigFile string = "APP_CONFIG"
+
+// fileName is the name, without an extension, of the configuration file which
+// is searched for if no explicit file path is provided. Viper will look for
+// any supported extension, ex: config.toml or config.yaml
+const fileName string = "config"
+
+// defaults holds the value each configuration key has if it is not set by
+// a configuration file, environment variable or command line flag. Some
+// values are replaced by the application environment's profile, see profiles.
+var defaults map[string]interface{} = map[string]interface{}{
+	keyEnv:                string(EnvDevelop),
+	keyDebug:              false,
+	keyDBConnString:       "",
+	keyDBQueryTimeout:     "30s",
+	keyGAPIAPIKey:         "",
+	keyGeoBoundsNeLat:     39.9727,
+	keyGeoBoundsNeLong:    -75.1800,
+	keyGeoBoundsSwLat:     39.9467,
+	keyGeoBoundsSwLong:    -75.2106,
+	keyGeoAddrPostfix:     ", Philadelphia, PA",
+	keyGeoGeocoder:        GeocoderGAPI,
+	keyHTTPPort:           8080,
+	keyHTTPRequestTimeout: "10s",
+	keyPDFPasswords:       []string{},
+	keyTableMapping:       "",
+	keyIncidentMapping:    "incidents.toml",
+	keyLogVerbose:         false,
+}
+
+// Flags holds the command line flags which can be used to override
+// configuration values. The caller is responsible for parsing these flags
+// before calling NewConfig. Flags which are not set on the command line will
+// not override values from other sources.
+var Flags *pflag.FlagSet = newFlagSet()
+
+// flagKeys maps command line flag names to the configuration keys they
+// set
+var flagKeys map[string]string = map[string]string{
+	"env":              keyEnv,
+	"config":           keyFile,
+	"debug":            keyDebug,
+	"db-conn-string":   keyDBConnString,
+	"db-timeout":       keyDBQueryTimeout,
+	"gapi-api-key":     keyGAPIAPIKey,
+	"geo-ne-lat":       keyGeoBoundsNeLat,
+	"geo-ne-long":      keyGeoBoundsNeLong,
+	"geo-sw-lat":       keyGeoBoundsSwLat,
+	"geo-sw-long":      keyGeoBoundsSwLong,
+	"geo-postfix":      keyGeoAddrPostfix,
+	"geo-geocoder":     keyGeoGeocoder,
+	"http-port":        keyHTTPPort,
+	"http-timeout":     keyHTTPRequestTimeout,
+	"pdf-passwords":    keyPDFPasswords,
+	"table-mapping":    keyTableMapping,
+	"incident-mapping": keyIncidentMapping,
+	"verbose":          keyLogVerbose,
+}
+
+// instance holds the loaded configuration if already created
+var instance *Config
+
+// instanceLock ensures the configuration is only loaded once when NewConfig is
+// called from multiple goroutines
+var instanceLock sync.Mutex
+
+// Config holds all application configuration
+type Config struct {
+	// Env is the environment the application is running in
+	Env EnvType
+
+	// File is the path of the configuration file values were loaded from.
+	// Empty if no file was loaded.
+	File string
+
+	// Debug indicates if debugging features should be enabled. Not
+	// allowed in production.
+	Debug bool
+
+	// DB holds database configuration
+	DB DBConfig
+
+	// GAPI holds Google API configuration
+	GAPI GAPIConfig
+
+	// Geo holds crime location configuration
+	Geo GeoConfig
+
+	// HTTP holds web server configuration
+	HTTP HTTPConfig
+
+	// PDF holds report file configuration
+	PDF PDFConfig
+
+	// Table holds csv and html report file configuration
+	Table TableConfig
+
+	// Incident holds crime incident normalization configuration
+	Incident IncidentConfig
+
+	// Log holds application output configuration
+	Log LogConfig
+}
+
+// newFlagSet creates the set of command line flags which override
+// configuration values
+func newFlagSet() *pflag.FlagSet {
+	f := pflag.NewFlagSet("config", pflag.ContinueOnError)
+
+	f.String("env", "", "application environment (develop, test, production)")
+	f.String("config", "", "path of configuration file (toml or yaml)")
+	f.Bool("debug", false, "enable debugging features")
+	f.String("db-conn-string", "", "database connection string")
+	f.Duration("db-timeout", 0, "maximum time a database query may run "+
+		"for, 0 for no limit")
+	f.String("gapi-api-key", "", "Google API key")
+	f.Float64("geo-ne-lat", 0, "northeast latitude of geocoding bounds")
+	f.Float64("geo-ne-long", 0, "northeast longitude of geocoding bounds")
+	f.Float64("geo-sw-lat", 0, "southwest latitude of geocoding bounds")
+	f.Float64("geo-sw-long", 0, "southwest longitude of geocoding bounds")
+	f.String("geo-postfix", "", "string appended to addresses before geocoding")
+	f.String("geo-geocoder", "", "geocoder used to locate crimes (gapi, fake)")
+	f.Uint("http-port", 0, "port to serve HTTP content on")
+	f.Duration("http-timeout", 0, "maximum time an HTTP request may be "+
+		"handled for, 0 for no limit")
+	f.StringSlice("pdf-passwords", []string{}, "comma separated "+
+		"passwords to try when a report file is encrypted")
+	f.String("table-mapping", "", "path of file describing the columns "+
+		"of csv and html report files")
+	f.String("incident-mapping", "", "path of file mapping report "+
+		"incidents to incident categories, empty to not categorize")
+	f.Bool("verbose", false, "output detailed progress information")
+
+	return f
+}
+
+// NewConfig loads the application configuration. Values are layered in the
+// following order, each overriding the last:
+//
+//   - Defaults
+//   - Application environment profile defaults, see profiles
+//   - Configuration file, config.{toml,yaml} in the working directory, or
+//     the file specified by the APP_CONFIG environment variable / --config
+//     flag
+//   - Environment variables, ex: APP_ENV, DB_CONN_STRING, HTTP_PORT
+//   - Command line flags, see Flags
+//
+// The configuration is only loaded once, subsequent calls return the same
+// instance. An error is returned if one occurs, including if the resulting
+// configuration is invalid. Nil on success.
+func NewConfig() (*Config, error) {
+	instanceLock.Lock()
+	defer instanceLock.Unlock()
+
+	// Check if exists
+	if instance != nil {
+		return instance, nil
+	}
+
+	// Load
+	c, err := load(Flags)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate
+	if errs := c.Validate(); len(errs) != 0 {
+		errsArr := []string{}
+		for _, err := range errs {
+			errsArr = append(errsArr, err.Error())
+		}
+
+		return nil, fmt.Errorf("invalid configuration: %s",
+			strings.Join(errsArr, ", "))
+	}
+
+	instance = c
+
+	return instance, nil
+}
+
+// load reads configuration values from all sources into a Config. The
+// provided flags are used as the highest precedence source. An error is
+// returned if one occurs, nil on success.
+func load(flags *pflag.FlagSet) (*Config, error) {
+	v := viper.New()
+
+	// Defaults
+	for key, val := range defaults {
+		v.SetDefault(key, val)
+	}
+
+	// Environment variables
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+
+	if err := v.BindEnv(keyEnv, envAppEnv); err != nil {
+		return nil, fmt.Errorf("error binding %s environment variable: %s",
+			envAppEnv, err.Error())
+	}
+
+	if err := v.BindEnv(keyFile, envConfigFile); err != nil {
+		return nil, fmt.Errorf("error binding %s environment variable: %s",
+			envConfigFile, err.Error())
+	}
+
+	// Flags
+	for name, key := range flagKeys {
+		if err := v.BindPFlag(key, flags.Lookup(name)); err != nil {
+			return nil, fmt.Errorf("error binding --%s flag: %s", name,
+				err.Error())
+		}
+	}
+
+	// Configuration file
+	if file := v.GetString(keyFile); len(file) > 0 {
+		// If a file was explicitly specified it must exist
+		v.SetConfigFile(file)
+
+		if err := v.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("error reading configuration file"+
+				", file: %s, err: %s", file, err.Error())
+		}
+	} else {
+		// Otherwise look for an optional file
+		v.SetConfigName(fileName)
+		v.AddConfigPath(".")
+
+		err := v.ReadInConfig()
+		if _, ok := err.(viper.ConfigFileNotFoundError); err != nil && !ok {
+			return nil, fmt.Errorf("error reading configuration "+
+				"file: %s", err.Error())
+		}
+	}
+
+	// Environment
+	env, err := NewEnvType(v.GetString(keyEnv))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing application environment: %s",
+			err.Error())
+	}
+
+	// Apply environment profile. Defaults have the lowest precedence so
+	// this can be done after other sources have been read.
+	for key, val := range profiles[env] {
+		v.SetDefault(key, val)
+	}
+
+	// Durations, parsed here so invalid values are not silently
+	// treated as 0
+	dbQueryTimeout, err := cast.ToDurationE(v.Get(keyDBQueryTimeout))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s: %s", keyDBQueryTimeout,
+			err.Error())
+	}
+
+	httpRequestTimeout, err := cast.ToDurationE(
+		v.Get(keyHTTPRequestTimeout))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s: %s",
+			keyHTTPRequestTimeout, err.Error())
+	}
+
+	// Build
+	return &Config{
+		Env:   env,
+		File:  v.ConfigFileUsed(),
+		Debug: v.GetBool(keyDebug),
+		DB: DBConfig{
+			ConnString:   v.GetString(keyDBConnString),
+			QueryTimeout: dbQueryTimeout,
+		},
+		GAPI: GAPIConfig{
+			APIKey: v.GetString(keyGAPIAPIKey),
+		},
+		Geo: GeoConfig{
+			BoundsNeLat:  v.GetFloat64(keyGeoBoundsNeLat),
+			BoundsNeLong: v.GetFloat64(keyGeoBoundsNeLong),
+			BoundsSwLat:  v.GetFloat64(keyGeoBoundsSwLat),
+			BoundsSwLong: v.GetFloat64(keyGeoBoundsSwLong),
+			AddrPostfix:  v.GetString(keyGeoAddrPostfix),
+			Geocoder:     v.GetString(keyGeoGeocoder),
+		},
+		HTTP: HTTPConfig{
+			Port:           uint(v.GetInt(keyHTTPPort)),
+			RequestTimeout: httpRequestTimeout,
+		},
+		PDF: PDFConfig{
+			Passwords: v.GetStringSlice(keyPDFPasswords),
+		},
+		Table: TableConfig{
+			Mapping: v.GetString(keyTableMapping),
+		},
+		Incident: IncidentConfig{
+			Mapping: v.GetString(keyIncidentMapping),
+		},
+		Log: LogConfig{
+			Verbose: v.GetBool(keyLogVerbose),
+		},
+	}, nil
+}
+
+// Validate checks that all configuration values are acceptable. All problems
+// found are returned, an empty slice indicates the configuration is valid.
+func (c Config) Validate() []error {
+	errs := []error{}
+
+	errs = append(errs, c.DB.Validate()...)
+	errs = append(errs, c.Geo.Validate()...)
+	errs = append(errs, c.HTTP.Validate()...)
+	errs = append(errs, c.validateEnv()...)
+
+	return errs
+}
@@ -1,8 +1,37 @@
 package config
 
+import (
+	"errors"
+	"time"
+)
+
 // DBConfig holds database configuration values
 type DBConfig struct {
 	// ConnString holds the connection string used to connect to the
 	// database
 	ConnString string
+
+	// QueryTimeout is the maximum amount of time a database query may run
+	// for. 0 if queries may run for any amount of time.
+	QueryTimeout time.Duration
+}
+
+// Validate checks the database configuration. All problems found are
+// returned, an empty slice indicates the configuration is valid.
+func (c DBConfig) Validate() []error {
+	errs := []error{}
+
+	// Check connection string provided
+	if len(c.ConnString) == 0 {
+		errs = append(errs, errors.New("db connection string must not "+
+			"be empty"))
+	}
+
+	// Check timeout not negative
+	if c.QueryTimeout < 0 {
+		errs = append(errs, errors.New("db query timeout must not be "+
+			"negative"))
+	}
+
+	return errs
 }
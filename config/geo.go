@@ -1,9 +1,19 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"googlemaps.github.io/maps"
 )
 
+// GeocoderGAPI indicates that the Google Maps Geocoding API should be used to
+// locate crimes
+const GeocoderGAPI string = "gapi"
+
+// GeocoderFake indicates that crimes should be given made up locations
+// inside the configured bounds. Used so tests do not make GAPI requests.
+const GeocoderFake string = "fake"
+
 // GeoConfig holds configuration related to locating crimes from reports
 type GeoConfig struct {
 	// BoundsNeLat holds the northeast bounds latitude of the area to look
@@ -25,6 +35,10 @@ type GeoConfig struct {
 	// AddrPostfix is the string appended to the end of crime address
 	// before attempting to locate it on a map
 	AddrPostfix string
+
+	// Geocoder is the name of the geocoder used to locate crimes. One of
+	// GeocoderGAPI or GeocoderFake.
+	Geocoder string
 }
 
 // MakeMapsBounds constructs a Google Maps map.LatLngBounds struct from the
@@ -42,3 +56,30 @@ func (c GeoConfig) MakeMapsBounds() *maps.LatLngBounds {
 		},
 	}
 }
+
+// Validate checks the geographic configuration. All problems found are
+// returned, an empty slice indicates the configuration is valid.
+func (c GeoConfig) Validate() []error {
+	errs := []error{}
+
+	// Check northeast corner is north of southwest corner
+	if c.BoundsNeLat <= c.BoundsSwLat {
+		errs = append(errs, errors.New("geo bounds northeast latitude "+
+			"must be greater than southwest latitude"))
+	}
+
+	// Check northeast corner is east of southwest corner
+	if c.BoundsNeLong <= c.BoundsSwLong {
+		errs = append(errs, errors.New("geo bounds northeast longitude "+
+			"must be greater than southwest longitude"))
+	}
+
+	// Check geocoder is known
+	if c.Geocoder != GeocoderGAPI && c.Geocoder != GeocoderFake {
+		errs = append(errs, fmt.Errorf("geo geocoder must be one of %s"+
+			" or %s, was: %s", GeocoderGAPI, GeocoderFake,
+			c.Geocoder))
+	}
+
+	return errs
+}
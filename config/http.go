@@ -1,7 +1,42 @@
 package config
 
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// maxPort is the largest valid system network port
+const maxPort uint = 65535
+
 // HTTPConfig holds web server related configuration
 type HTTPConfig struct {
 	// Port is the system network port to serve HTTP content on
 	Port uint
+
+	// RequestTimeout is the maximum amount of time a request may be
+	// handled for. Database queries made while handling the request are
+	// canceled once it passes. 0 if requests may be handled for any
+	// amount of time.
+	RequestTimeout time.Duration
+}
+
+// Validate checks the web server configuration. All problems found are
+// returned, an empty slice indicates the configuration is valid.
+func (c HTTPConfig) Validate() []error {
+	errs := []error{}
+
+	// Check port in range
+	if c.Port == 0 || c.Port > maxPort {
+		errs = append(errs, fmt.Errorf("http port must be between 1 and"+
+			" %d, was: %d", maxPort, c.Port))
+	}
+
+	// Check timeout not negative
+	if c.RequestTimeout < 0 {
+		errs = append(errs, errors.New("http request timeout must not "+
+			"be negative"))
+	}
+
+	return errs
 }
@@ -0,0 +1,9 @@
+package config
+
+// IncidentConfig holds configuration related to normalizing crime incidents
+type IncidentConfig struct {
+	// Mapping is the path of a file with rules which map the incidents
+	// written in reports to incident categories. Empty if crimes should
+	// not be categorized.
+	Mapping string
+}
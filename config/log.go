@@ -0,0 +1,8 @@
+package config
+
+// LogConfig holds configuration related to application output
+type LogConfig struct {
+	// Verbose indicates if detailed progress information should be
+	// output
+	Verbose bool
+}
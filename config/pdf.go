@@ -0,0 +1,9 @@
+package config
+
+// PDFConfig holds configuration related to reading report pdf files
+type PDFConfig struct {
+	// Passwords holds candidate passwords for encrypted report files.
+	// The empty password is always tried first, then each of these in
+	// order.
+	Passwords []string
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+// profile holds the configuration defaults for an application environment.
+// These replace the general defaults, but are still overridden by values from
+// a configuration file, environment variables and command line flags.
+type profile map[string]interface{}
+
+// profiles holds the configuration defaults for each application environment
+var profiles map[EnvType]profile = map[EnvType]profile{
+	EnvDevelop: profile{
+		keyDBConnString: "host=localhost port=5432 user=develop " +
+			"password=develop dbname=crime-map-develop sslmode=disable",
+		keyGeoGeocoder: GeocoderGAPI,
+		keyLogVerbose:  true,
+		keyDebug:       true,
+	},
+	EnvTest: profile{
+		keyDBConnString: "host=localhost port=5432 user=test " +
+			"password=test dbname=crime-map-test sslmode=disable",
+		keyGeoGeocoder: GeocoderFake,
+		keyLogVerbose:  false,
+		keyDebug:       false,
+	},
+	EnvProd: profile{
+		keyDBConnString: "",
+		keyGeoGeocoder:  GeocoderGAPI,
+		keyLogVerbose:   false,
+		keyDebug:        false,
+	},
+}
+
+// validateEnv checks that configuration values are allowed in the
+// configuration's application environment. All problems found are returned,
+// an empty slice indicates the configuration is valid.
+func (c Config) validateEnv() []error {
+	errs := []error{}
+
+	// Check production does not have debug settings
+	if c.Env == EnvProd {
+		if c.Debug {
+			errs = append(errs, errors.New("debug mode can not be "+
+				"enabled in production"))
+		}
+
+		if c.Log.Verbose {
+			errs = append(errs, errors.New("verbose logging can not "+
+				"be enabled in production"))
+		}
+
+		if c.Geo.Geocoder == GeocoderFake {
+			errs = append(errs, fmt.Errorf("%s geocoder can not be "+
+				"used in production", GeocoderFake))
+		}
+	}
+
+	// Check test does not make real geocoding requests
+	if c.Env == EnvTest && c.Geo.Geocoder != GeocoderFake {
+		errs = append(errs, fmt.Errorf("only the %s geocoder can be used"+
+			" in test", GeocoderFake))
+	}
+
+	return errs
+}
@@ -0,0 +1,9 @@
+package config
+
+// TableConfig holds configuration related to reading csv and html report files
+type TableConfig struct {
+	// Mapping is the path of a file describing which columns of csv and
+	// html report files hold each crime field. Empty if these files
+	// should not be read.
+	Mapping string
+}
@@ -0,0 +1,74 @@
+package date
+
+import (
+	"fmt"
+	"time"
+)
+
+// Local creates a time from a wall clock date and time in the provided
+// location.
+//
+// When clocks are set back at the end of daylight saving time a range of wall
+// clock times occurs twice, ex: 01:30 on the first Sunday in November in
+// America/New_York. These times are interpreted as their first occurrence,
+// before clocks were set back. Wall clock times which are skipped when clocks
+// are set forward are normalized by time.Date.
+func Local(year int, month time.Month, day, hour, min, sec int, loc *time.Location) time.Time {
+	t := time.Date(year, month, day, hour, min, sec, 0, loc)
+
+	// Check if clocks were set back in the last day, if so the wall clock
+	// time may have also occurred before they were
+	_, offset := t.Zone()
+	_, prevOffset := t.Add(-24 * time.Hour).Zone()
+
+	if prevOffset <= offset {
+		return t
+	}
+
+	earlier := t.Add(-time.Duration(prevOffset-offset) * time.Second)
+
+	if earlier.Year() == year && earlier.Month() == month &&
+		earlier.Day() == day && earlier.Hour() == hour &&
+		earlier.Minute() == min && earlier.Second() == sec {
+		return earlier
+	}
+
+	return t
+}
+
+// Relocate interprets the wall clock date and time of t in UTC as a wall clock
+// date and time in the provided location, see Local. Used to correct times
+// which were written in the location, but saved as if they were written in
+// UTC.
+func Relocate(t time.Time, loc *time.Location) time.Time {
+	t = t.UTC()
+
+	return Local(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(),
+		t.Second(), loc)
+}
+
+// Check returns an error if a wall clock date and time does not exist on the
+// calendar or clock, ex: month 13, February 30th, or 25:70. Which time.Date,
+// and so Local, would silently normalize into a different date.
+func Check(year int, month time.Month, day, hour, min int) error {
+	if month < time.January || month > time.December {
+		return fmt.Errorf("month must be 1-12: %d", month)
+	}
+
+	// Day 0 of the next month is the last day of this month
+	days := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	if day < 1 || day > days {
+		return fmt.Errorf("day must be 1-%d in %s %d: %d", days,
+			month, year, day)
+	}
+
+	if hour < 0 || hour > 23 {
+		return fmt.Errorf("hour must be 0-23: %d", hour)
+	}
+
+	if min < 0 || min > 59 {
+		return fmt.Errorf("minute must be 0-59: %d", min)
+	}
+
+	return nil
+}
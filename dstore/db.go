@@ -1,32 +1,18 @@
 package dstore
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"github.com/Noah-Huppert/crime-map/config"
 	_ "github.com/lib/pq"
 )
 
-// instance holds the database instance if already created
-var instance *sql.DB
-
-// NewDB creates a new connected DB instance and returns it. Along with an
-// error if one occurs. Or nil on success.
-func NewDB() (*sql.DB, error) {
-	// Check if exists
-	if instance != nil {
-		return instance, nil
-	}
-
-	// Get db config
-	c, err := config.NewConfig()
-	if err != nil {
-		return nil, fmt.Errorf("error loading configuration: %s",
-			err.Error())
-	}
-
+// NewDB creates a new DB instance connected to the configured database and
+// returns it. Along with an error if one occurs. Or nil on success.
+func NewDB(c config.DBConfig) (*sql.DB, error) {
 	// Connect
-	db, err := sql.Open("postgres", c.DB.ConnString)
+	db, err := sql.Open("postgres", c.ConnString)
 
 	if err != nil {
 		return nil, fmt.Errorf("error connecting to db: %s", err.Error())
@@ -38,7 +24,22 @@ func NewDB() (*sql.DB, error) {
 			err.Error())
 	}
 
-	instance = db
+	return db, nil
+}
 
-	return instance, nil
+// Querier runs SQL statements. It is implemented by *sql.DB and *sql.Tx, so
+// the same code can be run inside or outside of a transaction. Statements are
+// canceled if their context is canceled.
+type Querier interface {
+	// ExecContext runs a statement which does not return rows
+	ExecContext(ctx context.Context, query string,
+		args ...interface{}) (sql.Result, error)
+
+	// QueryContext runs a statement which returns rows
+	QueryContext(ctx context.Context, query string,
+		args ...interface{}) (*sql.Rows, error)
+
+	// QueryRowContext runs a statement which returns at most one row
+	QueryRowContext(ctx context.Context, query string,
+		args ...interface{}) *sql.Row
 }
@@ -1,31 +1,68 @@
 package geo
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"sync"
 
 	"github.com/Noah-Huppert/crime-map/models"
 )
 
-// GeoCache caches GeoLoc models retrieved from the database
+// GeoCache caches GeoLoc models retrieved from the database. It is safe for use
+// by multiple goroutines.
 type GeoCache struct {
+	// store is used to retrieve and save GeoLoc models
+	store models.GeoLocStore
+
 	// locs holds all GeoLoc models retrieved from the database
 	locs map[string]*models.GeoLoc
+
+	// lock controls access to locs
+	lock sync.Mutex
 }
 
-// NewGeoCache constructs a new GeoCache object
-func NewGeoCache() *GeoCache {
+// NewGeoCache constructs a new GeoCache object which retrieves GeoLoc models
+// from the provided store
+func NewGeoCache(store models.GeoLocStore) *GeoCache {
 	return &GeoCache{
-		locs: make(map[string]*models.GeoLoc),
+		store: store,
+		locs:  make(map[string]*models.GeoLoc),
 	}
 }
 
 // Get retrieves a GeoCache model with the provided raw value. This model will
 // be populated with the raw and ID field only. An error is returned if one
 // occurs, or nil on success.
-func (c GeoCache) Get(raw string) (*models.GeoLoc, error) {
+func (c *GeoCache) Get(ctx context.Context, raw string) (*models.GeoLoc, error) {
+	return c.get(ctx, c.store, raw)
+}
+
+// cached retrieves a GeoLoc model from the cache. A boolean indicating if one
+// was found is returned.
+func (c *GeoCache) cached(raw string) (*models.GeoLoc, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	loc, ok := c.locs[raw]
+
+	return loc, ok
+}
+
+// add caches GeoLoc models
+func (c *GeoCache) add(locs ...*models.GeoLoc) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for _, loc := range locs {
+		c.locs[loc.Raw] = loc
+	}
+}
+
+// get implements Get, querying with the provided store
+func (c *GeoCache) get(ctx context.Context, store models.GeoLocStore, raw string) (*models.GeoLoc, error) {
 	// Check cached in locs var
-	if val, ok := c.locs[raw]; ok {
+	if val, ok := c.cached(raw); ok {
 		return val, nil
 	}
 
@@ -34,7 +71,7 @@ func (c GeoCache) Get(raw string) (*models.GeoLoc, error) {
 	loc := models.NewGeoLoc(raw)
 
 	// Query
-	err := loc.Query()
+	err := store.QueryGeoLoc(ctx, loc)
 
 	// Check if not found
 	if err == sql.ErrNoRows {
@@ -47,7 +84,7 @@ func (c GeoCache) Get(raw string) (*models.GeoLoc, error) {
 	}
 
 	// If found, cache in locs var
-	c.locs[raw] = loc
+	c.add(loc)
 
 	// Success
 	return loc, nil
@@ -56,23 +93,114 @@ func (c GeoCache) Get(raw string) (*models.GeoLoc, error) {
 // InsertIfNew inserts the GeoLoc model into the database if it does not exist.
 // The ID of the model in the database will be set in the GeoLoc.ID field. An
 // error is returned if one occurs, nil on success.
-func (c GeoCache) InsertIfNew(raw string) (*models.GeoLoc, error) {
-	// Query
-	loc, err := c.Get(raw)
+//
+// To insert GeoLoc models as part of a transaction use Begin instead.
+// Otherwise the cache could hold models which were rolled back.
+func (c *GeoCache) InsertIfNew(ctx context.Context, raw string) (*models.GeoLoc, error) {
+	// Check cached in locs var
+	if loc, ok := c.cached(raw); ok {
+		return loc, nil
+	}
 
-	// Check if model doesn't exist
-	if err == sql.ErrNoRows {
-		// Insert
-		if err = loc.Insert(); err != nil {
-			return nil, fmt.Errorf("error inserting non-existent GeoLoc"+
-				" model: %s", err.Error())
-		}
-	} else if err != nil {
-		// General error
-		return nil, fmt.Errorf("error querying for GeoLoc model: %s",
+	// Insert, or find if another goroutine inserted it first
+	loc := models.NewGeoLoc(raw)
+
+	if err := c.store.InsertGeoLocIfNew(ctx, loc); err != nil {
+		return nil, fmt.Errorf("error querying/inserting GeoLoc model: %s",
+			err.Error())
+	}
+
+	// Cache so other reports do not query for it again
+	c.add(loc)
+
+	// Success
+	return loc, nil
+}
+
+// Begin starts using the cache with a Store transaction. Many GeoCacheTx can
+// be open at once. The returned GeoCacheTx must be committed or rolled back
+// when the transaction finishes.
+func (c *GeoCache) Begin(tx models.GeoLocStore) *GeoCacheTx {
+	return &GeoCacheTx{
+		cache:    c,
+		tx:       tx,
+		inserted: make(map[string]*models.GeoLoc),
+	}
+}
+
+// GeoCacheTx uses a GeoCache while inserting GeoLoc models in a database
+// transaction. GeoLoc models inserted by the transaction are only added to the
+// GeoCache once the transaction is committed.
+type GeoCacheTx struct {
+	// cache is the GeoCache which existing GeoLoc models are retrieved from
+	cache *GeoCache
+
+	// tx is the Store transaction GeoLoc models are queried and inserted
+	// with
+	tx models.GeoLocStore
+
+	// inserted holds GeoLoc models which were inserted by the transaction
+	inserted map[string]*models.GeoLoc
+
+	// done indicates if the transaction has been committed or rolled back
+	done bool
+}
+
+// InsertIfNew inserts the GeoLoc model into the database transaction if it does
+// not exist. The ID of the model in the database will be set in the GeoLoc.ID
+// field. An error is returned if one occurs, nil on success.
+//
+// The raw column is unique, so if another transaction is inserting the same
+// raw location this waits until it finishes, then uses its row if it was
+// committed.
+func (t *GeoCacheTx) InsertIfNew(ctx context.Context, raw string) (*models.GeoLoc, error) {
+	// Check if inserted by this transaction
+	if loc, ok := t.inserted[raw]; ok {
+		return loc, nil
+	}
+
+	// Check cached in GeoCache
+	if loc, ok := t.cache.cached(raw); ok {
+		return loc, nil
+	}
+
+	// Insert, or find if it already exists
+	loc := models.NewGeoLoc(raw)
+
+	if err := t.tx.InsertGeoLocIfNew(ctx, loc); err != nil {
+		return nil, fmt.Errorf("error querying/inserting GeoLoc model: %s",
 			err.Error())
 	}
 
+	// May have existed before the transaction, but only cache once
+	// committed in case it did not
+	t.inserted[raw] = loc
+
 	// Success
 	return loc, nil
 }
+
+// Commit adds the GeoLoc models inserted by the transaction to the GeoCache.
+// Must be called after the database transaction has been committed.
+func (t *GeoCacheTx) Commit() {
+	if t.done {
+		return
+	}
+
+	for _, loc := range t.inserted {
+		t.cache.add(loc)
+	}
+
+	t.done = true
+}
+
+// Rollback discards the GeoLoc models inserted by the transaction. Must be
+// called after the database transaction has been rolled back. Does nothing if
+// the GeoCacheTx has already been committed, so it can be deferred.
+func (t *GeoCacheTx) Rollback() {
+	if t.done {
+		return
+	}
+
+	t.done = true
+}
@@ -0,0 +1,80 @@
+package geo
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+
+	"googlemaps.github.io/maps"
+
+	"github.com/Noah-Huppert/crime-map/config"
+	"github.com/Noah-Huppert/crime-map/gapi"
+)
+
+// Geocoder converts addresses into locations on a map. The Google Maps API
+// client implements this interface.
+type Geocoder interface {
+	// Geocode finds locations which match the request's address
+	Geocode(ctx context.Context, r *maps.GeocodingRequest) ([]maps.GeocodingResult, error)
+}
+
+// NewGeocoder creates the Geocoder specified by the GeoConfig.Geocoder
+// configuration value. An error is returned if one occurs, nil on success.
+func NewGeocoder(c *config.Config) (Geocoder, error) {
+	switch c.Geo.Geocoder {
+	case config.GeocoderGAPI:
+		client, err := gapi.NewClient(c.GAPI)
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving GAPI client: %s",
+				err.Error())
+		}
+
+		return client, nil
+	case config.GeocoderFake:
+		return FakeGeocoder{}, nil
+	default:
+		return nil, fmt.Errorf("unknown geocoder: %s", c.Geo.Geocoder)
+	}
+}
+
+// FakeGeocoder implements Geocoder without making any network requests. It
+// places each address at a made up location inside the request bounds. The
+// same address will always be placed at the same location.
+type FakeGeocoder struct{}
+
+// Geocode implements Geocoder.Geocode for FakeGeocoder
+func (g FakeGeocoder) Geocode(ctx context.Context, r *maps.GeocodingRequest) ([]maps.GeocodingResult, error) {
+	// Check request has bounds to place address in
+	if r.Bounds == nil {
+		return nil, fmt.Errorf("fake geocoder requires request bounds")
+	}
+
+	// Hash address so the same one always ends up in the same place
+	h := fnv.New32a()
+	h.Write([]byte(r.Address))
+	sum := h.Sum32()
+
+	// Pick position inside bounds
+	latFrac := float64(sum&0xffff) / 0xffff
+	lngFrac := float64(sum>>16) / 0xffff
+
+	ne := r.Bounds.NorthEast
+	sw := r.Bounds.SouthWest
+
+	loc := maps.LatLng{
+		Lat: sw.Lat + ((ne.Lat - sw.Lat) * latFrac),
+		Lng: sw.Lng + ((ne.Lng - sw.Lng) * lngFrac),
+	}
+
+	return []maps.GeocodingResult{
+		maps.GeocodingResult{
+			FormattedAddress: r.Address,
+			Geometry: maps.AddressGeometry{
+				Location:     loc,
+				LocationType: "APPROXIMATE",
+				Viewport:     *r.Bounds,
+			},
+			PlaceID: fmt.Sprintf("fake-%x", sum),
+		},
+	}, nil
+}
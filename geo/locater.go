@@ -8,7 +8,6 @@ import (
 	"strings"
 
 	"github.com/Noah-Huppert/crime-map/config"
-	"github.com/Noah-Huppert/crime-map/gapi"
 	"github.com/Noah-Huppert/crime-map/models"
 )
 
@@ -20,13 +19,28 @@ const region string = "us"
 // crime's location is unknown
 const unknownLocRaw string = "UNKNOWN LOCATION - Non-reportable Location"
 
-// Locater uses the Google Maps API to determine exactly where new GeoLoc
-// models are in the world
-type Locater struct{}
+// Locater uses a Geocoder, usually the Google Maps API, to determine exactly
+// where new GeoLoc models are in the world
+type Locater struct {
+	// store is used to save the GeoBound models of located GeoLocs
+	store models.GeoLocStore
 
-// NewLocater creates a new Locater instance
-func NewLocater() *Locater {
-	return &Locater{}
+	// geocoder is used to find locations
+	geocoder Geocoder
+
+	// config holds the area locations are searched for in
+	config config.GeoConfig
+}
+
+// NewLocater creates a new Locater instance which finds locations with the
+// provided geocoder, inside the configured area. GeoBound models are saved in
+// the provided store.
+func NewLocater(store models.GeoLocStore, geocoder Geocoder, c config.GeoConfig) *Locater {
+	return &Locater{
+		store:    store,
+		geocoder: geocoder,
+		config:   c,
+	}
 }
 
 // Locate determines where a GeoLoc model resides on the map. Determining
@@ -48,20 +62,6 @@ func (l Locater) Locate(ctx context.Context, loc *models.GeoLoc) error {
 		return nil
 	}
 
-	// Get api client
-	client, err := gapi.NewClient()
-	if err != nil {
-		return fmt.Errorf("error retrieving GAPI client: %s",
-			err.Error())
-	}
-
-	// Get configuration
-	c, err := config.NewConfig()
-	if err != nil {
-		return fmt.Errorf("error retrieving configuration: %s",
-			err.Error())
-	}
-
 	// Trim raw location string
 	// Usually in form:
 	// 	<actual addr> - <addr annotation>
@@ -72,17 +72,17 @@ func (l Locater) Locate(ctx context.Context, loc *models.GeoLoc) error {
 	locStr = strings.Split(locStr, " (")[0]
 
 	// Add a postfix to the address to zero in on the area
-	locStr += c.Geo.AddrPostfix
+	locStr += l.config.AddrPostfix
 
 	// Construct Geocode request
 	req := maps.GeocodingRequest{
 		Address: locStr,
 		Region:  region,
-		Bounds:  c.Geo.MakeMapsBounds(),
+		Bounds:  l.config.MakeMapsBounds(),
 	}
 
 	// Make Geocode request
-	res, err := client.Geocode(ctx, &req)
+	res, err := l.geocoder.Geocode(ctx, &req)
 	if err != nil {
 		// Indicate geocoding failed
 		loc.GAPISuccess = false
@@ -122,7 +122,7 @@ func (l Locater) Locate(ctx context.Context, loc *models.GeoLoc) error {
 
 	// Insert bounds if provided
 	if loc.BoundsProvided {
-		if err = bounds.InsertIfNew(); err != nil {
+		if err = l.store.InsertGeoBoundIfNew(ctx, bounds); err != nil {
 			return fmt.Errorf("error querying/inserting location "+
 				"bounds: %s", err.Error())
 		}
@@ -135,7 +135,7 @@ func (l Locater) Locate(ctx context.Context, loc *models.GeoLoc) error {
 
 	// Viewport bounds
 	viewBounds := models.GeoBoundFromMapsBound(best.Geometry.Viewport)
-	if err = viewBounds.InsertIfNew(); err != nil {
+	if err = l.store.InsertGeoBoundIfNew(ctx, viewBounds); err != nil {
 		return fmt.Errorf("error querying/inserting viewport bounds: %s",
 			err.Error())
 	}
@@ -34,7 +34,10 @@ const RespKeyCrimes string = "crimes"
 // 	- limit (uint): Index of last element to return.
 //	- order_by (date_occurred|date_reported): Specifies how to order
 //					          returned results.
-type GetCrimesHandler struct{}
+type GetCrimesHandler struct {
+	// store is used to retrieve crimes
+	store models.CrimeStore
+}
 
 // Register implements Registerable for GetCrimesHandler
 func (h GetCrimesHandler) Register(r *mux.Router) error {
@@ -46,7 +49,7 @@ func (h GetCrimesHandler) Register(r *mux.Router) error {
 			QueryParamLimitKey)).
 		Queries(QueryParamOrderByKey, fmt.Sprintf("{%s:.+}",
 			QueryParamOrderByKey)).
-		Handler(GetCrimesHandler{})
+		Handler(h)
 
 	return nil
 }
@@ -69,7 +72,7 @@ func (h GetCrimesHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	}
 
 	// Query
-	crimes, err := models.QueryAllCrimes(offset, limit, orderBy)
+	crimes, err := h.store.QueryAllCrimes(req.Context(), offset, limit, orderBy)
 	if err != nil {
 		WriteErr(w, fmt.Errorf("error querying for crimes: %s",
 			err.Error()))
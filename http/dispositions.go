@@ -0,0 +1,59 @@
+package http
+
+import (
+	"fmt"
+	"github.com/gorilla/mux"
+	"net/http"
+
+	"github.com/Noah-Huppert/crime-map/models"
+)
+
+// RespKeyDispositions holds the key which DispositionChange models will be
+// returned in
+const RespKeyDispositions string = "dispositions"
+
+// GetDispositionHistoryHandler lists the changes in disposition of one crime,
+// across the reports it was listed in. It expects the same query parameters
+// as GetCrimeHistoryHandler.
+type GetDispositionHistoryHandler struct {
+	// store is used to retrieve crime revisions
+	store models.CrimeStore
+}
+
+// Register implements Registerable for GetDispositionHistoryHandler
+func (h GetDispositionHistoryHandler) Register(r *mux.Router) error {
+	r.Path("/api/v1/dispositions").
+		Methods("GET").
+		Queries(QueryParamUniversityKey, fmt.Sprintf("{%s:.+}",
+			QueryParamUniversityKey)).
+		Queries(QueryParamReportNumberKey, fmt.Sprintf("{%s:.+}",
+			QueryParamReportNumberKey)).
+		Handler(h)
+
+	return nil
+}
+
+// ServeHTTP implements the serve method for http.Handler. Changes are
+// returned oldest first.
+func (h GetDispositionHistoryHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	// Find crime
+	crime, errs := findCrime(h.store, req)
+	if len(errs) != 0 {
+		WriteErr(w, errs...)
+		return
+	}
+
+	// Query
+	revisions, err := h.store.QueryCrimeRevisions(req.Context(), crime.ID)
+	if err != nil {
+		WriteErr(w, fmt.Errorf("error querying for crime revisions: %s",
+			err.Error()))
+		return
+	}
+
+	// Response
+	resp := make(map[string]interface{})
+	resp[RespKeyDispositions] = models.NewDispositionChanges(revisions)
+
+	WriteResp(w, resp)
+}
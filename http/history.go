@@ -0,0 +1,151 @@
+package http
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"net/http"
+
+	"github.com/Noah-Huppert/crime-map/models"
+)
+
+// QueryParamUniversityKey holds the key which the university query parameter
+// will be passed by
+const QueryParamUniversityKey string = "university"
+
+// QueryParamReportNumberKey holds the key which the report number query
+// parameter will be passed by
+const QueryParamReportNumberKey string = "report_number"
+
+// RespKeyCrimeID holds the key which the ID of a crime will be returned in
+const RespKeyCrimeID string = "crime_id"
+
+// RespKeySightings holds the key which CrimeSighting models will be returned
+// in
+const RespKeySightings string = "sightings"
+
+// RespKeyRevisions holds the key which CrimeRevision models will be returned
+// in
+const RespKeyRevisions string = "revisions"
+
+// GetCrimeHistoryHandler lists the reports one crime was listed in, and the
+// revisions of its fields between them. It expects the following query
+// parameters:
+//
+//   - university (string): Institution which published the crime's
+//     reports.
+//   - report_number (string): Police report ID of the crime, the two
+//     parts separated by a dash. Ex: 1710-05589.
+type GetCrimeHistoryHandler struct {
+	// store is used to retrieve sightings and revisions
+	store models.CrimeStore
+}
+
+// Register implements Registerable for GetCrimeHistoryHandler
+func (h GetCrimeHistoryHandler) Register(r *mux.Router) error {
+	r.Path("/api/v1/crimes/history").
+		Methods("GET").
+		Queries(QueryParamUniversityKey, fmt.Sprintf("{%s:.+}",
+			QueryParamUniversityKey)).
+		Queries(QueryParamReportNumberKey, fmt.Sprintf("{%s:.+}",
+			QueryParamReportNumberKey)).
+		Handler(h)
+
+	return nil
+}
+
+// ServeHTTP implements the serve method for http.Handler. Sightings and
+// revisions are returned oldest first.
+func (h GetCrimeHistoryHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	// Find crime
+	crime, errs := findCrime(h.store, req)
+	if len(errs) != 0 {
+		WriteErr(w, errs...)
+		return
+	}
+
+	// Query
+	sightings, err := h.store.QueryCrimeSightings(req.Context(), crime.ID)
+	if err != nil {
+		WriteErr(w, fmt.Errorf("error querying for crime sightings: %s",
+			err.Error()))
+		return
+	}
+
+	revisions, err := h.store.QueryCrimeRevisions(req.Context(), crime.ID)
+	if err != nil {
+		WriteErr(w, fmt.Errorf("error querying for crime revisions: %s",
+			err.Error()))
+		return
+	}
+
+	// Response
+	resp := make(map[string]interface{})
+	resp[RespKeyCrimeID] = crime.ID
+	resp[RespKeySightings] = sightings
+	resp[RespKeyRevisions] = revisions
+
+	WriteResp(w, resp)
+}
+
+// findCrime finds the crime identified by the 'university' and
+// 'report_number' query parameters of the request. Only the Crime.ID,
+// Crime.University, Crime.ReportSuperID and Crime.ReportSubID fields are set.
+// Returned along with an array of errors that may have occurred. This will
+// be len = 0 on success.
+func findCrime(store models.CrimeStore, req *http.Request) (*models.Crime, []error) {
+	// Record any errors
+	errs := []error{}
+
+	// Get vars
+	vars := mux.Vars(req)
+	crime := &models.Crime{}
+
+	// If university query provided
+	if query, ok := vars[QueryParamUniversityKey]; ok {
+		val, err := models.NewUniversityType(query)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error parsing "+
+				"'university' query parameter: %s", err.Error()))
+		} else {
+			crime.University = val
+		}
+	} else {
+		errs = append(errs, errors.New("'university' query parameter "+
+			"must be provided"))
+	}
+
+	// If report number query provided
+	if query, ok := vars[QueryParamReportNumberKey]; ok {
+		var rest string
+
+		n, _ := fmt.Sscanf(query, "%d-%d%s", &crime.ReportSuperID,
+			&crime.ReportSubID, &rest)
+		if n != 2 {
+			errs = append(errs, fmt.Errorf("error parsing "+
+				"'report_number' query parameter, expected 2 "+
+				"numbers separated by a dash: %s", query))
+		}
+	} else {
+		errs = append(errs, errors.New("'report_number' query "+
+			"parameter must be provided"))
+	}
+
+	if len(errs) != 0 {
+		return nil, errs
+	}
+
+	// Query
+	err := store.QueryCrime(req.Context(), crime)
+	if err == sql.ErrNoRows {
+		return nil, []error{fmt.Errorf("no crime with report number "+
+			"%d-%d from %s", crime.ReportSuperID, crime.ReportSubID,
+			crime.University)}
+	} else if err != nil {
+		return nil, []error{fmt.Errorf("error querying for crime: %s",
+			err.Error())}
+	}
+
+	return crime, nil
+}
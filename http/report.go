@@ -12,11 +12,14 @@ import (
 const ReportsKey string = "reports"
 
 // ListReportsHandler retrieves all report models
-type ListReportsHandler struct{}
+type ListReportsHandler struct {
+	// store is used to retrieve reports
+	store models.ReportStore
+}
 
 // Register implements the Registerable interface for ListReportsHandler
 func (h ListReportsHandler) Register(r *mux.Router) error {
-	r.Path("/api/v1/reports").Handler(ListReportsHandler{})
+	r.Path("/api/v1/reports").Handler(h)
 
 	return nil
 }
@@ -24,7 +27,7 @@ func (h ListReportsHandler) Register(r *mux.Router) error {
 // ServeHTTP returns a list of Report models in the 'reports' field.
 func (h ListReportsHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	// Query
-	reports, err := models.QueryAllReports()
+	reports, err := h.store.QueryAllReports(req.Context())
 	if err != nil {
 		WriteErr(w, err)
 		return
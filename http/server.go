@@ -1,11 +1,14 @@
 package http
 
 import (
+	"context"
 	"fmt"
 	"github.com/gorilla/mux"
 	"net/http"
+	"time"
 
 	"github.com/Noah-Huppert/crime-map/config"
+	"github.com/Noah-Huppert/crime-map/models"
 )
 
 // Server manges HTTP handlers
@@ -13,18 +16,27 @@ type Server struct {
 	// router is the Gorilla router used to map requests
 	router *mux.Router
 
+	// config configures the port requests are served on, and how long
+	// they may take
+	config config.HTTPConfig
+
 	// Routes holds all registered handlers. This field may be manipulated
 	// to add and remove handlers. Primarily in the NewServer method.
 	Routes []Registerable
 }
 
-// NewServer makes a new Server instance
-func NewServer() *Server {
+// NewServer makes a new Server instance which serves models from the
+// provided store, as configured
+func NewServer(c *config.Config, store models.Store) *Server {
 	return &Server{
 		router: mux.NewRouter(),
+		config: c.HTTP,
 		Routes: []Registerable{
-			GetCrimesHandler{},
-			ListReportsHandler{},
+			GetCrimesHandler{store: store},
+			ListReportsHandler{store: store},
+			GetDispositionHistoryHandler{store: store},
+			GetCrimeHistoryHandler{store: store},
+			StatusHandler{env: c.Env},
 		},
 	}
 }
@@ -50,19 +62,29 @@ func (s Server) Register() error {
 // Serve starts the HTTP server component. An error is returned if one occurs,
 // or nil on success
 func (s Server) Serve() error {
-	// Get config
-	c, err := config.NewConfig()
-	if err != nil {
-		return fmt.Errorf("error loading configuration: %s\n",
-			err.Error())
-	}
-
 	// Setup routes
-	if err = s.Register(); err != nil {
+	if err := s.Register(); err != nil {
 		return fmt.Errorf("error setting up routes: %s", err.Error())
 	}
 
 	// Start listening
-	fmt.Printf("listening on :%d\n", c.HTTP.Port)
-	return http.ListenAndServe(fmt.Sprintf(":%d", c.HTTP.Port), s.router)
+	fmt.Printf("listening on :%d\n", s.config.Port)
+	return http.ListenAndServe(fmt.Sprintf(":%d", s.config.Port),
+		timeoutHandler(s.router, s.config.RequestTimeout))
+}
+
+// timeoutHandler wraps a handler so the context of each request is canceled
+// once the timeout passes. The context is also canceled if the client
+// disconnects. If the timeout is 0 requests are not limited.
+func timeoutHandler(h http.Handler, timeout time.Duration) http.Handler {
+	if timeout == 0 {
+		return h
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx, cancel := context.WithTimeout(req.Context(), timeout)
+		defer cancel()
+
+		h.ServeHTTP(w, req.WithContext(ctx))
+	})
 }
@@ -0,0 +1,219 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Noah-Huppert/crime-map/config"
+	"github.com/Noah-Huppert/crime-map/models"
+)
+
+// newTestServer creates a Server which serves models from a MemStore. A crime
+// listed by two reports, whose disposition changed between them, is saved.
+// The test fails if an error occurs.
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+
+	ctx := context.Background()
+	store := models.NewMemStore()
+
+	loc := models.NewGeoLoc("3200 CHESTNUT ST")
+	if err := store.InsertGeoLocIfNew(ctx, loc); err != nil {
+		t.Fatalf("error inserting GeoLoc: %s", err.Error())
+	}
+
+	occurred := time.Date(2017, 10, 2, 13, 0, 0, 0, time.UTC)
+	remediations := []string{"Pending", "(1) Arrest"}
+
+	for i, remediation := range remediations {
+		// Each report covers a later week
+		now := time.Now()
+		start := occurred.AddDate(0, 0, 7*i)
+		end := start.AddDate(0, 0, 7)
+
+		report := models.NewReport(models.UniversityDrexel, &now,
+			&start, &end, 1)
+		if err := store.InsertReport(ctx, report); err != nil {
+			t.Fatalf("error inserting report: %s", err.Error())
+		}
+
+		crime := models.Crime{
+			University:        models.UniversityDrexel,
+			ReportID:          report.ID,
+			Page:              1,
+			DateReported:      occurred,
+			DateOccurredStart: occurred,
+			DateOccurredEnd:   occurred,
+			ReportSuperID:     1710,
+			ReportSubID:       5589,
+			GeoLocID:          loc.ID,
+			Incidents:         []string{"THEFT"},
+			Descriptions:      []string{"Laptop stolen"},
+			Remediation:       remediation,
+		}
+		crime.DispositionStatus, crime.DispositionCount =
+			models.ParseDisposition(remediation)
+
+		if err := store.InsertCrimes(ctx, []models.Crime{crime}); err != nil {
+			t.Fatalf("error inserting crime: %s", err.Error())
+		}
+	}
+
+	server := NewServer(&config.Config{Env: config.EnvTest}, store)
+	if err := server.Register(); err != nil {
+		t.Fatalf("error registering routes: %s", err.Error())
+	}
+
+	return server
+}
+
+// get makes a GET request to the server, and decodes the JSON response into
+// resp. The test fails if the response has errors.
+func get(t *testing.T, server *Server, url string, resp interface{}) {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200 from %s, got %d", url, w.Code)
+	}
+
+	// Check errors
+	errs := struct {
+		Errors []string `json:"errors"`
+	}{}
+
+	if err := json.Unmarshal(w.Body.Bytes(), &errs); err != nil {
+		t.Fatalf("error decoding response from %s: %s, body: %s", url,
+			err.Error(), w.Body.String())
+	}
+
+	if len(errs.Errors) != 0 {
+		t.Fatalf("expected no errors from %s, got: %v", url, errs.Errors)
+	}
+
+	if err := json.Unmarshal(w.Body.Bytes(), resp); err != nil {
+		t.Fatalf("error decoding response from %s: %s", url,
+			err.Error())
+	}
+}
+
+// TestStatusHandler checks the status endpoint returns the configured
+// environment
+func TestStatusHandler(t *testing.T) {
+	server := newTestServer(t)
+
+	resp := map[string]interface{}{}
+	get(t, server, "/api/v1/status", &resp)
+
+	if resp[StatusEnvKey] != string(config.EnvTest) {
+		t.Fatalf("expected env %s, got %v", config.EnvTest,
+			resp[StatusEnvKey])
+	}
+}
+
+// TestGetCrimesHandler checks the crime listed by both reports is returned
+// once, with the fields of the newer report
+func TestGetCrimesHandler(t *testing.T) {
+	server := newTestServer(t)
+
+	resp := struct {
+		Crimes []models.Crime `json:"crimes"`
+	}{}
+	get(t, server, "/api/v1/crimes?offset=0&limit=10&order_by=date_reported",
+		&resp)
+
+	if len(resp.Crimes) != 1 {
+		t.Fatalf("expected 1 crime, got %d", len(resp.Crimes))
+	}
+
+	if resp.Crimes[0].Remediation != "(1) Arrest" {
+		t.Fatalf("expected newest remediation, got: %s",
+			resp.Crimes[0].Remediation)
+	}
+}
+
+// TestGetCrimeHistoryHandler checks the sightings and revisions of a crime are
+// returned
+func TestGetCrimeHistoryHandler(t *testing.T) {
+	server := newTestServer(t)
+
+	resp := struct {
+		Sightings []models.CrimeSighting `json:"sightings"`
+		Revisions []models.CrimeRevision `json:"revisions"`
+	}{}
+	get(t, server, "/api/v1/crimes/history?university=Drexel+University"+
+		"&report_number=1710-05589", &resp)
+
+	if len(resp.Sightings) != 2 {
+		t.Fatalf("expected 2 sightings, got %d", len(resp.Sightings))
+	}
+
+	if len(resp.Revisions) != 1 {
+		t.Fatalf("expected 1 revision, got %d", len(resp.Revisions))
+	}
+
+	revision := resp.Revisions[0]
+	if revision.Field != models.FieldRemediation ||
+		revision.Previous != "Pending" || revision.Value != "(1) Arrest" {
+
+		t.Fatalf("unexpected revision: %+v", revision)
+	}
+
+	if revision.ReportID != resp.Sightings[1].ReportID {
+		t.Fatalf("expected revision by report %d, got %d",
+			resp.Sightings[1].ReportID, revision.ReportID)
+	}
+}
+
+// TestGetDispositionHistoryHandler checks the change in a crime's disposition
+// is returned with the old and new statuses
+func TestGetDispositionHistoryHandler(t *testing.T) {
+	server := newTestServer(t)
+
+	resp := struct {
+		Dispositions []models.DispositionChange `json:"dispositions"`
+	}{}
+	get(t, server, "/api/v1/dispositions?university=Drexel+University"+
+		"&report_number=1710-05589", &resp)
+
+	if len(resp.Dispositions) != 1 {
+		t.Fatalf("expected 1 disposition change, got %d",
+			len(resp.Dispositions))
+	}
+
+	change := resp.Dispositions[0]
+	if change.PreviousStatus != models.DispositionOpen ||
+		change.Status != models.DispositionArrest ||
+		!change.Count.Valid || change.Count.Int64 != 1 {
+
+		t.Fatalf("unexpected disposition change: %+v", change)
+	}
+}
+
+// TestGetCrimeHistoryHandlerUnknown checks an error is returned for a crime
+// which does not exist
+func TestGetCrimeHistoryHandlerUnknown(t *testing.T) {
+	server := newTestServer(t)
+
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet,
+		"/api/v1/crimes/history?university=Drexel+University"+
+			"&report_number=1710-1", nil))
+
+	resp := struct {
+		Errors []string `json:"errors"`
+	}{}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("error decoding response: %s", err.Error())
+	}
+
+	if len(resp.Errors) != 1 {
+		t.Fatalf("expected 1 error, got: %v", resp.Errors)
+	}
+}
@@ -0,0 +1,36 @@
+package http
+
+import (
+	"github.com/gorilla/mux"
+	"net/http"
+
+	"github.com/Noah-Huppert/crime-map/config"
+)
+
+// StatusEnvKey is the key which the application environment will be returned
+// in by the status endpoint
+const StatusEnvKey string = "env"
+
+// StatusHandler reports information about the running server
+type StatusHandler struct {
+	// env is the application environment the server is running in
+	env config.EnvType
+}
+
+// Register implements the Registerable interface for StatusHandler
+func (h StatusHandler) Register(r *mux.Router) error {
+	r.Path("/api/v1/status").
+		Methods("GET").
+		Handler(h)
+
+	return nil
+}
+
+// ServeHTTP returns the application environment in the 'env' field
+func (h StatusHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	// Respond
+	resp := make(map[string]interface{})
+	resp[StatusEnvKey] = h.env
+
+	WriteResp(w, resp)
+}
@@ -0,0 +1,242 @@
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Noah-Huppert/crime-map/geo"
+	"github.com/Noah-Huppert/crime-map/models"
+	"github.com/Noah-Huppert/crime-map/parsers"
+	"github.com/Noah-Huppert/crime-map/pdf"
+)
+
+// Summary records the outcome of ingesting a single report file
+type Summary struct {
+	// File is the path of the report file
+	File string
+
+	// ReportID is the ID of the Report model the file was saved as. 0 if
+	// the report could not be determined.
+	ReportID int
+
+	// University is the institution which published the report. Empty if
+	// it could not be determined.
+	University models.UniversityType
+
+	// RangeStart is the start of the date range the report covers. Nil if
+	// it could not be determined.
+	RangeStart *time.Time
+
+	// RangeEnd is the end of the date range the report covers. Nil if it
+	// could not be determined.
+	RangeEnd *time.Time
+
+	// Crimes is the number of crimes parsed from the report
+	Crimes int
+
+	// ParseErrors is the number of parse errors recorded while parsing the
+	// report's crimes, and the report as a whole
+	ParseErrors int
+
+	// ReportParseErrors holds the problems found in the report as a whole,
+	// ex: records which were skipped. Only recorded when ingesting
+	// leniently.
+	ReportParseErrors []models.ParseError
+
+	// Unmapped holds the incidents in the report which the incident
+	// mapping has no rule for. Crimes with these incidents were saved, but
+	// not categorized by them.
+	Unmapped []string
+
+	// Diagnostics records pages of the report file which could not be
+	// read. Crimes on these pages were not saved.
+	Diagnostics pdf.Diagnostics
+
+	// Skipped indicates that the report was not parsed because a file
+	// with the same contents had already been ingested
+	Skipped bool
+
+	// Status indicates how the report relates to reports which had
+	// already been ingested
+	Status parsers.ReportStatus
+
+	// SupersededID is the ID of the Report replaced by this report. 0 if
+	// no report was replaced.
+	SupersededID int
+
+	// Err holds the error which stopped the report from being ingested,
+	// nil on success
+	Err error
+}
+
+// Options configures how report files are ingested
+type Options struct {
+	// Verbose indicates if every saved crime should be output
+	Verbose bool
+
+	// Passwords holds candidate passwords for encrypted report files,
+	// tried after the empty password
+	Passwords []string
+
+	// Parser is the name of the registered parser used for every pdf
+	// report file. If empty the parser is detected from each report.
+	Parser string
+
+	// Mapping describes the columns of csv and html report files. If nil
+	// these files can not be ingested.
+	Mapping *parsers.TableMapping
+
+	// Incidents maps the incidents in reports to incident categories. If
+	// nil crimes are not categorized.
+	Incidents *parsers.IncidentMapping
+
+	// Mode determines how problems in reports are handled. In
+	// parsers.ModeLenient parts of a report which can not be parsed are
+	// skipped, and recorded as parse errors.
+	Mode parsers.Mode
+}
+
+// Batch ingests multiple report files at once
+type Batch struct {
+	// Jobs is the maximum number of files ingested at the same time
+	Jobs uint
+
+	// Options configures how each file is ingested
+	Options
+
+	// store is used to save reports and crimes
+	store models.Store
+
+	// geoCache is shared by all ingest jobs
+	geoCache *geo.GeoCache
+}
+
+// NewBatch creates a new Batch which ingests up to the specified number of
+// files at the same time, saving them in the provided store. If jobs is 0
+// files are ingested one at a time.
+func NewBatch(store models.Store, jobs uint, opts Options) *Batch {
+	if jobs == 0 {
+		jobs = 1
+	}
+
+	return &Batch{
+		Jobs:     jobs,
+		Options:  opts,
+		store:    store,
+		geoCache: geo.NewGeoCache(store),
+	}
+}
+
+// Ingest parses and saves each file. A failure to ingest one file does not
+// stop the others from being ingested. A Summary is returned for each file,
+// in the same order as the files argument.
+//
+// If the context is canceled files which have not started being ingested are
+// given a Summary with the context's error.
+func (b Batch) Ingest(ctx context.Context, files []string) []Summary {
+	summaries := make([]Summary, len(files))
+
+	// Channel of file indexes for jobs to ingest
+	idxs := make(chan int)
+
+	// Start jobs
+	var wg sync.WaitGroup
+
+	for j := uint(0); j < b.Jobs; j++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range idxs {
+				summaries[i] = File(ctx, b.store,
+					b.geoCache, files[i], b.Options)
+			}
+		}()
+	}
+
+	// Send files to jobs
+	for i, file := range files {
+		select {
+		case idxs <- i:
+		case <-ctx.Done():
+			summaries[i] = Summary{
+				File: file,
+				Err:  ctx.Err(),
+			}
+		}
+	}
+
+	close(idxs)
+	wg.Wait()
+
+	return summaries
+}
+
+// File parses a report file and saves the crimes, and their parse errors, in
+// the database. Everything is saved in one transaction, so if ingesting fails
+// the file can simply be ingested again. If the context is canceled ingesting
+// stops. A Summary of the outcome is returned.
+func File(ctx context.Context, store models.Store, geoCache *geo.GeoCache, file string, opts Options) Summary {
+	summary := Summary{File: file}
+
+	// Determine file format
+	src, err := parsers.NewSource(file, parsers.SourceOptions{
+		Passwords: opts.Passwords,
+		Mapping:   opts.Mapping,
+	})
+	if err != nil {
+		summary.Err = fmt.Errorf("error reading report: %s",
+			err.Error())
+		return summary
+	}
+
+	// Parse crimes
+	r := parsers.NewReader(src, store, geoCache, opts.Parser, opts.Mode,
+		opts.Incidents)
+
+	crimes, err := r.Parse(ctx)
+
+	// Record report information, even if parsing failed
+	summary.Status = r.Status()
+	summary.Diagnostics = r.Diagnostics()
+
+	if report := r.Report(); report != nil {
+		summary.ReportID = report.ID
+		summary.University = report.University
+		summary.RangeStart = report.RangeStartDate
+		summary.RangeEnd = report.RangeEndDate
+	}
+
+	if superseded := r.Superseded(); superseded != nil {
+		summary.SupersededID = superseded.ID
+	}
+
+	// Check if already ingested
+	if err == parsers.ErrReportParsed {
+		summary.Skipped = true
+		return summary
+	} else if err != nil {
+		summary.Err = fmt.Errorf("error parsing report: %s",
+			err.Error())
+		return summary
+	}
+
+	// Count saved crimes
+	for _, crime := range crimes {
+		if opts.Verbose {
+			fmt.Printf("saved crime:\n%s\n", crime)
+		}
+
+		summary.Crimes++
+		summary.ParseErrors += len(crime.ParseErrors)
+	}
+
+	summary.ReportParseErrors = r.ParseErrors()
+	summary.ParseErrors += len(summary.ReportParseErrors)
+	summary.Unmapped = r.Unmapped()
+
+	return summary
+}
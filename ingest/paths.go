@@ -0,0 +1,91 @@
+package ingest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Noah-Huppert/crime-map/parsers"
+)
+
+// ExpandPaths converts a list of file paths, directory paths and glob
+// patterns into a list of report files. Directories are searched recursively
+// for files with one of the parsers.SourceExts extensions. Each file is only returned once. An
+// error is returned if one occurs, nil on success.
+func ExpandPaths(paths []string) ([]string, error) {
+	files := []string{}
+	seen := map[string]bool{}
+
+	// add records a file if it has not been seen yet
+	add := func(file string) {
+		if !seen[file] {
+			seen[file] = true
+			files = append(files, file)
+		}
+	}
+
+	for _, path := range paths {
+		// Expand glob
+		matches, err := filepath.Glob(path)
+		if err != nil {
+			return files, fmt.Errorf("error expanding glob, pattern: "+
+				"%s, err: %s", path, err.Error())
+		}
+
+		// If not a glob, or glob matched nothing, use path as is so
+		// missing files are reported
+		if len(matches) == 0 {
+			matches = []string{path}
+		}
+
+		for _, match := range matches {
+			// Check exists
+			info, err := os.Stat(match)
+			if err != nil {
+				return files, fmt.Errorf("error reading path: %s",
+					err.Error())
+			}
+
+			// If file
+			if !info.IsDir() {
+				add(match)
+				continue
+			}
+
+			// If dir
+			err = filepath.Walk(match, func(p string, i os.FileInfo,
+				err error) error {
+
+				if err != nil {
+					return err
+				}
+
+				if !i.IsDir() && isReportFile(p) {
+					add(p)
+				}
+
+				return nil
+			})
+			if err != nil {
+				return files, fmt.Errorf("error searching "+
+					"directory, dir: %s, err: %s", match,
+					err.Error())
+			}
+		}
+	}
+
+	return files, nil
+}
+
+// isReportFile determines if a file has the extension of a report file format
+// which can be ingested
+func isReportFile(path string) bool {
+	for _, ext := range parsers.SourceExts {
+		if strings.EqualFold(filepath.Ext(path), ext) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,3 @@
+ALTER TABLE reports
+	DROP COLUMN pages_failed,
+	DROP COLUMN diagnostics;
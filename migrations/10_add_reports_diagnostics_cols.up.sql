@@ -0,0 +1,3 @@
+ALTER TABLE reports
+	ADD COLUMN pages_failed INTEGER NOT NULL DEFAULT 0,
+	ADD COLUMN diagnostics TEXT NOT NULL DEFAULT '';
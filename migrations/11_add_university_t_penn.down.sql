@@ -0,0 +1,11 @@
+ALTER TYPE university_t RENAME TO university_t_old;
+
+CREATE TYPE university_t AS ENUM (
+	'Drexel University'
+);
+
+ALTER TABLE reports
+	ALTER COLUMN university TYPE university_t
+	USING university::TEXT::university_t;
+
+DROP TYPE university_t_old;
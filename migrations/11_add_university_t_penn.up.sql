@@ -0,0 +1 @@
+ALTER TYPE university_t ADD VALUE 'University of Pennsylvania';
@@ -0,0 +1,13 @@
+DELETE FROM parse_errors WHERE err_type = 'MISSING_RANGE';
+
+ALTER TYPE ERR_TYPE_T RENAME TO err_type_t_old;
+
+CREATE TYPE ERR_TYPE_T AS ENUM (
+	'BAD_RANGE_END'
+);
+
+ALTER TABLE parse_errors
+	ALTER COLUMN err_type TYPE ERR_TYPE_T
+	USING err_type::TEXT::ERR_TYPE_T;
+
+DROP TYPE err_type_t_old;
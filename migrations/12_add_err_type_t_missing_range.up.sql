@@ -0,0 +1 @@
+ALTER TYPE ERR_TYPE_T ADD VALUE 'MISSING_RANGE';
@@ -0,0 +1,22 @@
+ALTER TABLE reports
+	DROP COLUMN parse_partial;
+
+DELETE FROM parse_errors WHERE crime_id IS NULL OR err_type NOT IN
+	('BAD_RANGE_END', 'MISSING_RANGE');
+
+ALTER TABLE parse_errors
+	DROP COLUMN report_id,
+	ALTER COLUMN crime_id SET NOT NULL;
+
+ALTER TYPE ERR_TYPE_T RENAME TO err_type_t_old;
+
+CREATE TYPE ERR_TYPE_T AS ENUM (
+	'BAD_RANGE_END',
+	'MISSING_RANGE'
+);
+
+ALTER TABLE parse_errors
+	ALTER COLUMN err_type TYPE ERR_TYPE_T
+	USING err_type::TEXT::ERR_TYPE_T;
+
+DROP TYPE err_type_t_old;
@@ -0,0 +1,24 @@
+ALTER TYPE ERR_TYPE_T RENAME TO err_type_t_old;
+
+CREATE TYPE ERR_TYPE_T AS ENUM (
+	'BAD_RANGE_END',
+	'MISSING_RANGE',
+	'BAD_REPORT_ID',
+	'UNKNOWN_FIELD',
+	'COUNT_MISMATCH',
+	'BAD_DATE',
+	'TRUNCATED_RECORD'
+);
+
+ALTER TABLE parse_errors
+	ALTER COLUMN err_type TYPE ERR_TYPE_T
+	USING err_type::TEXT::ERR_TYPE_T;
+
+DROP TYPE err_type_t_old;
+
+ALTER TABLE parse_errors
+	ALTER COLUMN crime_id DROP NOT NULL,
+	ADD COLUMN report_id INTEGER REFERENCES reports;
+
+ALTER TABLE reports
+	ADD COLUMN parse_partial BOOLEAN NOT NULL DEFAULT FALSE;
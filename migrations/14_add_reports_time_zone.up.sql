@@ -0,0 +1,2 @@
+ALTER TABLE reports
+	ADD COLUMN time_zone TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+DROP TABLE crime_incident_categories;
+
+DROP TABLE incident_categories;
@@ -0,0 +1,44 @@
+CREATE TABLE incident_categories (
+	name TEXT PRIMARY KEY,
+	clery BOOLEAN NOT NULL,
+	ucr_code TEXT NOT NULL
+);
+
+INSERT INTO incident_categories (name, clery, ucr_code) VALUES
+	('Murder and Non-Negligent Manslaughter', TRUE, '09A'),
+	('Manslaughter by Negligence', TRUE, '09B'),
+	('Rape', TRUE, '11A'),
+	('Fondling', TRUE, '11D'),
+	('Incest', TRUE, '36A'),
+	('Statutory Rape', TRUE, '36B'),
+	('Robbery', TRUE, '120'),
+	('Aggravated Assault', TRUE, '13A'),
+	('Burglary', TRUE, '220'),
+	('Motor Vehicle Theft', TRUE, '240'),
+	('Arson', TRUE, '200'),
+	('Larceny-Theft', TRUE, '23H'),
+	('Simple Assault', TRUE, '13B'),
+	('Intimidation', TRUE, '13C'),
+	('Destruction/Damage/Vandalism of Property', TRUE, '290'),
+	('Domestic Violence', TRUE, ''),
+	('Dating Violence', TRUE, ''),
+	('Stalking', TRUE, ''),
+	('Liquor Law Violation', TRUE, '90G'),
+	('Drug Law Violation', TRUE, '35A'),
+	('Weapons Law Violation', TRUE, '520'),
+	('Fraud', FALSE, '26A'),
+	('Extortion', FALSE, '210'),
+	('Pornography', FALSE, '370'),
+	('Disorderly Conduct', FALSE, '90C'),
+	('Driving Under the Influence', FALSE, '90D'),
+	('Drunkenness', FALSE, '90E'),
+	('Family Offenses, Nonviolent', FALSE, '90F'),
+	('Trespass of Real Property', FALSE, '90J'),
+	('All Other Offenses', FALSE, '90Z');
+
+CREATE TABLE crime_incident_categories (
+	crime_id INTEGER REFERENCES crimes NOT NULL,
+	category TEXT REFERENCES incident_categories NOT NULL,
+
+	PRIMARY KEY (crime_id, category)
+);
@@ -0,0 +1,7 @@
+DROP TABLE disposition_changes;
+
+ALTER TABLE crimes
+	DROP COLUMN disposition_status,
+	DROP COLUMN disposition_count;
+
+DROP TYPE DISPOSITION_STATUS_T;
@@ -0,0 +1,43 @@
+CREATE TYPE DISPOSITION_STATUS_T AS ENUM (
+	'OPEN',
+	'CLOSED',
+	'ARREST',
+	'REFERRAL',
+	'UNFOUNDED',
+	'EXCEPTIONALLY_CLEARED'
+);
+
+ALTER TABLE crimes
+	ADD COLUMN disposition_status DISPOSITION_STATUS_T,
+	ADD COLUMN disposition_count INTEGER;
+
+-- Parse dispositions of existing crimes, the same as models.ParseDisposition
+UPDATE crimes SET
+	disposition_count = substring(remediation
+		FROM '^\s*\(([0-9]+)\)')::INTEGER,
+	disposition_status = CASE
+		WHEN remediation ~* 'exceptional|cleared by exception'
+			THEN 'EXCEPTIONALLY_CLEARED'
+		WHEN remediation ~* 'unfounded' THEN 'UNFOUNDED'
+		WHEN remediation ~* 'arrest' THEN 'ARREST'
+		WHEN remediation ~* 'referr|student conduct' THEN 'REFERRAL'
+		WHEN remediation ~* 'closed' THEN 'CLOSED'
+		WHEN remediation ~* 'pending|active|open|investigat' THEN 'OPEN'
+		ELSE NULL
+	END::DISPOSITION_STATUS_T;
+
+CREATE TABLE disposition_changes (
+	id SERIAL PRIMARY KEY,
+
+	university UNIVERSITY_T NOT NULL,
+	report_super_id INTEGER NOT NULL,
+	report_sub_id INTEGER NOT NULL,
+
+	previous_crime_id INTEGER REFERENCES crimes NOT NULL,
+	crime_id INTEGER REFERENCES crimes NOT NULL,
+
+	UNIQUE (previous_crime_id, crime_id)
+);
+
+CREATE INDEX disposition_changes_report_idx ON disposition_changes (
+	university, report_super_id, report_sub_id);
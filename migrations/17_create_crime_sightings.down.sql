@@ -0,0 +1,25 @@
+-- Merged crimes are not split apart again, so disposition changes can not be
+-- copied back out of crime_revisions. The table is recreated empty.
+CREATE TABLE disposition_changes (
+	id SERIAL PRIMARY KEY,
+
+	university UNIVERSITY_T NOT NULL,
+	report_super_id INTEGER NOT NULL,
+	report_sub_id INTEGER NOT NULL,
+
+	previous_crime_id INTEGER REFERENCES crimes NOT NULL,
+	crime_id INTEGER REFERENCES crimes NOT NULL,
+
+	UNIQUE (previous_crime_id, crime_id)
+);
+
+CREATE INDEX disposition_changes_report_idx ON disposition_changes (
+	university, report_super_id, report_sub_id);
+
+DROP TABLE crime_revisions;
+
+DROP TABLE crime_sightings;
+
+ALTER TABLE crimes
+	DROP CONSTRAINT crimes_report_number_key,
+	DROP COLUMN university;
@@ -0,0 +1,131 @@
+ALTER TABLE crimes
+	ADD COLUMN university UNIVERSITY_T;
+
+UPDATE crimes SET university = reports.university FROM reports
+	WHERE reports.id = crimes.report_id;
+
+CREATE TABLE crime_sightings (
+	id SERIAL PRIMARY KEY,
+
+	crime_id INTEGER REFERENCES crimes NOT NULL,
+	report_id INTEGER REFERENCES reports NOT NULL,
+	page INTEGER NOT NULL,
+
+	UNIQUE (crime_id, report_id)
+);
+
+CREATE INDEX crime_sightings_report_idx ON crime_sightings (report_id);
+
+CREATE TABLE crime_revisions (
+	id SERIAL PRIMARY KEY,
+
+	sighting_id INTEGER REFERENCES crime_sightings NOT NULL,
+
+	field TEXT NOT NULL,
+	previous TEXT NOT NULL,
+	value TEXT NOT NULL
+);
+
+CREATE INDEX crime_revisions_sighting_idx ON crime_revisions (sighting_id);
+
+-- Crimes listed by more than one report were saved once per report. Merge
+-- each into the crime from the most recent report, the same as
+-- models.InsertCrimes. Crimes are ordered by the end of their report's range,
+-- then the order they were saved in.
+CREATE TEMP TABLE crime_merges AS
+	SELECT crimes.id, crimes.report_id, crimes.page,
+		last_value(crimes.id) OVER w AS keep_id,
+		lag(crimes.id) OVER w AS previous_id
+	FROM crimes JOIN reports ON reports.id = crimes.report_id
+	WINDOW w AS (
+		PARTITION BY crimes.university, crimes.report_super_id,
+			crimes.report_sub_id
+		ORDER BY upper(reports.covers_range), crimes.id
+		ROWS BETWEEN UNBOUNDED PRECEDING AND UNBOUNDED FOLLOWING
+	);
+
+INSERT INTO crime_sightings (crime_id, report_id, page)
+	SELECT DISTINCT ON (keep_id, report_id) keep_id, report_id, page
+	FROM crime_merges
+	ORDER BY keep_id, report_id, id;
+
+-- Record fields which changed between reports, formatted the same as
+-- models.CrimeRevision. Remediation changes already recorded in
+-- disposition_changes are copied below instead.
+INSERT INTO crime_revisions (sighting_id, field, previous, value)
+	SELECT crime_sightings.id, v.field, v.previous, v.value
+	FROM crime_merges
+	JOIN crimes p ON p.id = crime_merges.previous_id
+	JOIN crimes c ON c.id = crime_merges.id
+	JOIN crime_sightings ON
+		crime_sightings.crime_id = crime_merges.keep_id AND
+		crime_sightings.report_id = crime_merges.report_id
+	CROSS JOIN LATERAL (VALUES
+		(0, 'date_reported',
+			to_char(p.date_reported AT TIME ZONE 'UTC',
+				'YYYY-MM-DD"T"HH24:MI:SS"Z"'),
+			to_char(c.date_reported AT TIME ZONE 'UTC',
+				'YYYY-MM-DD"T"HH24:MI:SS"Z"')),
+		(1, 'date_occurred',
+			CASE WHEN isempty(p.date_occurred) THEN '' ELSE
+				to_char(lower(p.date_occurred) AT TIME ZONE 'UTC',
+					'YYYY-MM-DD"T"HH24:MI:SS"Z"') || '/' ||
+				to_char(upper(p.date_occurred) AT TIME ZONE 'UTC',
+					'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+			END,
+			CASE WHEN isempty(c.date_occurred) THEN '' ELSE
+				to_char(lower(c.date_occurred) AT TIME ZONE 'UTC',
+					'YYYY-MM-DD"T"HH24:MI:SS"Z"') || '/' ||
+				to_char(upper(c.date_occurred) AT TIME ZONE 'UTC',
+					'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+			END),
+		(2, 'geo_loc_id', p.geo_loc_id::TEXT, c.geo_loc_id::TEXT),
+		(3, 'incidents', array_to_json(p.incidents)::TEXT,
+			array_to_json(c.incidents)::TEXT),
+		(4, 'descriptions', array_to_json(p.descriptions)::TEXT,
+			array_to_json(c.descriptions)::TEXT),
+		(5, 'remediation', p.remediation, c.remediation)
+	) AS v(ord, field, previous, value)
+	WHERE p.report_id <> c.report_id AND v.previous <> v.value AND
+		NOT (v.field = 'remediation' AND EXISTS (
+			SELECT 1 FROM disposition_changes
+			WHERE disposition_changes.crime_id = c.id))
+	ORDER BY crime_merges.id, v.ord;
+
+-- Disposition changes are now revisions of the remediation field, recorded on
+-- the sighting of the crime by the newer report
+INSERT INTO crime_revisions (sighting_id, field, previous, value)
+	SELECT crime_sightings.id, 'remediation', p.remediation, c.remediation
+	FROM disposition_changes
+	JOIN crimes p ON p.id = disposition_changes.previous_crime_id
+	JOIN crimes c ON c.id = disposition_changes.crime_id
+	JOIN crime_merges ON crime_merges.id = c.id
+	JOIN crime_sightings ON
+		crime_sightings.crime_id = crime_merges.keep_id AND
+		crime_sightings.report_id = c.report_id
+	ORDER BY disposition_changes.id;
+
+-- Crime parse errors now also record the report being parsed
+UPDATE parse_errors SET report_id = crimes.report_id FROM crimes
+	WHERE crimes.id = parse_errors.crime_id AND
+		parse_errors.report_id IS NULL;
+
+UPDATE parse_errors SET crime_id = crime_merges.keep_id FROM crime_merges
+	WHERE crime_merges.id = parse_errors.crime_id AND
+		crime_merges.id <> crime_merges.keep_id;
+
+DELETE FROM crime_incident_categories WHERE crime_id IN (
+	SELECT id FROM crime_merges WHERE id <> keep_id);
+
+-- Copied into crime_revisions above
+DROP TABLE disposition_changes;
+
+DELETE FROM crimes WHERE id IN (
+	SELECT id FROM crime_merges WHERE id <> keep_id);
+
+DROP TABLE crime_merges;
+
+ALTER TABLE crimes
+	ALTER COLUMN university SET NOT NULL,
+	ADD CONSTRAINT crimes_report_number_key
+		UNIQUE (university, report_super_id, report_sub_id);
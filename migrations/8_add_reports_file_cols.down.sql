@@ -0,0 +1,7 @@
+DROP INDEX reports_file_sha256_idx;
+
+ALTER TABLE reports
+	DROP COLUMN file_sha256,
+	DROP COLUMN file_name,
+	DROP COLUMN file_size,
+	DROP COLUMN superseded_by;
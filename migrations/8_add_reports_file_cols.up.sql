@@ -0,0 +1,8 @@
+ALTER TABLE reports
+	ADD COLUMN file_sha256 TEXT NOT NULL DEFAULT '',
+	ADD COLUMN file_name TEXT NOT NULL DEFAULT '',
+	ADD COLUMN file_size BIGINT NOT NULL DEFAULT 0,
+	ADD COLUMN superseded_by INTEGER REFERENCES reports;
+
+CREATE UNIQUE INDEX reports_file_sha256_idx ON reports (file_sha256)
+	WHERE file_sha256 <> '';
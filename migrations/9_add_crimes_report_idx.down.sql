@@ -0,0 +1 @@
+DROP INDEX crimes_report_idx;
@@ -0,0 +1,2 @@
+CREATE INDEX crimes_report_idx ON crimes (report_id, report_super_id,
+	report_sub_id);
@@ -1,10 +1,12 @@
 package models
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"github.com/Noah-Huppert/crime-map/dstore"
 	"github.com/lib/pq"
+	"sort"
 	"strings"
 	"time"
 )
@@ -47,8 +49,14 @@ type Crime struct {
 	// ID is a unique identifier
 	ID int
 
-	// ReportID is the unique identifier of the Report the crime was parsed
-	// from
+	// University is the institution which published the reports the
+	// crime was listed in. Crimes are identified by their university and
+	// police report ID.
+	University UniversityType
+
+	// ReportID is the unique identifier of the Report the crime's fields
+	// were parsed from. The most recent report which listed the crime,
+	// see CrimeSighting.
 	ReportID int
 
 	// Page indicates which page of the report a crime was reported on
@@ -90,6 +98,18 @@ type Crime struct {
 	// crime to deal with the criminal activity
 	Remediation string
 
+	// DispositionStatus is the outcome of the crime's case, parsed from
+	// the Remediation field, see ParseDisposition
+	DispositionStatus DispositionStatusType
+
+	// DispositionCount is the count which prefixes the Remediation field,
+	// ex: 4 for "(4) Student Conduct Referrals". Invalid if there is none.
+	DispositionCount sql.NullInt64
+
+	// Categories holds the names of the incident categories the crime's
+	// incidents were normalized to, see IncidentTaxonomy. Sorted.
+	Categories pq.StringArray `gorm:"type:text[]"`
+
 	// ParseErrors holds any errors that occur while parsing the crime.
 	// These will be saved in other db tables depending on their types.
 	//
@@ -98,10 +118,20 @@ type Crime struct {
 	ParseErrors []ParseError `json:"-"`
 }
 
+// crimeCols is the list of columns selected by queries which retrieve Crime
+// models. Rows from these queries can be parsed by NewCrime.
+const crimeCols string = "crimes.id, crimes.university, crimes.report_id, " +
+	"crimes.page, " +
+	"crimes.date_reported, crimes.date_occurred, crimes.report_super_id, " +
+	"crimes.report_sub_id, crimes.geo_loc_id, crimes.incidents, " +
+	"crimes.descriptions, crimes.remediation, " +
+	"COALESCE(crimes.disposition_status::TEXT, ''), " +
+	"crimes.disposition_count, " +
+	"ARRAY(SELECT category FROM crime_incident_categories " +
+	"WHERE crime_id = crimes.id ORDER BY category)"
+
 // NewCrime creates a new Crime model from a database query sql.Rows
-// result set. This query should select the id, report_id, page, university,
-// date_reported, date_occurred, report_super_id, report_sub_id, geo_loc_id,
-// incidents, descriptions, and remediations fields.
+// result set. This query should select the columns in crimeCols.
 //
 // An Crime instance and error is returned. Nil on success.
 func NewCrime(rows *sql.Rows) (*Crime, error) {
@@ -110,10 +140,13 @@ func NewCrime(rows *sql.Rows) (*Crime, error) {
 	// Parse
 	// TODO: Figure out how to parse date range var d
 	var d interface{}
-	if err := rows.Scan(&crime.ID, &crime.ReportID, &crime.Page,
+	if err := rows.Scan(&crime.ID, &crime.University, &crime.ReportID,
+		&crime.Page,
 		&crime.DateReported, &d, &crime.ReportSuperID,
 		&crime.ReportSubID, &crime.GeoLocID, &crime.Incidents,
-		&crime.Descriptions, &crime.Remediation); err != nil {
+		&crime.Descriptions, &crime.Remediation,
+		&crime.DispositionStatus, &crime.DispositionCount,
+		&crime.Categories); err != nil {
 		return crime, fmt.Errorf("error parsing crime values from row"+
 			": %s", err.Error())
 	}
@@ -123,7 +156,8 @@ func NewCrime(rows *sql.Rows) (*Crime, error) {
 }
 
 func (c Crime) String() string {
-	return fmt.Sprintf("ReportID: %d\n"+
+	return fmt.Sprintf("University: %s\n"+
+		"ReportID: %d\n"+
 		"Page: %d\n"+
 		"Reported: %s\n"+
 		"Occurred Start: %s\n"+
@@ -133,7 +167,10 @@ func (c Crime) String() string {
 		"Incidents: %s\n"+
 		"Description: %s\n"+
 		"Remediation: %s\n"+
+		"Disposition: %s\n"+
+		"Categories: %s\n"+
 		"Parse Errors: %s",
+		c.University,
 		c.ReportID,
 		c.Page,
 		c.DateReported,
@@ -145,31 +182,24 @@ func (c Crime) String() string {
 		strings.Join(c.Incidents, ","),
 		strings.Join(c.Descriptions, ","),
 		c.Remediation,
+		c.DispositionStatus,
+		strings.Join(c.Categories, ","),
 		strings.Join(StringParseErrors(c.ParseErrors), ", "))
 }
 
-// Query finds a model with matching attributes in the db and sets the Crime.ID
-// field if found. Additionally an error is returned. Which will be
-// sql.ErrNoRows if a matching model is not found. Or nil on success.
-func (c *Crime) Query() error {
-	// Get db
-	db, err := dstore.NewDB()
-	if err != nil {
-		return fmt.Errorf("error getting db instance: %s", err.Error())
-	}
-
+// Query finds the model with the same university and police report ID in the
+// db and sets the Crime.ID field if found. Additionally an error is returned.
+// Which will be sql.ErrNoRows if a matching model is not found. Or nil on
+// success.
+func (c *Crime) Query(ctx context.Context, db dstore.Querier) error {
 	// Query
-	row := db.QueryRow("SELECT id FROM crimes WHERE report_id=$1 AND "+
-		"page=$2 AND date_reported=$3 AND date_occurred=tstzrange($4,"+
-		" $5, '()') AND report_super_id=$6 AND report_sub_id=$7 AND "+
-		"incidents=$8 AND descriptions=$9 AND "+
-		"remediation=$10", c.ReportID, c.Page, c.DateReported,
-		c.DateOccurredStart, c.DateOccurredEnd, c.ReportSuperID,
-		c.ReportSubID, c.Incidents, c.Descriptions,
-		c.Remediation)
+	row := db.QueryRowContext(ctx, "SELECT id FROM crimes WHERE "+
+		"university = $1 AND report_super_id = $2 AND "+
+		"report_sub_id = $3", c.University, c.ReportSuperID,
+		c.ReportSubID)
 
 	// Get ID
-	err = row.Scan(&c.ID)
+	err := row.Scan(&c.ID)
 
 	// Check if no row found
 	if err == sql.ErrNoRows {
@@ -183,46 +213,53 @@ func (c *Crime) Query() error {
 	return nil
 }
 
-// Insert adds the model to the database and sets the Crime.ID field to the
-// newly inserted models ID. Additionally an error is returned if one occurs,
-// or nil on success.
-func (c *Crime) Insert() error {
-	// Get db instance
-	db, err := dstore.NewDB()
-	if err != nil {
-		return fmt.Errorf("error creating db instance: %s",
-			err.Error())
-	}
-
+// Insert adds the model to the database, along with a CrimeSighting for the
+// report it was parsed from. Sets the Crime.ID field to the newly inserted
+// models ID. Additionally an error is returned if one occurs, or nil on
+// success.
+func (c *Crime) Insert(ctx context.Context, db dstore.Querier) error {
 	// Insert
-	row := db.QueryRow("INSERT INTO crimes (report_id, page, date_reported, "+
-		"date_occurred, report_super_id, report_sub_id, geo_loc_id, "+
-		"incidents, descriptions, remediation) VALUES ($1, $2, $3, "+
-		"tstzrange($4, $5, '()'), $6, $7, $8, $9, $10, $11) RETURNING id",
-		c.ReportID, c.Page, c.DateReported, c.DateOccurredStart,
-		c.DateOccurredEnd, c.ReportSuperID, c.ReportSubID, c.GeoLocID,
-		c.Incidents, c.Descriptions, c.Remediation)
+	row := db.QueryRowContext(ctx, "INSERT INTO crimes (university, "+
+		"report_id, page, date_reported, date_occurred, "+
+		"report_super_id, report_sub_id, geo_loc_id, incidents, "+
+		"descriptions, remediation, disposition_status, "+
+		"disposition_count) VALUES ($1, $2, $3, $4, "+
+		"tstzrange($5, $6, '()'), $7, $8, $9, $10, $11, $12, "+
+		"NULLIF($13, '')::DISPOSITION_STATUS_T, $14) RETURNING id",
+		c.University, c.ReportID, c.Page, c.DateReported,
+		c.DateOccurredStart, c.DateOccurredEnd, c.ReportSuperID,
+		c.ReportSubID, c.GeoLocID, c.Incidents, c.Descriptions,
+		c.Remediation, string(c.DispositionStatus), c.DispositionCount)
 
 	// Get ID
-	err = row.Scan(&c.ID)
+	err := row.Scan(&c.ID)
 	if err != nil {
 		return fmt.Errorf("error inserting into db: %s",
 			err.Error())
 	}
 
+	// Insert sighting
+	_, err = db.ExecContext(ctx, "INSERT INTO crime_sightings (crime_id, "+
+		"report_id, page) VALUES ($1, $2, $3)", c.ID, c.ReportID, c.Page)
+	if err != nil {
+		return fmt.Errorf("error inserting crime sighting into db: %s",
+			err.Error())
+	}
+
 	return nil
 }
 
-// InsertIfNew saves the current Crime model if it does not exist in the db.
-// Returns an error if one occurs, or nil on success.
-func (c *Crime) InsertIfNew() error {
+// InsertIfNew saves the current Crime model if no crime with the same
+// university and police report ID exists in the db. Returns an error if one
+// occurs, or nil on success.
+func (c *Crime) InsertIfNew(ctx context.Context, db dstore.Querier) error {
 	// Query
-	err := c.Query()
+	err := c.Query(ctx, db)
 
 	// Check if doesn't exist
 	if err == sql.ErrNoRows {
 		// Insert
-		err := c.Insert()
+		err := c.Insert(ctx, db)
 		if err != nil {
 			return fmt.Errorf("error inserting non existing model: %s",
 				err.Error())
@@ -237,33 +274,33 @@ func (c *Crime) InsertIfNew() error {
 	return nil
 }
 
+// crimesCurrentCond is the SQL condition which matches crimes listed by at
+// least one report which has not been superseded
+const crimesCurrentCond string = "EXISTS (SELECT 1 FROM crime_sightings " +
+	"JOIN reports ON reports.id = crime_sightings.report_id WHERE " +
+	"crime_sightings.crime_id = crimes.id AND " +
+	"reports.superseded_by IS NULL)"
+
 // QueryAllCrimes retrieves the specified number of Crime models from the
 // database. Ordered by the field specified in the orderBy argument. Must be
 // one of 'date_reported' or 'date_occurred'. An array of Crimes are returned,
 // along with an error. Which is nil on success.
 //
-// Retrieves all crime columns.
-func QueryAllCrimes(offset uint, limit uint, orderBy OrderByType) ([]*Crime, error) {
+// Retrieves the columns in crimeCols. Crimes only listed by superseded reports
+// are not included.
+func QueryAllCrimes(ctx context.Context, db dstore.Querier, offset uint, limit uint, orderBy OrderByType) ([]*Crime, error) {
 	crimes := []*Crime{}
 
-	// Check orderBy var
-	if orderBy == OrderByErr {
+	// Check orderBy var. It is placed directly in the query, so it must
+	// be one of the known column names.
+	if _, err := NewOrderByType(string(orderBy)); err != nil {
 		return crimes, fmt.Errorf("invalid orderBy value: %s", orderBy)
 	}
 
-	// Get db
-	db, err := dstore.NewDB()
-	if err != nil {
-		return crimes, fmt.Errorf("error retrieving database instance"+
-			": %s", err.Error())
-	}
-
 	// Query
-	rows, err := db.Query("SELECT id, report_id, page, date_reported, "+
-		"date_occurred, report_super_id, report_sub_id, "+
-		"geo_loc_id, incidents, descriptions, remediation "+
-		"FROM crimes ORDER BY $1 DESC OFFSET $2 LIMIT $3",
-		orderBy, offset, limit)
+	rows, err := db.QueryContext(ctx, "SELECT "+crimeCols+" "+
+		"FROM crimes WHERE "+crimesCurrentCond+" ORDER BY "+string(orderBy)+
+		" DESC, id DESC OFFSET $1 LIMIT $2", offset, limit)
 
 	if err != nil {
 		return crimes, fmt.Errorf("error querying database for crimes"+
@@ -290,3 +327,270 @@ func QueryAllCrimes(offset uint, limit uint, orderBy OrderByType) ([]*Crime, err
 	// Success
 	return crimes, nil
 }
+
+// crimesStagingMatch is the SQL condition which matches a crimes_staging row,
+// aliased s, with the same crime in the crimes table, aliased c. Crimes are
+// identified by their university and police report ID.
+const crimesStagingMatch string = "c.university = s.university AND " +
+	"c.report_super_id = s.report_super_id AND " +
+	"c.report_sub_id = s.report_sub_id"
+
+// crimesStagingFirst selects the first staged row of each crime, in case a
+// report lists the same police report ID twice
+const crimesStagingFirst string = "(SELECT DISTINCT ON (university, " +
+	"report_super_id, report_sub_id) * FROM crimes_staging ORDER BY " +
+	"university, report_super_id, report_sub_id, idx)"
+
+// crimesStagingNewer is the SQL condition which matches staged rows whose
+// report, aliased sr, is at least as recent as the report the crime's fields
+// were parsed from, aliased cr. Reports are compared by the end of their
+// ranges. If either range is unknown the staged row is considered newer.
+const crimesStagingNewer string = "COALESCE(upper(sr.covers_range) >= " +
+	"upper(cr.covers_range), TRUE)"
+
+// crimesStagingSightings is the SQL query which saves a sighting for each
+// staged row whose crime exists, unless the crime was already sighted in the
+// row's report
+const crimesStagingSightings string = "INSERT INTO crime_sightings (" +
+	"crime_id, report_id, page) SELECT c.id, s.report_id, s.page FROM " +
+	crimesStagingFirst + " s JOIN crimes c ON " + crimesStagingMatch +
+	" WHERE NOT EXISTS (SELECT 1 FROM crime_sightings g WHERE " +
+	"g.crime_id = c.id AND g.report_id = s.report_id) ORDER BY s.idx"
+
+// InsertCrimes saves many Crime models at once. Crimes are identified by their
+// university and police report ID, so a crime which already exists is not
+// saved again. Instead a CrimeSighting is saved for each crime, linking it to
+// the report it was parsed from.
+//
+// If an existing crime was parsed from an older report its fields are
+// replaced, and a CrimeRevision is saved for each field which changed. If it
+// was parsed from the same report, ex: when reparsing, its fields are replaced
+// without revisions. If it was parsed from a newer report it is not changed.
+//
+// Each Crime.ID field is set to the ID of the inserted or existing row. The
+// Crime.ReportID and Crime.Page fields are set to the report the saved
+// crime's fields were parsed from.
+//
+// The crimes are streamed into a temporary staging table with COPY. Then
+// merged into the crimes table with a few queries. This is much faster than
+// saving each crime, which would take several round trips to the db per
+// crime.
+//
+// Reports overlap, so transactions ingesting reports at the same time may save
+// the same crimes. The merge only sees crimes which were committed before it
+// ran, so each transaction would insert the crime, and all but one would fail
+// the crimes_report_number_key constraint. To prevent this a transaction
+// locks the crimes of each university it saves, until it ends. Other
+// transactions saving crimes of the university wait, then merge into the
+// crimes it saved.
+//
+// COPY requires a transaction. An error is returned if one occurs, nil on
+// success.
+func InsertCrimes(ctx context.Context, tx *sql.Tx, crimes []Crime) error {
+	// Check if anything to insert
+	if len(crimes) == 0 {
+		return nil
+	}
+
+	// Lock universities' crimes, in order so transactions do not deadlock
+	universities := []string{}
+	seen := map[UniversityType]bool{}
+
+	for _, c := range crimes {
+		if !seen[c.University] {
+			seen[c.University] = true
+			universities = append(universities, string(c.University))
+		}
+	}
+
+	sort.Strings(universities)
+
+	for _, university := range universities {
+		_, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock("+
+			"hashtext('crimes'), hashtext($1))", university)
+		if err != nil {
+			return fmt.Errorf("error locking %s crimes: %s",
+				university, err.Error())
+		}
+	}
+
+	// Make staging table
+	_, err := tx.ExecContext(ctx, "CREATE TEMP TABLE crimes_staging ("+
+		"idx INTEGER NOT NULL, "+
+		"university UNIVERSITY_T NOT NULL, "+
+		"report_id INTEGER NOT NULL, "+
+		"page INTEGER NOT NULL, "+
+		"date_reported TIMESTAMP WITH TIME ZONE NOT NULL, "+
+		"date_occurred_start TIMESTAMP WITH TIME ZONE NOT NULL, "+
+		"date_occurred_end TIMESTAMP WITH TIME ZONE NOT NULL, "+
+		"report_super_id INTEGER NOT NULL, "+
+		"report_sub_id INTEGER NOT NULL, "+
+		"geo_loc_id INTEGER NOT NULL, "+
+		"incidents TEXT[] NOT NULL, "+
+		"descriptions TEXT[] NOT NULL, "+
+		"remediation TEXT NOT NULL, "+
+		"disposition_status TEXT NOT NULL, "+
+		"disposition_count INTEGER"+
+		") ON COMMIT DROP")
+	if err != nil {
+		return fmt.Errorf("error creating staging table: %s",
+			err.Error())
+	}
+
+	// Copy crimes into staging table
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn("crimes_staging", "idx",
+		"university", "report_id", "page", "date_reported",
+		"date_occurred_start", "date_occurred_end", "report_super_id",
+		"report_sub_id", "geo_loc_id", "incidents", "descriptions",
+		"remediation", "disposition_status", "disposition_count"))
+	if err != nil {
+		return fmt.Errorf("error preparing copy statement: %s",
+			err.Error())
+	}
+
+	for i, c := range crimes {
+		_, err = stmt.ExecContext(ctx, i, string(c.University),
+			c.ReportID, c.Page, c.DateReported, c.DateOccurredStart,
+			c.DateOccurredEnd, int64(c.ReportSuperID),
+			int64(c.ReportSubID), c.GeoLocID, c.Incidents,
+			c.Descriptions, c.Remediation,
+			string(c.DispositionStatus), c.DispositionCount)
+		if err != nil {
+			stmt.Close()
+			return fmt.Errorf("error copying crime, i: %d, crime: %s"+
+				", err: %s", i, c, err.Error())
+		}
+	}
+
+	// Flush
+	if _, err = stmt.ExecContext(ctx); err != nil {
+		stmt.Close()
+		return fmt.Errorf("error finishing copy: %s", err.Error())
+	}
+
+	if err = stmt.Close(); err != nil {
+		return fmt.Errorf("error closing copy statement: %s",
+			err.Error())
+	}
+
+	// Sight existing crimes
+	if _, err = tx.ExecContext(ctx, crimesStagingSightings); err != nil {
+		return fmt.Errorf("error saving sightings of existing crimes: %s",
+			err.Error())
+	}
+
+	// Record revisions of existing crimes parsed from older reports
+	_, err = tx.ExecContext(ctx, "INSERT INTO crime_revisions ("+
+		"sighting_id, field, previous, value) SELECT g.id, v.field, "+
+		"v.previous, v.value FROM "+crimesStagingFirst+" s "+
+		"JOIN crimes c ON "+crimesStagingMatch+" "+
+		"JOIN reports cr ON cr.id = c.report_id "+
+		"JOIN reports sr ON sr.id = s.report_id "+
+		"JOIN crime_sightings g ON g.crime_id = c.id AND "+
+		"g.report_id = s.report_id "+
+		"CROSS JOIN LATERAL "+revisionValuesSQL()+" AS "+
+		"v(ord, field, previous, value) "+
+		"WHERE c.report_id <> s.report_id AND "+crimesStagingNewer+
+		" AND v.previous <> v.value ORDER BY s.idx, v.ord")
+	if err != nil {
+		return fmt.Errorf("error saving crime revisions: %s",
+			err.Error())
+	}
+
+	// Replace fields of existing crimes, unless parsed from a newer report
+	_, err = tx.ExecContext(ctx, "UPDATE crimes c SET "+
+		"report_id = s.report_id, page = s.page, "+
+		"date_reported = s.date_reported, "+
+		"date_occurred = tstzrange(s.date_occurred_start, "+
+		"s.date_occurred_end, '()'), geo_loc_id = s.geo_loc_id, "+
+		"incidents = s.incidents, descriptions = s.descriptions, "+
+		"remediation = s.remediation, disposition_status = "+
+		"NULLIF(s.disposition_status, '')::DISPOSITION_STATUS_T, "+
+		"disposition_count = s.disposition_count "+
+		"FROM "+crimesStagingFirst+" s, reports cr, reports sr "+
+		"WHERE "+crimesStagingMatch+" AND cr.id = c.report_id AND "+
+		"sr.id = s.report_id AND "+crimesStagingNewer)
+	if err != nil {
+		return fmt.Errorf("error updating existing crimes: %s",
+			err.Error())
+	}
+
+	// Insert crimes which do not exist yet. Crimes are inserted in the
+	// order they were provided.
+	_, err = tx.ExecContext(ctx, "INSERT INTO crimes (university, "+
+		"report_id, page, date_reported, date_occurred, "+
+		"report_super_id, report_sub_id, geo_loc_id, incidents, "+
+		"descriptions, remediation, disposition_status, "+
+		"disposition_count) SELECT university, report_id, page, "+
+		"date_reported, tstzrange(date_occurred_start, "+
+		"date_occurred_end, '()'), report_super_id, report_sub_id, "+
+		"geo_loc_id, incidents, descriptions, remediation, "+
+		"NULLIF(disposition_status, '')::DISPOSITION_STATUS_T, "+
+		"disposition_count FROM "+crimesStagingFirst+" s WHERE "+
+		"NOT EXISTS (SELECT 1 FROM crimes c WHERE "+
+		crimesStagingMatch+") ORDER BY idx")
+	if err != nil {
+		return fmt.Errorf("error inserting new crimes: %s",
+			err.Error())
+	}
+
+	// Sight new crimes
+	if _, err = tx.ExecContext(ctx, crimesStagingSightings); err != nil {
+		return fmt.Errorf("error saving sightings of new crimes: %s",
+			err.Error())
+	}
+
+	// Get IDs
+	rows, err := tx.QueryContext(ctx, "SELECT s.idx, c.id, c.report_id, "+
+		"c.page FROM crimes_staging s JOIN crimes c ON "+
+		crimesStagingMatch)
+	if err != nil {
+		return fmt.Errorf("error querying for merged crime IDs: %s",
+			err.Error())
+	}
+
+	found := 0
+
+	for rows.Next() {
+		var idx int
+		crime := Crime{}
+
+		if err = rows.Scan(&idx, &crime.ID, &crime.ReportID,
+			&crime.Page); err != nil {
+			rows.Close()
+			return fmt.Errorf("error parsing merged crime ID row: %s",
+				err.Error())
+		}
+
+		crimes[idx].ID = crime.ID
+		crimes[idx].ReportID = crime.ReportID
+		crimes[idx].Page = crime.Page
+		found++
+	}
+
+	if err = rows.Err(); err != nil {
+		rows.Close()
+		return fmt.Errorf("error reading merged crime IDs: %s",
+			err.Error())
+	}
+
+	if err = rows.Close(); err != nil {
+		return fmt.Errorf("error closing merged crime IDs query: %s",
+			err.Error())
+	}
+
+	// Check all crimes were saved
+	if found != len(crimes) {
+		return fmt.Errorf("only found %d of %d crimes after merging",
+			found, len(crimes))
+	}
+
+	// Drop staging table, so InsertCrimes can be called again in the
+	// same transaction
+	if _, err = tx.ExecContext(ctx, "DROP TABLE crimes_staging"); err != nil {
+		return fmt.Errorf("error dropping staging table: %s",
+			err.Error())
+	}
+
+	return nil
+}
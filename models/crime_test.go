@@ -0,0 +1,430 @@
+package models
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// queryTestCrime finds the saved crime with the ID, and its sightings and
+// revisions. The test fails if it does not exist, or an error occurs.
+func queryTestCrime(t *testing.T, store Store, id int) (*Crime, []*CrimeSighting, []*CrimeRevision) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	crimes, err := store.QueryAllCrimes(ctx, 0, 1000000, OrderByReported)
+	if err != nil {
+		t.Fatalf("error querying for crimes: %s", err.Error())
+	}
+
+	var found *Crime
+
+	for _, crime := range crimes {
+		if crime.ID == id {
+			found = crime
+		} else if crime.University == UniversityDrexel &&
+			crime.ReportSuperID == testReportSuperID {
+
+			t.Fatalf("expected only crime %d, found crime %d", id,
+				crime.ID)
+		}
+	}
+
+	if found == nil {
+		t.Fatalf("no crime with ID: %d", id)
+	}
+
+	sightings, err := store.QueryCrimeSightings(ctx, id)
+	if err != nil {
+		t.Fatalf("error querying for sightings: %s", err.Error())
+	}
+
+	revisions, err := store.QueryCrimeRevisions(ctx, id)
+	if err != nil {
+		t.Fatalf("error querying for revisions: %s", err.Error())
+	}
+
+	return found, sightings, revisions
+}
+
+// checkSightings fails the test if the sightings are not of the reports, in
+// order
+func checkSightings(t *testing.T, sightings []*CrimeSighting, reports ...*Report) {
+	t.Helper()
+
+	ids := []int{}
+	for _, sighting := range sightings {
+		ids = append(ids, sighting.ReportID)
+	}
+
+	want := []int{}
+	for _, report := range reports {
+		want = append(want, report.ID)
+	}
+
+	if !reflect.DeepEqual(ids, want) {
+		t.Fatalf("expected sightings in reports %v, got %v", want, ids)
+	}
+}
+
+// TestInsertCrimesOverlapping checks a crime listed by Drexel's daily log,
+// and then its yearly log, is saved once with a sighting in each
+func TestInsertCrimesOverlapping(t *testing.T) {
+	runStoreTest(t, func(t *testing.T, store Store) {
+		day := time.Date(2017, 10, 14, 4, 0, 0, 0, time.UTC)
+		daily := insertTestReport(t, store, day, day.AddDate(0, 0, 1))
+
+		year := time.Date(2017, 1, 1, 5, 0, 0, 0, time.UTC)
+		yearly := insertTestReport(t, store, year, year.AddDate(1, 0, 0))
+
+		loc := insertTestGeoLoc(t, store, "NORTH HALL")
+
+		// Daily
+		inDaily := insertTestCrimes(t, store, newTestCrime(daily, loc, 1,
+			"Pending"))[0]
+
+		// Yearly, same fields
+		listed := newTestCrime(daily, loc, 1, "Pending")
+		listed.ReportID = yearly.ID
+		listed.Page = 40
+
+		inYearly := insertTestCrimes(t, store, listed)[0]
+
+		if inYearly.ID != inDaily.ID {
+			t.Fatalf("expected existing crime %d, got crime %d",
+				inDaily.ID, inYearly.ID)
+		}
+
+		if inYearly.ReportID != yearly.ID || inYearly.Page != 40 {
+			t.Fatalf("expected fields from yearly report %d page 40, "+
+				"got report %d page %d", yearly.ID,
+				inYearly.ReportID, inYearly.Page)
+		}
+
+		crime, sightings, revisions := queryTestCrime(t, store,
+			inDaily.ID)
+
+		if crime.ReportID != yearly.ID {
+			t.Fatalf("expected saved fields from yearly report %d, "+
+				"got report %d", yearly.ID, crime.ReportID)
+		}
+
+		checkSightings(t, sightings, daily, yearly)
+
+		if sightings[1].Page != 40 {
+			t.Fatalf("expected yearly sighting on page 40, got %d",
+				sightings[1].Page)
+		}
+
+		if len(revisions) != 0 {
+			t.Fatalf("expected no revisions, got %d", len(revisions))
+		}
+	})
+}
+
+// TestInsertCrimesRevision checks a crime listed with different fields by a
+// newer report is revised, not saved again. And that a crime listed by an
+// older report is only sighted.
+func TestInsertCrimesRevision(t *testing.T) {
+	runStoreTest(t, func(t *testing.T, store Store) {
+		start := time.Date(2017, 10, 2, 4, 0, 0, 0, time.UTC)
+		first := insertTestReport(t, store, start, start.AddDate(0, 0, 7))
+		second := insertTestReport(t, store, start, start.AddDate(0, 0, 14))
+		older := insertTestReport(t, store, start, start.AddDate(0, 0, 3))
+
+		loc := insertTestGeoLoc(t, store, "NORTH HALL")
+		otherLoc := insertTestGeoLoc(t, store, "SOUTH HALL")
+
+		// First report
+		saved := insertTestCrimes(t, store, newTestCrime(first, loc, 2,
+			"Pending"))[0]
+
+		// Newer report, different disposition, location and
+		// descriptions
+		revised := newTestCrime(second, otherLoc, 2, "(1) Arrest")
+		revised.Descriptions = []string{"Laptop stolen",
+			"Suspect arrested"}
+
+		insertTestCrimes(t, store, revised)
+
+		crime, sightings, revisions := queryTestCrime(t, store, saved.ID)
+
+		checkSightings(t, sightings, first, second)
+
+		if crime.ReportID != second.ID || crime.GeoLocID != otherLoc.ID ||
+			crime.Remediation != "(1) Arrest" ||
+			crime.DispositionStatus != DispositionArrest ||
+			!reflect.DeepEqual(crime.Descriptions,
+				revised.Descriptions) {
+
+			t.Fatalf("expected fields from newer report, got: %+v",
+				crime)
+		}
+
+		want := []CrimeRevision{
+			{
+				Field:    FieldGeoLocID,
+				Previous: strconv.Itoa(loc.ID),
+				Value:    strconv.Itoa(otherLoc.ID),
+			},
+			{
+				Field:    FieldDescriptions,
+				Previous: `["Laptop stolen"]`,
+				Value:    `["Laptop stolen","Suspect arrested"]`,
+			},
+			{
+				Field:    FieldRemediation,
+				Previous: "Pending",
+				Value:    "(1) Arrest",
+			},
+		}
+
+		checkRevisions(t, revisions, sightings[1], want)
+
+		// Older report, different disposition
+		insertTestCrimes(t, store, newTestCrime(older, loc, 2,
+			"CLOSED"))
+
+		crime, sightings, revisions = queryTestCrime(t, store, saved.ID)
+
+		checkSightings(t, sightings, older, first, second)
+
+		if crime.ReportID != second.ID || crime.Remediation != "(1) Arrest" {
+			t.Fatalf("expected fields from newest report, got: %+v",
+				crime)
+		}
+
+		checkRevisions(t, revisions, sightings[2], want)
+	})
+}
+
+// TestInsertCrimesReparse checks a crime listed again by the same report, ex:
+// when reparsing, has its fields replaced without revisions
+func TestInsertCrimesReparse(t *testing.T) {
+	runStoreTest(t, func(t *testing.T, store Store) {
+		start := time.Date(2017, 10, 2, 4, 0, 0, 0, time.UTC)
+		report := insertTestReport(t, store, start, start.AddDate(0, 0, 7))
+
+		loc := insertTestGeoLoc(t, store, "NORTH HALL")
+
+		saved := insertTestCrimes(t, store, newTestCrime(report, loc, 3,
+			"Pending"))[0]
+
+		// Parse again, fixed disposition
+		insertTestCrimes(t, store, newTestCrime(report, loc, 3,
+			"STUDENT CONDUCT"))
+
+		crime, sightings, revisions := queryTestCrime(t, store, saved.ID)
+
+		checkSightings(t, sightings, report)
+
+		if len(revisions) != 0 {
+			t.Fatalf("expected no revisions, got %d", len(revisions))
+		}
+
+		if crime.Remediation != "STUDENT CONDUCT" ||
+			crime.DispositionStatus != DispositionReferral {
+
+			t.Fatalf("expected replaced disposition, got: %s",
+				crime.Remediation)
+		}
+	})
+}
+
+// TestInsertCrimesDuplicate checks a crime listed twice by the same report is
+// saved once, with the fields of the first listing
+func TestInsertCrimesDuplicate(t *testing.T) {
+	runStoreTest(t, func(t *testing.T, store Store) {
+		start := time.Date(2017, 10, 2, 4, 0, 0, 0, time.UTC)
+		report := insertTestReport(t, store, start, start.AddDate(0, 0, 7))
+
+		loc := insertTestGeoLoc(t, store, "NORTH HALL")
+
+		duplicate := newTestCrime(report, loc, 4, "CLOSED")
+		duplicate.Page = 2
+
+		crimes := insertTestCrimes(t, store,
+			newTestCrime(report, loc, 4, "Pending"), duplicate)
+
+		if crimes[0].ID != crimes[1].ID {
+			t.Fatalf("expected both listings saved as crime %d, got "+
+				"crime %d", crimes[0].ID, crimes[1].ID)
+		}
+
+		crime, sightings, revisions := queryTestCrime(t, store,
+			crimes[0].ID)
+
+		checkSightings(t, sightings, report)
+
+		if len(revisions) != 0 {
+			t.Fatalf("expected no revisions, got %d", len(revisions))
+		}
+
+		if crime.Remediation != "Pending" || crime.Page != 1 {
+			t.Fatalf("expected fields of first listing, got: %+v",
+				crime)
+		}
+	})
+}
+
+// checkRevisions fails the test if the revisions do not have the fields and
+// values of want, in order, and were not made by the sighting
+func checkRevisions(t *testing.T, revisions []*CrimeRevision, sighting *CrimeSighting, want []CrimeRevision) {
+	t.Helper()
+
+	if len(revisions) != len(want) {
+		t.Fatalf("expected %d revisions, got %d", len(want),
+			len(revisions))
+	}
+
+	for i, revision := range revisions {
+		if revision.Field != want[i].Field ||
+			revision.Previous != want[i].Previous ||
+			revision.Value != want[i].Value {
+
+			t.Errorf("revision %d: expected %s from %q to %q, got "+
+				"%s from %q to %q", i, want[i].Field,
+				want[i].Previous, want[i].Value, revision.Field,
+				revision.Previous, revision.Value)
+		}
+
+		if revision.SightingID != sighting.ID ||
+			revision.ReportID != sighting.ReportID {
+
+			t.Errorf("revision %d: expected sighting %d in report "+
+				"%d, got sighting %d in report %d", i,
+				sighting.ID, sighting.ReportID,
+				revision.SightingID, revision.ReportID)
+		}
+	}
+}
+
+// TestInsertCrimesConcurrent checks overlapping reports ingested at the same
+// time, in separate transactions, save each crime once. With a sighting in
+// each report, and the fields of the newer report.
+func TestInsertCrimesConcurrent(t *testing.T) {
+	t.Run("MemStore", func(t *testing.T) {
+		testInsertCrimesConcurrent(t, NewMemStore(), nil)
+	})
+
+	t.Run("PgStore", func(t *testing.T) {
+		db := openTestDB(t)
+		defer db.Close()
+
+		store := NewPgStore(db, 0)
+
+		// Both transactions are open before either saves crimes, so
+		// neither sees the other's crimes
+		ready := &sync.WaitGroup{}
+		ready.Add(2)
+
+		testInsertCrimesConcurrent(t, store, func() {
+			ready.Done()
+			ready.Wait()
+		})
+	})
+}
+
+// testInsertCrimesConcurrent implements TestInsertCrimesConcurrent. The
+// transactions are committed, and their rows deleted when the test ends. If
+// not nil inTx is called by each transaction before it saves crimes.
+func testInsertCrimesConcurrent(t *testing.T, store Store, inTx func()) {
+	ctx := context.Background()
+
+	// Reports, the yearly log lists every crime in the daily log
+	day := time.Date(2017, 10, 14, 4, 0, 0, 0, time.UTC)
+	daily := insertTestReport(t, store, day, day.AddDate(0, 0, 1))
+
+	year := time.Date(2017, 1, 1, 5, 0, 0, 0, time.UTC)
+	yearly := insertTestReport(t, store, year, year.AddDate(1, 0, 0))
+
+	loc := insertTestGeoLoc(t, store, "CONCURRENT INGEST TEST HALL")
+
+	if pg, ok := store.(*PgStore); ok {
+		defer deleteTestCrimes(t, pg, loc, daily, yearly)
+	}
+
+	// Crimes
+	const count uint = 200
+	listed := map[*Report][]Crime{}
+
+	for _, report := range []*Report{daily, yearly} {
+		for subID := uint(1); subID <= count; subID++ {
+			crime := newTestCrime(daily, loc, subID, "Pending")
+			crime.ReportID = report.ID
+
+			if report == yearly {
+				crime.Remediation = "CLOSED"
+			}
+
+			listed[report] = append(listed[report], crime)
+		}
+	}
+
+	// Save at the same time
+	wg := &sync.WaitGroup{}
+	errs := make(chan error, len(listed))
+
+	for _, crimes := range listed {
+		wg.Add(1)
+
+		go func(crimes []Crime) {
+			defer wg.Done()
+
+			errs <- store.Tx(ctx, func(tx Store) error {
+				if inTx != nil {
+					inTx()
+				}
+
+				return tx.InsertCrimes(ctx, crimes)
+			})
+		}(crimes)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("error inserting crimes: %s", err.Error())
+		}
+	}
+
+	// Check
+	saved, err := store.QueryAllCrimes(ctx, 0, 1000000, OrderByReported)
+	if err != nil {
+		t.Fatalf("error querying for crimes: %s", err.Error())
+	}
+
+	found := uint(0)
+
+	for _, crime := range saved {
+		if crime.University != UniversityDrexel ||
+			crime.ReportSuperID != testReportSuperID {
+			continue
+		}
+
+		found++
+
+		if crime.ReportID != yearly.ID || crime.Remediation != "CLOSED" {
+			t.Fatalf("crime %d: expected fields from yearly report "+
+				"%d, got report %d: %s", crime.ID, yearly.ID,
+				crime.ReportID, crime.Remediation)
+		}
+
+		sightings, err := store.QueryCrimeSightings(ctx, crime.ID)
+		if err != nil {
+			t.Fatalf("error querying for sightings: %s", err.Error())
+		}
+
+		checkSightings(t, sightings, daily, yearly)
+	}
+
+	if found != count {
+		t.Fatalf("expected %d crimes, got %d", count, found)
+	}
+}
@@ -0,0 +1,170 @@
+package models
+
+import (
+	"database/sql"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DispositionStatusType is a string type alias, used to represent the outcome
+// of a crime's case. Parsed from the free text Crime.Remediation field.
+type DispositionStatusType string
+
+const (
+	// DispositionUnknown indicates that the status could not be
+	// determined from the disposition text. Saved as NULL.
+	DispositionUnknown DispositionStatusType = ""
+
+	// DispositionOpen indicates that the case is still being
+	// investigated
+	DispositionOpen DispositionStatusType = "OPEN"
+
+	// DispositionClosed indicates that the case was closed without
+	// further action
+	DispositionClosed DispositionStatusType = "CLOSED"
+
+	// DispositionArrest indicates that the case was cleared by an arrest
+	DispositionArrest DispositionStatusType = "ARREST"
+
+	// DispositionReferral indicates that the case was referred to
+	// another office, ex: student conduct
+	DispositionReferral DispositionStatusType = "REFERRAL"
+
+	// DispositionUnfounded indicates that the report was found to be
+	// false or baseless
+	DispositionUnfounded DispositionStatusType = "UNFOUNDED"
+
+	// DispositionExceptionallyCleared indicates that the case was cleared
+	// for a reason outside of police control, ex: the victim declined to
+	// prosecute
+	DispositionExceptionallyCleared DispositionStatusType = "EXCEPTIONALLY_CLEARED"
+)
+
+// dispositionCountExpr matches the count which prefixes some dispositions,
+// ex: "(4) Student Conduct Referrals"
+var dispositionCountExpr *regexp.Regexp = regexp.MustCompile(`^\(([0-9]+)\)\s*`)
+
+// dispositionPattern identifies the status of dispositions matching an
+// expression
+type dispositionPattern struct {
+	// expr matches the disposition text
+	expr *regexp.Regexp
+
+	// status is the status of matching dispositions
+	status DispositionStatusType
+}
+
+// dispositionPatterns are tried in order against disposition text, the first
+// which matches determines the status. Ordered so more specific outcomes are
+// matched first, ex: "CLEARED BY ARREST" is an arrest, not just cleared.
+var dispositionPatterns []dispositionPattern = []dispositionPattern{
+	{
+		expr:   regexp.MustCompile(`(?i)exceptional|cleared by exception`),
+		status: DispositionExceptionallyCleared,
+	},
+	{
+		expr:   regexp.MustCompile(`(?i)unfounded`),
+		status: DispositionUnfounded,
+	},
+	{
+		expr:   regexp.MustCompile(`(?i)arrest`),
+		status: DispositionArrest,
+	},
+	{
+		expr:   regexp.MustCompile(`(?i)referr|student conduct`),
+		status: DispositionReferral,
+	},
+	{
+		expr:   regexp.MustCompile(`(?i)closed`),
+		status: DispositionClosed,
+	},
+	{
+		expr:   regexp.MustCompile(`(?i)pending|active|open|investigat`),
+		status: DispositionOpen,
+	},
+}
+
+// ParseDisposition determines the status of a crime's case from the text of its
+// disposition, ex: "CLEARED BY ARREST" or "(4) Student Conduct Referrals".
+// The status is returned, DispositionUnknown if it could not be determined.
+// Followed by the count which prefixes the text, invalid if there is none.
+func ParseDisposition(text string) (DispositionStatusType, sql.NullInt64) {
+	text = strings.TrimSpace(text)
+
+	// Count
+	count := sql.NullInt64{}
+
+	if match := dispositionCountExpr.FindStringSubmatch(text); match != nil {
+		if n, err := strconv.ParseInt(match[1], 10, 64); err == nil {
+			count = sql.NullInt64{Int64: n, Valid: true}
+		}
+
+		text = text[len(match[0]):]
+	}
+
+	// Status
+	for _, pattern := range dispositionPatterns {
+		if pattern.expr.MatchString(text) {
+			return pattern.status, count
+		}
+	}
+
+	return DispositionUnknown, count
+}
+
+// DispositionChange describes a revision of a crime's disposition, see
+// CrimeRevision
+type DispositionChange struct {
+	// RevisionID is the ID of the CrimeRevision which recorded the change
+	RevisionID int
+
+	// ReportID is the ID of the report which listed the new disposition
+	ReportID int
+
+	// PreviousRemediation is the old disposition text
+	PreviousRemediation string
+
+	// PreviousStatus is the status of the old disposition
+	PreviousStatus DispositionStatusType
+
+	// PreviousCount is the count of the old disposition
+	PreviousCount sql.NullInt64
+
+	// Remediation is the new disposition text
+	Remediation string
+
+	// Status is the status of the new disposition
+	Status DispositionStatusType
+
+	// Count is the count of the new disposition
+	Count sql.NullInt64
+}
+
+// NewDispositionChanges finds the revisions of a crime's Remediation field,
+// and parses the old and new dispositions of each. Revisions of other fields
+// are ignored. The changes are returned in the same order as the revisions.
+func NewDispositionChanges(revisions []*CrimeRevision) []*DispositionChange {
+	changes := []*DispositionChange{}
+
+	for _, revision := range revisions {
+		if revision.Field != FieldRemediation {
+			continue
+		}
+
+		d := &DispositionChange{
+			RevisionID:          revision.ID,
+			ReportID:            revision.ReportID,
+			PreviousRemediation: revision.Previous,
+			Remediation:         revision.Value,
+		}
+
+		d.PreviousStatus, d.PreviousCount = ParseDisposition(
+			revision.Previous)
+		d.Status, d.Count = ParseDisposition(revision.Value)
+
+		changes = append(changes, d)
+	}
+
+	return changes
+}
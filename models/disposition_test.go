@@ -0,0 +1,158 @@
+package models
+
+import (
+	"database/sql"
+	"testing"
+)
+
+// nullCount is a valid sql.NullInt64 holding n
+func nullCount(n int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: n, Valid: true}
+}
+
+// TestParseDisposition checks the status and count of disposition text written
+// in reports
+func TestParseDisposition(t *testing.T) {
+	tests := []struct {
+		text   string
+		status DispositionStatusType
+		count  sql.NullInt64
+	}{
+		// Each status
+		{"Pending Investigation DUPD", DispositionOpen, sql.NullInt64{}},
+		{"ACTIVE", DispositionOpen, sql.NullInt64{}},
+		{"Open", DispositionOpen, sql.NullInt64{}},
+		{"Under investigation", DispositionOpen, sql.NullInt64{}},
+		{"CLOSED", DispositionClosed, sql.NullInt64{}},
+		{"Case Closed", DispositionClosed, sql.NullInt64{}},
+		{"(1) Arrest", DispositionArrest, nullCount(1)},
+		{"Arrested", DispositionArrest, sql.NullInt64{}},
+		{"STUDENT CONDUCT", DispositionReferral, sql.NullInt64{}},
+		{"Referred to Student Conduct", DispositionReferral,
+			sql.NullInt64{}},
+		{"UNFOUNDED", DispositionUnfounded, sql.NullInt64{}},
+		{"Cleared by exception", DispositionExceptionallyCleared,
+			sql.NullInt64{}},
+		{"EXCEPTIONALLY CLEARED", DispositionExceptionallyCleared,
+			sql.NullInt64{}},
+
+		// Earlier patterns take precedence
+		{"CLEARED BY ARREST", DispositionArrest, sql.NullInt64{}},
+		{"Closed - Unfounded", DispositionUnfounded, sql.NullInt64{}},
+		{"Arrest Pending", DispositionArrest, sql.NullInt64{}},
+		{"Referred, case closed", DispositionReferral, sql.NullInt64{}},
+		{"Exceptionally cleared, no arrest", DispositionExceptionallyCleared,
+			sql.NullInt64{}},
+
+		// Counts
+		{"(4) Student Conduct Referrals", DispositionReferral, nullCount(4)},
+		{"(12)Arrests", DispositionArrest, nullCount(12)},
+		{"(0) Arrests", DispositionArrest, nullCount(0)},
+		{"  (2)   Student Conduct Referrals  ", DispositionReferral,
+			nullCount(2)},
+		{"(99999999999999999999) Arrests", DispositionArrest,
+			sql.NullInt64{}},
+		{"(3)", DispositionUnknown, nullCount(3)},
+		{"Arrests (3)", DispositionArrest, sql.NullInt64{}},
+		{"(a) Arrest", DispositionArrest, sql.NullInt64{}},
+
+		// Unknown
+		{"", DispositionUnknown, sql.NullInt64{}},
+		{"   ", DispositionUnknown, sql.NullInt64{}},
+		{"Information Only", DispositionUnknown, sql.NullInt64{}},
+		{"N/A", DispositionUnknown, sql.NullInt64{}},
+		{"(5) Information Only", DispositionUnknown, nullCount(5)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.text, func(t *testing.T) {
+			status, n := ParseDisposition(test.text)
+
+			if status != test.status {
+				t.Errorf("expected status %q, got %q",
+					test.status, status)
+			}
+
+			if n != test.count {
+				t.Errorf("expected count %v, got %v", test.count,
+					n)
+			}
+		})
+	}
+}
+
+// TestNewDispositionChanges checks only revisions of remediations are
+// returned, with the old and new dispositions parsed
+func TestNewDispositionChanges(t *testing.T) {
+	revisions := []*CrimeRevision{
+		{
+			ID:       1,
+			ReportID: 10,
+			Field:    FieldDescriptions,
+			Previous: "Laptop stolen",
+			Value:    "Laptop stolen from lounge",
+		},
+		{
+			ID:       2,
+			ReportID: 10,
+			Field:    FieldRemediation,
+			Previous: "Pending",
+			Value:    "(1) Arrest",
+		},
+		{
+			ID:       3,
+			ReportID: 11,
+			Field:    FieldIncidents,
+			Previous: "THEFT",
+			Value:    "BURGLARY",
+		},
+		{
+			ID:       4,
+			ReportID: 12,
+			Field:    FieldRemediation,
+			Previous: "(1) Arrest",
+			Value:    "Information Only",
+		},
+	}
+
+	want := []DispositionChange{
+		{
+			RevisionID:          2,
+			ReportID:            10,
+			PreviousRemediation: "Pending",
+			PreviousStatus:      DispositionOpen,
+			PreviousCount:       sql.NullInt64{},
+			Remediation:         "(1) Arrest",
+			Status:              DispositionArrest,
+			Count:               nullCount(1),
+		},
+		{
+			RevisionID:          4,
+			ReportID:            12,
+			PreviousRemediation: "(1) Arrest",
+			PreviousStatus:      DispositionArrest,
+			PreviousCount:       nullCount(1),
+			Remediation:         "Information Only",
+			Status:              DispositionUnknown,
+			Count:               sql.NullInt64{},
+		},
+	}
+
+	changes := NewDispositionChanges(revisions)
+
+	if len(changes) != len(want) {
+		t.Fatalf("expected %d changes, got %d", len(want), len(changes))
+	}
+
+	for i, change := range changes {
+		if *change != want[i] {
+			t.Errorf("change %d: expected %+v, got %+v", i, want[i],
+				*change)
+		}
+	}
+
+	// No revisions
+	if changes := NewDispositionChanges(nil); len(changes) != 0 {
+		t.Fatalf("expected no changes, got %d", len(changes))
+	}
+}
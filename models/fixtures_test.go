@@ -0,0 +1,179 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Noah-Huppert/crime-map/config"
+	"github.com/Noah-Huppert/crime-map/dstore"
+	"github.com/lib/pq"
+)
+
+// errTestRollback is returned by transactions in tests which should be rolled
+// back
+var errTestRollback error = errors.New("rollback")
+
+// testDBEnv is the environment variable which holds the connection string of
+// a migrated database. If set, store tests are also run against a PgStore.
+const testDBEnv string = "TEST_DB_CONN_STRING"
+
+// runStoreTest runs a test against a MemStore, and a PgStore if the testDBEnv
+// environment variable is set. The PgStore test is run in a transaction which
+// is rolled back, so the database is not changed.
+func runStoreTest(t *testing.T, fn func(t *testing.T, store Store)) {
+	t.Run("MemStore", func(t *testing.T) {
+		fn(t, NewMemStore())
+	})
+
+	t.Run("PgStore", func(t *testing.T) {
+		db := openTestDB(t)
+		defer db.Close()
+
+		err := NewPgStore(db, 0).Tx(context.Background(),
+			func(tx Store) error {
+				fn(t, tx)
+				return errTestRollback
+			})
+		if err != errTestRollback {
+			t.Fatalf("error running transaction: %v", err)
+		}
+	})
+}
+
+// openTestDB connects to the database in the testDBEnv environment variable.
+// The test is skipped if it is not set, and fails if an error occurs.
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	connStr := os.Getenv(testDBEnv)
+	if len(connStr) == 0 {
+		t.Skipf("%s not set", testDBEnv)
+	}
+
+	db, err := dstore.NewDB(config.DBConfig{ConnString: connStr})
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+
+	return db
+}
+
+// insertTestReport saves a Drexel report covering the provided range in the
+// store. The report is returned. The test fails if an error occurs.
+func insertTestReport(t *testing.T, store Store, start time.Time, end time.Time) *Report {
+	t.Helper()
+
+	now := time.Now()
+	report := NewReport(UniversityDrexel, &now, &start, &end, 1)
+
+	if err := store.InsertReport(context.Background(), report); err != nil {
+		t.Fatalf("error inserting report: %s", err.Error())
+	}
+
+	return report
+}
+
+// insertTestGeoLoc saves a GeoLoc with the raw location in the store, if one
+// does not exist. The GeoLoc is returned. The test fails if an error occurs.
+func insertTestGeoLoc(t *testing.T, store Store, raw string) *GeoLoc {
+	t.Helper()
+
+	loc := NewGeoLoc(raw)
+
+	if err := store.InsertGeoLocIfNew(context.Background(), loc); err != nil {
+		t.Fatalf("error inserting GeoLoc: %s", err.Error())
+	}
+
+	return loc
+}
+
+// testReportSuperID is the police report super ID of crimes saved by tests.
+// Unlikely to be used by a real report, in case the test database has data.
+const testReportSuperID uint = 9901
+
+// newTestCrime creates a Drexel crime listed by the report, with the
+// disposition. The crime occurred at the start of the report's range.
+func newTestCrime(report *Report, loc *GeoLoc, subID uint, remediation string) Crime {
+	occurred := *report.RangeStartDate
+
+	crime := Crime{
+		University:        UniversityDrexel,
+		ReportID:          report.ID,
+		Page:              1,
+		DateReported:      occurred,
+		DateOccurredStart: occurred,
+		DateOccurredEnd:   occurred.Add(time.Hour),
+		ReportSuperID:     testReportSuperID,
+		ReportSubID:       subID,
+		GeoLocID:          loc.ID,
+		Incidents:         []string{"THEFT-Laptop"},
+		Descriptions:      []string{"Laptop stolen"},
+		Remediation:       remediation,
+	}
+	crime.DispositionStatus, crime.DispositionCount = ParseDisposition(
+		remediation)
+
+	return crime
+}
+
+// insertTestCrimes saves the crimes with InsertCrimes. The test fails if an
+// error occurs.
+func insertTestCrimes(t *testing.T, store Store, crimes ...Crime) []Crime {
+	t.Helper()
+
+	if err := store.InsertCrimes(context.Background(), crimes); err != nil {
+		t.Fatalf("error inserting crimes: %s", err.Error())
+	}
+
+	return crimes
+}
+
+// deleteTestCrimes deletes the crimes saved by tests, and the reports and
+// GeoLoc they reference. The test fails if an error occurs.
+func deleteTestCrimes(t *testing.T, store *PgStore, loc *GeoLoc, reports ...*Report) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	reportIDs := pq.Int64Array{}
+	for _, report := range reports {
+		reportIDs = append(reportIDs, int64(report.ID))
+	}
+
+	crimes := "SELECT id FROM crimes WHERE university = $1 AND " +
+		"report_super_id = $2"
+
+	queries := []struct {
+		query string
+		args  []interface{}
+	}{
+		{"DELETE FROM crime_revisions WHERE sighting_id IN (SELECT id " +
+			"FROM crime_sightings WHERE crime_id IN (" + crimes + "))",
+			[]interface{}{UniversityDrexel, testReportSuperID}},
+		{"DELETE FROM crime_sightings WHERE crime_id IN (" + crimes +
+			")", []interface{}{UniversityDrexel, testReportSuperID}},
+		{"DELETE FROM crime_incident_categories WHERE crime_id IN (" +
+			crimes + ")",
+			[]interface{}{UniversityDrexel, testReportSuperID}},
+		{"DELETE FROM parse_errors WHERE crime_id IN (" + crimes + ")",
+			[]interface{}{UniversityDrexel, testReportSuperID}},
+		{"DELETE FROM crimes WHERE id IN (" + crimes + ")",
+			[]interface{}{UniversityDrexel, testReportSuperID}},
+		{"DELETE FROM parse_errors WHERE report_id = ANY($1)",
+			[]interface{}{reportIDs}},
+		{"DELETE FROM reports WHERE id = ANY($1)",
+			[]interface{}{reportIDs}},
+		{"DELETE FROM geo_locs WHERE id = $1", []interface{}{loc.ID}},
+	}
+
+	for _, q := range queries {
+		if _, err := store.db.ExecContext(ctx, q.query,
+			q.args...); err != nil {
+			t.Fatalf("error deleting test rows: %s", err.Error())
+		}
+	}
+}
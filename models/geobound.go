@@ -1,6 +1,7 @@
 package models
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"googlemaps.github.io/maps"
@@ -45,21 +46,14 @@ func GeoBoundFromMapsBound(bounds maps.LatLngBounds) *GeoBound {
 // in the database. The GeoBound.ID field will be populated with the model's
 // ID in the database. An error will be returned if one occurs, or nil on
 // success.
-func (b *GeoBound) Query() error {
-	// Get database instance
-	db, err := dstore.NewDB()
-	if err != nil {
-		return fmt.Errorf("error retrieving database instance: %s",
-			err.Error())
-	}
-
+func (b *GeoBound) Query(ctx context.Context, db dstore.Querier) error {
 	// Query
-	row := db.QueryRow("SELECT id FROM geo_bounds WHERE ne_lat = $1 AND "+
+	row := db.QueryRowContext(ctx, "SELECT id FROM geo_bounds WHERE ne_lat = $1 AND "+
 		"ne_long = $2 AND sw_lat = $3 AND sw_long = $4",
 		b.NeLat, b.NeLong, b.SwLat, b.SwLong)
 
 	// Get ID
-	err = row.Scan(&b.ID)
+	err := row.Scan(&b.ID)
 	// If no rounds found
 	if err == sql.ErrNoRows {
 		// Just return error so we can identify
@@ -74,21 +68,14 @@ func (b *GeoBound) Query() error {
 
 // Insert adds a GeoBound model to the database. An error is returned if one
 // occurs, nil on success.
-func (b *GeoBound) Insert() error {
-	// Get db instance
-	db, err := dstore.NewDB()
-	if err != nil {
-		return fmt.Errorf("error retrieving database instance: %s",
-			err.Error())
-	}
-
+func (b *GeoBound) Insert(ctx context.Context, db dstore.Querier) error {
 	// Insert
-	row := db.QueryRow("INSERT INTO geo_bounds (ne_lat, ne_long, sw_lat, "+
+	row := db.QueryRowContext(ctx, "INSERT INTO geo_bounds (ne_lat, ne_long, sw_lat, "+
 		"sw_long) VALUES ($1, $2, $3, $4) RETURNING id",
 		b.NeLat, b.NeLong, b.SwLat, b.SwLong)
 
 	// Get new ID
-	err = row.Scan(&b.ID)
+	err := row.Scan(&b.ID)
 	if err != nil {
 		return fmt.Errorf("error inserting GeoBound into db: %s",
 			err.Error())
@@ -102,14 +89,14 @@ func (b *GeoBound) Insert() error {
 // database. If none is found, the model is added to the database. In both
 // cases the GeoBound.ID field is set to that of the found/inserted row in the
 // db. An error is returned if one occurs, or nil on success.
-func (b *GeoBound) InsertIfNew() error {
+func (b *GeoBound) InsertIfNew(ctx context.Context, db dstore.Querier) error {
 	// Query
-	err := b.Query()
+	err := b.Query(ctx, db)
 
 	// If doesn't exist yet
 	if err == sql.ErrNoRows {
 		// Insert
-		if err = b.Insert(); err != nil {
+		if err = b.Insert(ctx, db); err != nil {
 			return fmt.Errorf("error inserting non existing "+
 				"GeoBound: %s", err.Error())
 		}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 
@@ -134,27 +135,20 @@ func (l GeoLoc) String() string {
 		"GAPIPlaceID: %s\n"+
 		"Raw: %s",
 		l.ID, l.Located, l.GAPISuccess, l.Lat, l.Long, l.PostalAddr,
-		l.Accuracy, l.BoundsProvided, l.BoundsID, l.ViewportBoundsID,
-		l.GAPIPlaceID, l.Raw)
+		l.Accuracy, l.BoundsProvided, l.BoundsID.Int64,
+		l.ViewportBoundsID, l.GAPIPlaceID, l.Raw)
 }
 
 // Query attempts to find a GeoLoc model in the db with the same raw field
 // value. If a model is found, the GeoLoc.ID field is set. Additionally an
 // error is returned if one occurs. sql.ErrNoRows is returned if no GeoLocs
 // were found. Or nil on success.
-func (l *GeoLoc) Query() error {
-	// Get db instance
-	db, err := dstore.NewDB()
-	if err != nil {
-		return fmt.Errorf("error retrieving db instance: %s",
-			err.Error())
-	}
-
+func (l *GeoLoc) Query(ctx context.Context, db dstore.Querier) error {
 	// Query
-	row := db.QueryRow("SELECT id FROM geo_locs WHERE raw = $1", l.Raw)
+	row := db.QueryRowContext(ctx, "SELECT id FROM geo_locs WHERE raw = $1", l.Raw)
 
 	// Get ID
-	err = row.Scan(&l.ID)
+	err := row.Scan(&l.ID)
 
 	// Check if row found
 	if err == sql.ErrNoRows {
@@ -177,20 +171,13 @@ func (l *GeoLoc) Query() error {
 // column has a unique constraint, so this is sufficient.
 //
 // An error is returned if one occurs, or nil on success.
-func (l GeoLoc) Update() error {
-	// Get database instance
-	db, err := dstore.NewDB()
-	if err != nil {
-		return fmt.Errorf("error retrieving database instance: %s",
-			err.Error())
-	}
-
+func (l GeoLoc) Update(ctx context.Context, db dstore.Querier) error {
 	// Update
 	var row *sql.Row
 
 	// If not located
 	if !l.Located {
-		row = db.QueryRow("UPDATE geo_locs SET located = $1, raw = "+
+		row = db.QueryRowContext(ctx, "UPDATE geo_locs SET located = $1, raw = "+
 			"$2 WHERE raw = $2 RETURNING id", l.Located, l.Raw)
 	} else {
 		// Check accuracy value
@@ -199,7 +186,7 @@ func (l GeoLoc) Update() error {
 				l.Accuracy)
 		}
 		// If located
-		row = db.QueryRow("UPDATE geo_locs SET located = $1, "+
+		row = db.QueryRowContext(ctx, "UPDATE geo_locs SET located = $1, "+
 			"gapi_success = $2, lat = $3, long = $4, "+
 			"postal_addr = $5, accuracy = $6, bounds_provided = $7,"+
 			"bounds_id = $8, viewport_bounds_id = $9, "+
@@ -211,7 +198,7 @@ func (l GeoLoc) Update() error {
 	}
 
 	// Set ID
-	err = row.Scan(&l.ID)
+	err := row.Scan(&l.ID)
 
 	// If doesn't exist
 	if err == sql.ErrNoRows {
@@ -229,18 +216,11 @@ func (l GeoLoc) Update() error {
 
 // QueryUnlocatedGeoLocs finds all GeoLoc models which have not been located on
 // a map. Additionally an error is returned if one occurs, or nil on success.
-func QueryUnlocatedGeoLocs() ([]*GeoLoc, error) {
+func QueryUnlocatedGeoLocs(ctx context.Context, db dstore.Querier) ([]*GeoLoc, error) {
 	locs := []*GeoLoc{}
 
-	// Get db
-	db, err := dstore.NewDB()
-	if err != nil {
-		return locs, fmt.Errorf("error retrieving database instance: %s",
-			err.Error())
-	}
-
 	// Query
-	rows, err := db.Query("SELECT id, raw FROM geo_locs WHERE located = " +
+	rows, err := db.QueryContext(ctx, "SELECT id, raw FROM geo_locs WHERE located = "+
 		"false")
 
 	// Check if no results
@@ -278,14 +258,7 @@ func QueryUnlocatedGeoLocs() ([]*GeoLoc, error) {
 
 // Insert adds a GeoLoc model to the database. An error is returned if one
 // occurs, or nil on success.
-func (l *GeoLoc) Insert() error {
-	// Get db instance
-	db, err := dstore.NewDB()
-	if err != nil {
-		return fmt.Errorf("error retrieving DB instance: %s",
-			err.Error())
-	}
-
+func (l *GeoLoc) Insert(ctx context.Context, db dstore.Querier) error {
 	// Insert
 	var row *sql.Row
 
@@ -298,7 +271,7 @@ func (l *GeoLoc) Insert() error {
 		}
 
 		// If so, save all fields
-		row = db.QueryRow("INSERT INTO geo_locs (located, gapi_success"+
+		row = db.QueryRowContext(ctx, "INSERT INTO geo_locs (located, gapi_success"+
 			", lat, long, postal_addr, accuracy, bounds_provided, "+
 			"bounds_id, viewport_bounds_id, gapi_place_id, raw) "+
 			"VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) "+
@@ -308,13 +281,13 @@ func (l *GeoLoc) Insert() error {
 			l.ViewportBoundsID, l.GAPIPlaceID, l.Raw)
 	} else {
 		// If not, only save a couple, and leave rest null
-		row = db.QueryRow("INSERT INTO geo_locs (located, raw) VALUES"+
+		row = db.QueryRowContext(ctx, "INSERT INTO geo_locs (located, raw) VALUES"+
 			" ($1, $2) RETURNING id",
 			l.Located, l.Raw)
 	}
 
 	// Get inserted row ID
-	err = row.Scan(&l.ID)
+	err := row.Scan(&l.ID)
 	if err != nil {
 		return fmt.Errorf("error inserting row, Located: %t, err: %s",
 			l.Located, err.Error())
@@ -322,3 +295,33 @@ func (l *GeoLoc) Insert() error {
 
 	return nil
 }
+
+// InsertIfNew adds a GeoLoc model to the database if one with the same raw
+// field value does not exist. Only the raw field is saved, so the GeoLoc is not
+// located. In both cases the GeoLoc.ID field is set to that of the inserted/found row. An
+// error is returned if one occurs, or nil on success.
+//
+// The raw column is unique, so if another transaction inserts the same raw
+// value at once the database waits for it to finish, instead of inserting a
+// duplicate.
+func (l *GeoLoc) InsertIfNew(ctx context.Context, db dstore.Querier) error {
+	// Insert
+	row := db.QueryRowContext(ctx, "INSERT INTO geo_locs (raw) VALUES ($1) "+
+		"ON CONFLICT (raw) DO NOTHING RETURNING id", l.Raw)
+
+	err := row.Scan(&l.ID)
+
+	// If already exists
+	if err == sql.ErrNoRows {
+		if err = l.Query(ctx, db); err != nil {
+			return fmt.Errorf("error querying for existing GeoLoc: %s",
+				err.Error())
+		}
+	} else if err != nil {
+		return fmt.Errorf("error inserting GeoLoc if new: %s",
+			err.Error())
+	}
+
+	// Success
+	return nil
+}
@@ -0,0 +1,198 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/Noah-Huppert/crime-map/dstore"
+	"github.com/lib/pq"
+)
+
+// IncidentCategory is a normalized classification of crimes. Reports from each
+// university describe incidents in their own words, crimes are assigned
+// categories so they can be compared across universities.
+type IncidentCategory struct {
+	// Name identifies the category
+	Name string
+
+	// Clery indicates if the category is one the Clery act requires
+	// universities to count in their annual security reports
+	Clery bool
+
+	// UCRCode is the FBI Uniform Crime Reporting program's NIBRS offense
+	// code for the category. Empty if the category has no offense code.
+	UCRCode string
+}
+
+// IncidentTaxonomy holds every incident category crimes can be assigned. The
+// incident_categories table holds the same categories.
+var IncidentTaxonomy []IncidentCategory = []IncidentCategory{
+	// Clery criminal offenses
+	{Name: "Murder and Non-Negligent Manslaughter", Clery: true, UCRCode: "09A"},
+	{Name: "Manslaughter by Negligence", Clery: true, UCRCode: "09B"},
+	{Name: "Rape", Clery: true, UCRCode: "11A"},
+	{Name: "Fondling", Clery: true, UCRCode: "11D"},
+	{Name: "Incest", Clery: true, UCRCode: "36A"},
+	{Name: "Statutory Rape", Clery: true, UCRCode: "36B"},
+	{Name: "Robbery", Clery: true, UCRCode: "120"},
+	{Name: "Aggravated Assault", Clery: true, UCRCode: "13A"},
+	{Name: "Burglary", Clery: true, UCRCode: "220"},
+	{Name: "Motor Vehicle Theft", Clery: true, UCRCode: "240"},
+	{Name: "Arson", Clery: true, UCRCode: "200"},
+
+	// Clery hate crime offenses
+	{Name: "Larceny-Theft", Clery: true, UCRCode: "23H"},
+	{Name: "Simple Assault", Clery: true, UCRCode: "13B"},
+	{Name: "Intimidation", Clery: true, UCRCode: "13C"},
+	{Name: "Destruction/Damage/Vandalism of Property", Clery: true,
+		UCRCode: "290"},
+
+	// Clery violence against women act offenses
+	{Name: "Domestic Violence", Clery: true, UCRCode: ""},
+	{Name: "Dating Violence", Clery: true, UCRCode: ""},
+	{Name: "Stalking", Clery: true, UCRCode: ""},
+
+	// Clery arrests and disciplinary referrals
+	{Name: "Liquor Law Violation", Clery: true, UCRCode: "90G"},
+	{Name: "Drug Law Violation", Clery: true, UCRCode: "35A"},
+	{Name: "Weapons Law Violation", Clery: true, UCRCode: "520"},
+
+	// Other offenses
+	{Name: "Fraud", Clery: false, UCRCode: "26A"},
+	{Name: "Extortion", Clery: false, UCRCode: "210"},
+	{Name: "Pornography", Clery: false, UCRCode: "370"},
+	{Name: "Disorderly Conduct", Clery: false, UCRCode: "90C"},
+	{Name: "Driving Under the Influence", Clery: false, UCRCode: "90D"},
+	{Name: "Drunkenness", Clery: false, UCRCode: "90E"},
+	{Name: "Family Offenses, Nonviolent", Clery: false, UCRCode: "90F"},
+	{Name: "Trespass of Real Property", Clery: false, UCRCode: "90J"},
+	{Name: "All Other Offenses", Clery: false, UCRCode: "90Z"},
+}
+
+// LookupIncidentCategory finds the category in IncidentTaxonomy with the
+// provided name. An error is returned if there is none, nil on success.
+func LookupIncidentCategory(name string) (IncidentCategory, error) {
+	for _, category := range IncidentTaxonomy {
+		if category.Name == name {
+			return category, nil
+		}
+	}
+
+	return IncidentCategory{}, fmt.Errorf("unknown incident category: %s",
+		name)
+}
+
+// SetCrimeCategories replaces the incident categories of Crimes in the db with
+// their Crime.Categories fields. Every category must be in IncidentTaxonomy.
+// The categories of all the crimes are written by one delete and one insert.
+// An error is returned if one occurs, nil on success.
+func SetCrimeCategories(ctx context.Context, db dstore.Querier, crimes []Crime) error {
+	// Check if anything to set
+	if len(crimes) == 0 {
+		return nil
+	}
+
+	// Flatten into a row per category
+	crimeIDs := pq.Int64Array{}
+	rowCrimeIDs := pq.Int64Array{}
+	rowCategories := pq.StringArray{}
+
+	for _, c := range crimes {
+		crimeIDs = append(crimeIDs, int64(c.ID))
+
+		for _, category := range c.Categories {
+			rowCrimeIDs = append(rowCrimeIDs, int64(c.ID))
+			rowCategories = append(rowCategories, category)
+		}
+	}
+
+	// Delete existing
+	_, err := db.ExecContext(ctx, "DELETE FROM crime_incident_categories "+
+		"WHERE crime_id = ANY($1::INTEGER[])", crimeIDs)
+	if err != nil {
+		return fmt.Errorf("error deleting crimes' incident categories: "+
+			"%s", err.Error())
+	}
+
+	// Insert
+	if len(rowCategories) == 0 {
+		return nil
+	}
+
+	_, err = db.ExecContext(ctx, "INSERT INTO crime_incident_categories "+
+		"(crime_id, category) SELECT DISTINCT * FROM "+
+		"unnest($1::INTEGER[], $2::TEXT[])", rowCrimeIDs, rowCategories)
+	if err != nil {
+		return fmt.Errorf("error inserting crimes' incident categories"+
+			": %s", err.Error())
+	}
+
+	return nil
+}
+
+// QueryIncidentCounts counts the crimes each raw incident was recorded for.
+// Crimes only listed by superseded reports are not included. Counts are returned keyed
+// by incident, along with an error if one occurs, nil on success.
+func QueryIncidentCounts(ctx context.Context, db dstore.Querier) (map[string]uint, error) {
+	counts := map[string]uint{}
+
+	// Query
+	rows, err := db.QueryContext(ctx, "SELECT incident, COUNT(*) FROM "+
+		"crimes, unnest(incidents) AS incident WHERE "+
+		crimesCurrentCond+" GROUP BY incident")
+	if err != nil {
+		return counts, fmt.Errorf("error querying database for "+
+			"incidents: %s", err.Error())
+	}
+
+	// Parse
+	for rows.Next() {
+		var incident string
+		var count uint
+
+		if err = rows.Scan(&incident, &count); err != nil {
+			rows.Close()
+			return counts, fmt.Errorf("error parsing incident row: "+
+				"%s", err.Error())
+		}
+
+		counts[incident] = count
+	}
+
+	if err = rows.Err(); err != nil {
+		rows.Close()
+		return counts, fmt.Errorf("error reading incident rows: %s",
+			err.Error())
+	}
+
+	// Close query
+	if err = rows.Close(); err != nil {
+		return counts, fmt.Errorf("error closing incidents query: %s",
+			err.Error())
+	}
+
+	return counts, nil
+}
+
+// sortedCategories returns the unique category names, sorted. An error is
+// returned if a name is not in IncidentTaxonomy, nil on success.
+func sortedCategories(names []string) ([]string, error) {
+	out := []string{}
+	seen := map[string]bool{}
+
+	for _, name := range names {
+		if _, err := LookupIncidentCategory(name); err != nil {
+			return nil, err
+		}
+
+		if !seen[name] {
+			seen[name] = true
+			out = append(out, name)
+		}
+	}
+
+	sort.Strings(out)
+
+	return out, nil
+}
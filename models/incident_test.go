@@ -0,0 +1,86 @@
+package models
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestSetCrimeCategories checks the categories of many crimes are replaced at
+// once, and crimes which are not provided are not changed
+func TestSetCrimeCategories(t *testing.T) {
+	runStoreTest(t, func(t *testing.T, store Store) {
+		ctx := context.Background()
+
+		start := time.Date(2017, time.October, 1, 0, 0, 0, 0, time.UTC)
+		report := insertTestReport(t, store, start,
+			start.Add(7*24*time.Hour))
+		loc := insertTestGeoLoc(t, store, "3200 CHESTNUT ST")
+
+		crimes := insertTestCrimes(t, store,
+			newTestCrime(report, loc, 1, "Pending"),
+			newTestCrime(report, loc, 2, "Pending"),
+			newTestCrime(report, loc, 3, "Pending"))
+
+		// Set
+		crimes[0].Categories = []string{"Larceny-Theft", "Burglary"}
+		crimes[1].Categories = []string{"Fraud"}
+		crimes[2].Categories = []string{"Fraud"}
+
+		if err := store.SetCrimeCategories(ctx, crimes); err != nil {
+			t.Fatalf("error setting categories: %s", err.Error())
+		}
+
+		// Replace some
+		crimes[0].Categories = []string{"Burglary", "Burglary"}
+		crimes[1].Categories = []string{}
+
+		if err := store.SetCrimeCategories(ctx, crimes[:2]); err != nil {
+			t.Fatalf("error setting categories: %s", err.Error())
+		}
+
+		// Check
+		saved, err := store.QueryAllCrimes(ctx, 0, 1000000,
+			OrderByReported)
+		if err != nil {
+			t.Fatalf("error querying for crimes: %s", err.Error())
+		}
+
+		want := map[int][]string{
+			crimes[0].ID: {"Burglary"},
+			crimes[1].ID: {},
+			crimes[2].ID: {"Fraud"},
+		}
+
+		for _, crime := range saved {
+			categories, ok := want[crime.ID]
+			if !ok {
+				continue
+			}
+
+			got := []string(crime.Categories)
+			if got == nil {
+				got = []string{}
+			}
+
+			if !reflect.DeepEqual(got, categories) {
+				t.Errorf("crime %d: expected categories %v, got %v",
+					crime.ID, categories, got)
+			}
+
+			delete(want, crime.ID)
+		}
+
+		if len(want) > 0 {
+			t.Fatalf("crimes not found: %v", want)
+		}
+
+		// Unknown category
+		crimes[2].Categories = []string{"Theft"}
+
+		if err := store.SetCrimeCategories(ctx, crimes[2:]); err == nil {
+			t.Fatalf("expected error setting unknown category")
+		}
+	})
+}
@@ -0,0 +1,1135 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Noah-Huppert/crime-map/date"
+)
+
+// MemStore implements Store by keeping models in memory. It enforces the same
+// uniqueness and foreign key rules as the database schema. Useful for tests
+// and demos which should not require a database. It is safe for use by
+// multiple goroutines.
+//
+// Transactions hold the MemStore's lock while they run. So fn must only use
+// the Store it is passed, not the MemStore Tx was called on.
+type MemStore struct {
+	// lock controls access to data
+	lock *sync.Mutex
+
+	// data holds the models
+	data *memData
+
+	// inTx indicates if the MemStore is a transaction
+	inTx bool
+}
+
+// memData holds the rows of each table
+type memData struct {
+	// crimes holds Crime models, keyed by ID. ParseErrors fields are not
+	// stored. Categories fields are only set by SetCrimeCategories.
+	crimes map[int]Crime
+
+	// reports holds Report models, keyed by ID
+	reports map[int]Report
+
+	// geoLocs holds GeoLoc models, keyed by ID
+	geoLocs map[int]GeoLoc
+
+	// geoBounds holds GeoBound models, keyed by ID
+	geoBounds map[int]GeoBound
+
+	// parseErrors holds ParseError models, keyed by ID
+	parseErrors map[int]ParseError
+
+	// crimeSightings holds CrimeSighting models, keyed by ID
+	crimeSightings map[int]CrimeSighting
+
+	// crimeRevisions holds CrimeRevision models, keyed by ID. Fields
+	// which are only set when queried are not stored.
+	crimeRevisions map[int]CrimeRevision
+
+	// lastIDs holds the last ID given to a row in each table. Like
+	// database sequences, IDs are not reused if a transaction is rolled
+	// back.
+	lastIDs map[string]int
+}
+
+// NewMemStore creates an empty MemStore
+func NewMemStore() *MemStore {
+	return &MemStore{
+		lock: &sync.Mutex{},
+		data: &memData{
+			crimes:         map[int]Crime{},
+			reports:        map[int]Report{},
+			geoLocs:        map[int]GeoLoc{},
+			geoBounds:      map[int]GeoBound{},
+			parseErrors:    map[int]ParseError{},
+			crimeSightings: map[int]CrimeSighting{},
+			crimeRevisions: map[int]CrimeRevision{},
+			lastIDs:        map[string]int{},
+		},
+	}
+}
+
+// clone copies the data so it can be modified without changing the original
+func (d memData) clone() *memData {
+	c := &memData{
+		crimes:         map[int]Crime{},
+		reports:        map[int]Report{},
+		geoLocs:        map[int]GeoLoc{},
+		geoBounds:      map[int]GeoBound{},
+		parseErrors:    map[int]ParseError{},
+		crimeSightings: map[int]CrimeSighting{},
+		crimeRevisions: map[int]CrimeRevision{},
+		lastIDs:        d.lastIDs,
+	}
+
+	for id, v := range d.crimes {
+		c.crimes[id] = v
+	}
+
+	for id, v := range d.reports {
+		c.reports[id] = v
+	}
+
+	for id, v := range d.geoLocs {
+		c.geoLocs[id] = v
+	}
+
+	for id, v := range d.geoBounds {
+		c.geoBounds[id] = v
+	}
+
+	for id, v := range d.parseErrors {
+		c.parseErrors[id] = v
+	}
+
+	for id, v := range d.crimeSightings {
+		c.crimeSightings[id] = v
+	}
+
+	for id, v := range d.crimeRevisions {
+		c.crimeRevisions[id] = v
+	}
+
+	return c
+}
+
+// nextID returns the next ID for a row in the table
+func (d memData) nextID(table string) int {
+	d.lastIDs[table]++
+
+	return d.lastIDs[table]
+}
+
+// Tx implements Store.Tx. Changes are made to a copy of the data, which
+// replaces the data if fn succeeds.
+func (s *MemStore) Tx(ctx context.Context, fn func(s Store) error) error {
+	// Join existing transaction
+	if s.inTx {
+		return fn(s)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// Run with copy
+	tx := &MemStore{
+		lock: &sync.Mutex{},
+		data: s.data.clone(),
+		inTx: true,
+	}
+
+	if err := fn(tx); err != nil {
+		// Return error as is so it can be identified
+		return err
+	}
+
+	// Discard if canceled, like a database transaction
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// Save
+	s.data = tx.data
+
+	return nil
+}
+
+// QueryCrime implements CrimeStore.QueryCrime
+func (s *MemStore) QueryCrime(ctx context.Context, c *Crime) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.data.queryCrime(c)
+}
+
+// queryCrime implements QueryCrime
+func (d memData) queryCrime(c *Crime) error {
+	for id, row := range d.crimes {
+		if row.University == c.University &&
+			row.ReportSuperID == c.ReportSuperID &&
+			row.ReportSubID == c.ReportSubID {
+
+			c.ID = id
+			return nil
+		}
+	}
+
+	// Return error so we can identify
+	return sql.ErrNoRows
+}
+
+// InsertCrime implements CrimeStore.InsertCrime
+func (s *MemStore) InsertCrime(ctx context.Context, c *Crime) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.data.insertCrime(c)
+}
+
+// insertCrime implements InsertCrime
+func (d memData) insertCrime(c *Crime) error {
+	// Check foreign keys
+	if _, ok := d.reports[c.ReportID]; !ok {
+		return fmt.Errorf("error inserting crime: no report with ID: %d",
+			c.ReportID)
+	}
+
+	if _, ok := d.geoLocs[c.GeoLocID]; !ok {
+		return fmt.Errorf("error inserting crime: no GeoLoc with ID: %d",
+			c.GeoLocID)
+	}
+
+	// Check unique
+	existing := Crime{
+		University:    c.University,
+		ReportSuperID: c.ReportSuperID,
+		ReportSubID:   c.ReportSubID,
+	}
+	if err := d.queryCrime(&existing); err == nil {
+		return fmt.Errorf("error inserting crime: crime %d-%d from %s "+
+			"exists", c.ReportSuperID, c.ReportSubID, c.University)
+	}
+
+	// Insert
+	c.ID = d.nextID("crimes")
+
+	row := *c
+	row.ParseErrors = nil
+	row.Categories = []string{}
+	d.crimes[c.ID] = row
+
+	d.sightCrime(*c)
+
+	return nil
+}
+
+// sightCrime saves a CrimeSighting of a saved crime in the report it was
+// parsed from, unless the crime was already sighted in the report. The
+// sighting is returned.
+func (d memData) sightCrime(c Crime) CrimeSighting {
+	for _, row := range d.crimeSightings {
+		if row.CrimeID == c.ID && row.ReportID == c.ReportID {
+			return row
+		}
+	}
+
+	sighting := CrimeSighting{
+		ID:       d.nextID("crime_sightings"),
+		CrimeID:  c.ID,
+		ReportID: c.ReportID,
+		Page:     c.Page,
+	}
+	d.crimeSightings[sighting.ID] = sighting
+
+	return sighting
+}
+
+// crimeCurrent indicates if a crime is listed by at least one report which has
+// not been superseded
+func (d memData) crimeCurrent(crimeID int) bool {
+	for _, row := range d.crimeSightings {
+		if row.CrimeID == crimeID &&
+			!d.reports[row.ReportID].SupersededBy.Valid {
+			return true
+		}
+	}
+
+	return false
+}
+
+// InsertCrimeIfNew implements CrimeStore.InsertCrimeIfNew
+func (s *MemStore) InsertCrimeIfNew(ctx context.Context, c *Crime) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.data.insertCrimeIfNew(c)
+}
+
+// insertCrimeIfNew implements InsertCrimeIfNew
+func (d memData) insertCrimeIfNew(c *Crime) error {
+	if err := d.queryCrime(c); err != sql.ErrNoRows {
+		return err
+	}
+
+	return d.insertCrime(c)
+}
+
+// InsertCrimes implements CrimeStore.InsertCrimes. If any crime can not be
+// inserted none are.
+func (s *MemStore) InsertCrimes(ctx context.Context, crimes []Crime) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	d := s.data.clone()
+
+	// saved holds the IDs of crimes saved from the provided crimes, only
+	// the first of any with the same police report ID is saved
+	saved := map[int]bool{}
+
+	for i := range crimes {
+		c := &crimes[i]
+
+		if err := d.queryCrime(c); err == sql.ErrNoRows {
+			// Insert
+			if err = d.insertCrime(c); err != nil {
+				return fmt.Errorf("error inserting crime, i: %d, "+
+					"err: %s", i, err.Error())
+			}
+
+			saved[c.ID] = true
+			continue
+		} else if err != nil {
+			return fmt.Errorf("error querying for crime, i: %d, "+
+				"err: %s", i, err.Error())
+		}
+
+		row := d.crimes[c.ID]
+
+		if !saved[c.ID] {
+			saved[c.ID] = true
+
+			if err := d.reviseCrime(c, row); err != nil {
+				return fmt.Errorf("error revising crime, i: %d, "+
+					"err: %s", i, err.Error())
+			}
+
+			row = d.crimes[c.ID]
+		}
+
+		c.ReportID = row.ReportID
+		c.Page = row.Page
+	}
+
+	s.data = d
+
+	return nil
+}
+
+// reviseCrime saves a sighting of the existing crime row, which was parsed
+// again as c. Then replaces the row's fields, saving revisions, as described
+// by InsertCrimes. An error is returned if one occurs, nil on success.
+func (d memData) reviseCrime(c *Crime, row Crime) error {
+	// Check foreign keys
+	report, ok := d.reports[c.ReportID]
+	if !ok {
+		return fmt.Errorf("no report with ID: %d", c.ReportID)
+	}
+
+	if _, ok := d.geoLocs[c.GeoLocID]; !ok {
+		return fmt.Errorf("no GeoLoc with ID: %d", c.GeoLocID)
+	}
+
+	// Sight
+	sighting := d.sightCrime(*c)
+
+	// Check if parsed from a newer report
+	end := report.RangeEndDate
+	rowEnd := d.reports[row.ReportID].RangeEndDate
+
+	if end != nil && rowEnd != nil && end.Before(*rowEnd) {
+		return nil
+	}
+
+	// Record revisions
+	if row.ReportID != c.ReportID {
+		for _, revision := range row.Revisions(*c) {
+			revision.ID = d.nextID("crime_revisions")
+			revision.SightingID = sighting.ID
+			d.crimeRevisions[revision.ID] = revision
+		}
+	}
+
+	// Replace fields
+	row.ReportID = c.ReportID
+	row.Page = c.Page
+	row.DateReported = c.DateReported
+	row.DateOccurredStart = c.DateOccurredStart
+	row.DateOccurredEnd = c.DateOccurredEnd
+	row.GeoLocID = c.GeoLocID
+	row.Incidents = c.Incidents
+	row.Descriptions = c.Descriptions
+	row.Remediation = c.Remediation
+	row.DispositionStatus = c.DispositionStatus
+	row.DispositionCount = c.DispositionCount
+	d.crimes[row.ID] = row
+
+	return nil
+}
+
+// QueryAllCrimes implements CrimeStore.QueryAllCrimes
+func (s *MemStore) QueryAllCrimes(ctx context.Context, offset uint, limit uint, orderBy OrderByType) ([]*Crime, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	crimes := []*Crime{}
+
+	// Check orderBy var
+	if _, err := NewOrderByType(string(orderBy)); err != nil {
+		return crimes, fmt.Errorf("invalid orderBy value: %s", orderBy)
+	}
+
+	// Find crimes listed by reports which are not superseded
+	for _, row := range s.data.crimes {
+		if !s.data.crimeCurrent(row.ID) {
+			continue
+		}
+
+		crime := row
+		crimes = append(crimes, &crime)
+	}
+
+	// Order, newest first
+	sort.Slice(crimes, func(i, j int) bool {
+		a, b := crimes[i], crimes[j]
+
+		var aT, bT time.Time
+		if orderBy == OrderByReported {
+			aT, bT = a.DateReported, b.DateReported
+		} else {
+			aT, bT = a.DateOccurredStart, b.DateOccurredStart
+
+			if aT.Equal(bT) {
+				aT, bT = a.DateOccurredEnd, b.DateOccurredEnd
+			}
+		}
+
+		if !aT.Equal(bT) {
+			return aT.After(bT)
+		}
+
+		return a.ID > b.ID
+	})
+
+	// Page
+	if offset >= uint(len(crimes)) {
+		return []*Crime{}, nil
+	}
+	crimes = crimes[offset:]
+
+	if limit < uint(len(crimes)) {
+		crimes = crimes[:limit]
+	}
+
+	return crimes, nil
+}
+
+// QueryCrimeSightings implements CrimeStore.QueryCrimeSightings
+func (s *MemStore) QueryCrimeSightings(ctx context.Context, crimeID int) ([]*CrimeSighting, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	sightings := []*CrimeSighting{}
+
+	for _, row := range s.data.crimeSightings {
+		if row.CrimeID != crimeID {
+			continue
+		}
+
+		sighting := row
+		sightings = append(sightings, &sighting)
+	}
+
+	// Order by end of report's range, oldest first
+	sort.Slice(sightings, func(i, j int) bool {
+		return s.data.reportBefore(sightings[i].ReportID,
+			sightings[j].ReportID, sightings[i].ID < sightings[j].ID)
+	})
+
+	return sightings, nil
+}
+
+// QueryCrimeRevisions implements CrimeStore.QueryCrimeRevisions
+func (s *MemStore) QueryCrimeRevisions(ctx context.Context, crimeID int) ([]*CrimeRevision, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	revisions := []*CrimeRevision{}
+
+	for _, row := range s.data.crimeRevisions {
+		sighting := s.data.crimeSightings[row.SightingID]
+		if sighting.CrimeID != crimeID {
+			continue
+		}
+
+		revision := row
+		revision.ReportID = sighting.ReportID
+		revisions = append(revisions, &revision)
+	}
+
+	// Order by end of report's range, oldest first
+	sort.Slice(revisions, func(i, j int) bool {
+		return s.data.reportBefore(revisions[i].ReportID,
+			revisions[j].ReportID, revisions[i].ID < revisions[j].ID)
+	})
+
+	return revisions, nil
+}
+
+// reportBefore indicates if the range of report a ends before the range of
+// report b, like ORDER BY upper(covers_range). Reports with unknown ranges are
+// last. If the ranges end at the same time tie is returned.
+func (d memData) reportBefore(a int, b int, tie bool) bool {
+	aEnd := d.reports[a].RangeEndDate
+	bEnd := d.reports[b].RangeEndDate
+
+	if aEnd == nil || bEnd == nil {
+		if (aEnd == nil) != (bEnd == nil) {
+			return bEnd == nil
+		}
+
+		return tie
+	}
+
+	if !aEnd.Equal(*bEnd) {
+		return aEnd.Before(*bEnd)
+	}
+
+	return tie
+}
+
+// SetCrimeCategories implements CrimeStore.SetCrimeCategories
+func (s *MemStore) SetCrimeCategories(ctx context.Context, crimes []Crime) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// Check all crimes before changing any
+	categories := map[int][]string{}
+
+	for _, c := range crimes {
+		// Check foreign keys
+		if _, ok := s.data.crimes[c.ID]; !ok {
+			return fmt.Errorf("error setting crime categories: no "+
+				"crime with ID: %d", c.ID)
+		}
+
+		sorted, err := sortedCategories(c.Categories)
+		if err != nil {
+			return fmt.Errorf("error setting crime categories: %s",
+				err.Error())
+		}
+
+		categories[c.ID] = sorted
+	}
+
+	// Set
+	for id, sorted := range categories {
+		row := s.data.crimes[id]
+		row.Categories = sorted
+		s.data.crimes[id] = row
+	}
+
+	return nil
+}
+
+// QueryIncidentCounts implements CrimeStore.QueryIncidentCounts
+func (s *MemStore) QueryIncidentCounts(ctx context.Context) (map[string]uint, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	counts := map[string]uint{}
+
+	for _, row := range s.data.crimes {
+		if !s.data.crimeCurrent(row.ID) {
+			continue
+		}
+
+		for _, incident := range row.Incidents {
+			counts[incident]++
+		}
+	}
+
+	return counts, nil
+}
+
+// QueryReport implements ReportStore.QueryReport
+func (s *MemStore) QueryReport(ctx context.Context, id int) (*Report, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	row, ok := s.data.reports[id]
+	if !ok {
+		// Return error so we can identify
+		return nil, sql.ErrNoRows
+	}
+
+	return &row, nil
+}
+
+// QueryReportByFile implements ReportStore.QueryReportByFile
+func (s *MemStore) QueryReportByFile(ctx context.Context, r *Report) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, row := range s.data.reports {
+		if row.FileHash == r.FileHash {
+			r.ID = row.ID
+			r.ParseSuccess = row.ParseSuccess
+			r.CrimesCount = row.CrimesCount
+
+			return nil
+		}
+	}
+
+	// Return error so we can identify
+	return sql.ErrNoRows
+}
+
+// QueryPreviousReport implements ReportStore.QueryPreviousReport
+func (s *MemStore) QueryPreviousReport(ctx context.Context, r Report) (*Report, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var prev *Report
+
+	for _, row := range s.data.reports {
+		if row.University != r.University ||
+			!timesEqual(row.RangeStartDate, r.RangeStartDate) ||
+			!timesEqual(row.RangeEndDate, r.RangeEndDate) ||
+			row.SupersededBy.Valid || row.ID == r.ID {
+			continue
+		}
+
+		// Most recent
+		if prev == nil || row.ID > prev.ID {
+			found := row
+			prev = &found
+		}
+	}
+
+	if prev == nil {
+		// Return error so we can identify
+		return nil, sql.ErrNoRows
+	}
+
+	return prev, nil
+}
+
+// InsertReport implements ReportStore.InsertReport
+func (s *MemStore) InsertReport(ctx context.Context, r *Report) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// Check file hash unique
+	if err := s.data.checkReportFileHash(r.FileHash, 0); err != nil {
+		return fmt.Errorf("error inserting Report: %s", err.Error())
+	}
+
+	// Insert
+	r.ID = s.data.nextID("reports")
+	s.data.reports[r.ID] = *r
+
+	return nil
+}
+
+// checkReportFileHash returns an error if a report, other than the report with
+// the ID, has the same non empty file hash
+func (d memData) checkReportFileHash(hash string, id int) error {
+	if len(hash) == 0 {
+		return nil
+	}
+
+	for _, row := range d.reports {
+		if row.ID != id && row.FileHash == hash {
+			return fmt.Errorf("duplicate file hash: %s, already used "+
+				"by report %d", hash, row.ID)
+		}
+	}
+
+	return nil
+}
+
+// UpdateReportPostParseFields implements
+// ReportStore.UpdateReportPostParseFields
+func (s *MemStore) UpdateReportPostParseFields(ctx context.Context, r Report) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// Like an UPDATE, nothing happens if no report has the ID
+	if row, ok := s.data.reports[r.ID]; ok {
+		row.ParseSuccess = r.ParseSuccess
+		row.ParsePartial = r.ParsePartial
+		row.CrimesCount = r.CrimesCount
+		row.PagesFailed = r.PagesFailed
+		row.Diagnostics = r.Diagnostics
+		s.data.reports[r.ID] = row
+	}
+
+	return nil
+}
+
+// UpdateReportFileFields implements ReportStore.UpdateReportFileFields
+func (s *MemStore) UpdateReportFileFields(ctx context.Context, r Report) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// Check file hash unique
+	if err := s.data.checkReportFileHash(r.FileHash, r.ID); err != nil {
+		return fmt.Errorf("error updating Report: %s", err.Error())
+	}
+
+	// Like an UPDATE, nothing happens if no report has the ID
+	if row, ok := s.data.reports[r.ID]; ok {
+		row.FileHash = r.FileHash
+		row.FileName = r.FileName
+		row.FileSize = r.FileSize
+		s.data.reports[r.ID] = row
+	}
+
+	return nil
+}
+
+// SupersedeReport implements ReportStore.SupersedeReport
+func (s *MemStore) SupersedeReport(ctx context.Context, r Report, old *Report) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// Check foreign key
+	if _, ok := s.data.reports[r.ID]; !ok {
+		return fmt.Errorf("error superseding Report: no report with "+
+			"ID: %d", r.ID)
+	}
+
+	superseded := sql.NullInt64{
+		Int64: int64(r.ID),
+		Valid: true,
+	}
+
+	// Like an UPDATE, nothing happens if no report has the ID
+	if row, ok := s.data.reports[old.ID]; ok {
+		row.SupersededBy = superseded
+		s.data.reports[old.ID] = row
+	}
+
+	old.SupersededBy = superseded
+
+	return nil
+}
+
+// DeleteReportCrimes implements ReportStore.DeleteReportCrimes
+func (s *MemStore) DeleteReportCrimes(ctx context.Context, r *Report) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// Delete sightings, and their revisions
+	for sID, sighting := range s.data.crimeSightings {
+		if sighting.ReportID != r.ID {
+			continue
+		}
+
+		for rID, revision := range s.data.crimeRevisions {
+			if revision.SightingID == sID {
+				delete(s.data.crimeRevisions, rID)
+			}
+		}
+
+		delete(s.data.crimeSightings, sID)
+	}
+
+	// Delete crimes no report lists, and their parse errors
+	sighted := map[int]bool{}
+	for _, sighting := range s.data.crimeSightings {
+		sighted[sighting.CrimeID] = true
+	}
+
+	for id := range s.data.crimes {
+		if sighted[id] {
+			continue
+		}
+
+		for pID, pErr := range s.data.parseErrors {
+			if pErr.CrimeID == id {
+				delete(s.data.parseErrors, pID)
+			}
+		}
+
+		delete(s.data.crimes, id)
+	}
+
+	// Delete parse errors from parsing the report
+	for pID, pErr := range s.data.parseErrors {
+		if pErr.ReportID == r.ID {
+			delete(s.data.parseErrors, pID)
+		}
+	}
+
+	// Reset post parse fields
+	r.ParseSuccess = false
+	r.ParsePartial = false
+	r.CrimesCount = 0
+	r.PagesFailed = 0
+	r.Diagnostics = ""
+
+	if row, ok := s.data.reports[r.ID]; ok {
+		row.ParseSuccess = false
+		row.ParsePartial = false
+		row.CrimesCount = 0
+		row.PagesFailed = 0
+		row.Diagnostics = ""
+		s.data.reports[r.ID] = row
+	}
+
+	return nil
+}
+
+// LocalizeReport implements ReportStore.LocalizeReport
+func (s *MemStore) LocalizeReport(ctx context.Context, r *Report, loc *time.Location) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// Check not already localized, correcting again would shift times
+	// twice
+	if len(r.TimeZone) > 0 {
+		return fmt.Errorf("report times already in time zone: %s",
+			r.TimeZone)
+	}
+
+	// Correct crimes
+	for id, crime := range s.data.crimes {
+		if crime.ReportID != r.ID {
+			continue
+		}
+
+		crime.DateReported = date.Relocate(crime.DateReported, loc)
+		crime.DateOccurredStart = date.Relocate(crime.DateOccurredStart,
+			loc)
+		crime.DateOccurredEnd = date.Relocate(crime.DateOccurredEnd, loc)
+		s.data.crimes[id] = crime
+	}
+
+	// Correct report, reports which failed to parse may have no range
+	if r.RangeStartDate != nil && r.RangeEndDate != nil {
+		start := date.Relocate(*r.RangeStartDate, loc)
+		end := date.Relocate(*r.RangeEndDate, loc)
+
+		r.RangeStartDate = &start
+		r.RangeEndDate = &end
+	}
+
+	r.TimeZone = loc.String()
+
+	// Like an UPDATE, nothing happens if no report has the ID
+	if row, ok := s.data.reports[r.ID]; ok {
+		row.RangeStartDate = r.RangeStartDate
+		row.RangeEndDate = r.RangeEndDate
+		row.TimeZone = r.TimeZone
+		s.data.reports[r.ID] = row
+	}
+
+	return nil
+}
+
+// QueryAllReports implements ReportStore.QueryAllReports
+func (s *MemStore) QueryAllReports(ctx context.Context) ([]*Report, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	reports := []*Report{}
+
+	for _, row := range s.data.reports {
+		report := row
+		reports = append(reports, &report)
+	}
+
+	// Order by parsed on, newest first
+	sort.Slice(reports, func(i, j int) bool {
+		a, b := reports[i], reports[j]
+
+		if a.ParsedOn != nil && b.ParsedOn != nil &&
+			!a.ParsedOn.Equal(*b.ParsedOn) {
+			return a.ParsedOn.After(*b.ParsedOn)
+		}
+
+		return a.ID > b.ID
+	})
+
+	return reports, nil
+}
+
+// QueryGeoLoc implements GeoLocStore.QueryGeoLoc
+func (s *MemStore) QueryGeoLoc(ctx context.Context, l *GeoLoc) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	row, ok := s.data.geoLocByRaw(l.Raw)
+	if !ok {
+		// Return error so we can identify
+		return sql.ErrNoRows
+	}
+
+	l.ID = row.ID
+
+	return nil
+}
+
+// geoLocByRaw finds the GeoLoc with the raw location. A boolean indicating if
+// one was found is returned.
+func (d memData) geoLocByRaw(raw string) (GeoLoc, bool) {
+	for _, row := range d.geoLocs {
+		if row.Raw == raw {
+			return row, true
+		}
+	}
+
+	return GeoLoc{}, false
+}
+
+// checkGeoLocBounds returns an error if the GeoLoc references GeoBounds which
+// do not exist
+func (d memData) checkGeoLocBounds(l GeoLoc) error {
+	if l.BoundsID.Valid {
+		if _, ok := d.geoBounds[int(l.BoundsID.Int64)]; !ok {
+			return fmt.Errorf("no GeoBound with ID: %d",
+				l.BoundsID.Int64)
+		}
+	}
+
+	if _, ok := d.geoBounds[l.ViewportBoundsID]; !ok {
+		return fmt.Errorf("no viewport GeoBound with ID: %d",
+			l.ViewportBoundsID)
+	}
+
+	return nil
+}
+
+// InsertGeoLoc implements GeoLocStore.InsertGeoLoc
+func (s *MemStore) InsertGeoLoc(ctx context.Context, l *GeoLoc) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// Check raw unique
+	if _, ok := s.data.geoLocByRaw(l.Raw); ok {
+		return fmt.Errorf("error inserting GeoLoc: duplicate raw "+
+			"value: %s", l.Raw)
+	}
+
+	// Like the database, only save located fields if located
+	row := *NewGeoLoc(l.Raw)
+
+	if l.Located {
+		if l.Accuracy == AccuracyErr {
+			return fmt.Errorf("invalid accuracy value: %s",
+				l.Accuracy)
+		}
+
+		if err := s.data.checkGeoLocBounds(*l); err != nil {
+			return fmt.Errorf("error inserting GeoLoc: %s",
+				err.Error())
+		}
+
+		row = *l
+	}
+
+	// Insert
+	l.ID = s.data.nextID("geo_locs")
+	row.ID = l.ID
+	s.data.geoLocs[l.ID] = row
+
+	return nil
+}
+
+// InsertGeoLocIfNew implements GeoLocStore.InsertGeoLocIfNew
+func (s *MemStore) InsertGeoLocIfNew(ctx context.Context, l *GeoLoc) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// Query
+	if row, ok := s.data.geoLocByRaw(l.Raw); ok {
+		l.ID = row.ID
+		return nil
+	}
+
+	// Insert, like the database only the raw field
+	row := *NewGeoLoc(l.Raw)
+
+	l.ID = s.data.nextID("geo_locs")
+	row.ID = l.ID
+	s.data.geoLocs[l.ID] = row
+
+	return nil
+}
+
+// UpdateGeoLoc implements GeoLocStore.UpdateGeoLoc
+func (s *MemStore) UpdateGeoLoc(ctx context.Context, l GeoLoc) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// Find by raw, which is unique
+	row, ok := s.data.geoLocByRaw(l.Raw)
+	if !ok {
+		// Return error so we can identify
+		return sql.ErrNoRows
+	}
+
+	// Update
+	if !l.Located {
+		row.Located = false
+	} else {
+		if l.Accuracy == AccuracyErr {
+			return fmt.Errorf("invalid accuracy value: %s",
+				l.Accuracy)
+		}
+
+		if err := s.data.checkGeoLocBounds(l); err != nil {
+			return fmt.Errorf("error updating GeoLoc: %s",
+				err.Error())
+		}
+
+		l.ID = row.ID
+		row = l
+	}
+
+	s.data.geoLocs[row.ID] = row
+
+	return nil
+}
+
+// QueryUnlocatedGeoLocs implements GeoLocStore.QueryUnlocatedGeoLocs
+func (s *MemStore) QueryUnlocatedGeoLocs(ctx context.Context) ([]*GeoLoc, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	locs := []*GeoLoc{}
+
+	for _, row := range s.data.geoLocs {
+		if row.Located {
+			continue
+		}
+
+		// Only ID and raw fields, like NewUnlocatedGeoLoc
+		loc := NewGeoLoc(row.Raw)
+		loc.ID = row.ID
+
+		locs = append(locs, loc)
+	}
+
+	sort.Slice(locs, func(i, j int) bool {
+		return locs[i].ID < locs[j].ID
+	})
+
+	return locs, nil
+}
+
+// InsertGeoBoundIfNew implements GeoLocStore.InsertGeoBoundIfNew
+func (s *MemStore) InsertGeoBoundIfNew(ctx context.Context, b *GeoBound) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// Query
+	for id, row := range s.data.geoBounds {
+		if row.NeLat == b.NeLat && row.NeLong == b.NeLong &&
+			row.SwLat == b.SwLat && row.SwLong == b.SwLong {
+
+			b.ID = id
+			return nil
+		}
+	}
+
+	// Insert
+	b.ID = s.data.nextID("geo_bounds")
+	s.data.geoBounds[b.ID] = *b
+
+	return nil
+}
+
+// QueryParseError implements ParseErrorStore.QueryParseError
+func (s *MemStore) QueryParseError(ctx context.Context, e *ParseError) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.data.queryParseError(e)
+}
+
+// queryParseError implements QueryParseError
+func (d memData) queryParseError(e *ParseError) error {
+	for id, row := range d.parseErrors {
+		if row.CrimeID == e.CrimeID && row.ReportID == e.ReportID &&
+			row.Field == e.Field &&
+			row.Original == e.Original &&
+			row.Corrected == e.Corrected &&
+			row.ErrType == e.ErrType {
+
+			e.ID = id
+			return nil
+		}
+	}
+
+	// Return error so we can identify
+	return sql.ErrNoRows
+}
+
+// InsertParseError implements ParseErrorStore.InsertParseError
+func (s *MemStore) InsertParseError(ctx context.Context, e *ParseError) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.data.insertParseError(e)
+}
+
+// insertParseError implements InsertParseError
+func (d memData) insertParseError(e *ParseError) error {
+	// Check foreign keys
+	if _, ok := d.crimes[e.CrimeID]; !ok && e.CrimeID != 0 {
+		return fmt.Errorf("error inserting ParseError: no crime with "+
+			"ID: %d", e.CrimeID)
+	}
+
+	if _, ok := d.reports[e.ReportID]; !ok && e.ReportID != 0 {
+		return fmt.Errorf("error inserting ParseError: no report with "+
+			"ID: %d", e.ReportID)
+	}
+
+	// Insert
+	e.ID = d.nextID("parse_errors")
+	d.parseErrors[e.ID] = *e
+
+	return nil
+}
+
+// InsertParseErrorIfNew implements ParseErrorStore.InsertParseErrorIfNew
+func (s *MemStore) InsertParseErrorIfNew(ctx context.Context, e *ParseError) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := s.data.queryParseError(e); err != sql.ErrNoRows {
+		return err
+	}
+
+	return s.data.insertParseError(e)
+}
+
+// timesEqual indicates if two optional times are the same instant
+func timesEqual(a *time.Time, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return a.Equal(*b)
+}
@@ -0,0 +1,240 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+)
+
+// TestMemStoreGeoLocRawUnique checks GeoLoc raw locations are unique, like the
+// geo_locs.raw column
+func TestMemStoreGeoLocRawUnique(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemStore()
+
+	first := NewGeoLoc("3200 CHESTNUT ST")
+	if err := store.InsertGeoLoc(ctx, first); err != nil {
+		t.Fatalf("error inserting GeoLoc: %s", err.Error())
+	}
+
+	// Insert duplicate
+	if err := store.InsertGeoLoc(ctx, NewGeoLoc(first.Raw)); err == nil {
+		t.Fatalf("expected error inserting duplicate raw location")
+	}
+
+	// Insert if new
+	again := NewGeoLoc(first.Raw)
+	if err := store.InsertGeoLocIfNew(ctx, again); err != nil {
+		t.Fatalf("error inserting GeoLoc if new: %s", err.Error())
+	}
+
+	if again.ID != first.ID {
+		t.Fatalf("expected existing GeoLoc ID %d, got %d", first.ID,
+			again.ID)
+	}
+
+	other := NewGeoLoc("3300 MARKET ST")
+	if err := store.InsertGeoLocIfNew(ctx, other); err != nil {
+		t.Fatalf("error inserting GeoLoc if new: %s", err.Error())
+	}
+
+	if other.ID == first.ID {
+		t.Fatalf("expected new GeoLoc ID, got existing ID %d", other.ID)
+	}
+
+	locs, err := store.QueryUnlocatedGeoLocs(ctx)
+	if err != nil {
+		t.Fatalf("error querying for GeoLocs: %s", err.Error())
+	}
+
+	if len(locs) != 2 {
+		t.Fatalf("expected 2 GeoLocs, got %d", len(locs))
+	}
+}
+
+// TestMemStoreTxRollback checks changes made by a transaction which fails are
+// discarded, and changes made by one which succeeds are saved
+func TestMemStoreTxRollback(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemStore()
+
+	existing := insertTestGeoLoc(t, store, "3200 CHESTNUT ST")
+
+	// Roll back
+	var rolledBack *GeoLoc
+
+	err := store.Tx(ctx, func(tx Store) error {
+		rolledBack = insertTestGeoLoc(t, tx, "3300 MARKET ST")
+
+		// Change existing row
+		bound := &GeoBound{NeLat: 1, NeLong: 1, SwLat: 0, SwLong: 0}
+		if err := tx.InsertGeoBoundIfNew(ctx, bound); err != nil {
+			t.Fatalf("error inserting GeoBound: %s", err.Error())
+		}
+
+		located := *existing
+		located.Located = true
+		located.Accuracy = AccuracyPerfect
+		located.ViewportBoundsID = bound.ID
+
+		if err := tx.UpdateGeoLoc(ctx, located); err != nil {
+			t.Fatalf("error updating GeoLoc: %s", err.Error())
+		}
+
+		return errTestRollback
+	})
+	if err != errTestRollback {
+		t.Fatalf("expected transaction error returned as is, got: %v",
+			err)
+	}
+
+	locs, err := store.QueryUnlocatedGeoLocs(ctx)
+	if err != nil {
+		t.Fatalf("error querying for GeoLocs: %s", err.Error())
+	}
+
+	if len(locs) != 1 || locs[0].ID != existing.ID {
+		t.Fatalf("expected only unlocated GeoLoc %d after rollback, "+
+			"got %d GeoLocs", existing.ID, len(locs))
+	}
+
+	// Commit
+	var committed *GeoLoc
+
+	err = store.Tx(ctx, func(tx Store) error {
+		committed = insertTestGeoLoc(t, tx, "3300 MARKET ST")
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("error running transaction: %s", err.Error())
+	}
+
+	// IDs are not reused, like database sequences
+	if committed.ID == rolledBack.ID {
+		t.Fatalf("expected rolled back ID %d not to be reused",
+			rolledBack.ID)
+	}
+
+	found := NewGeoLoc(committed.Raw)
+	if err := store.QueryGeoLoc(ctx, found); err != nil {
+		t.Fatalf("error querying for committed GeoLoc: %s", err.Error())
+	}
+
+	if found.ID != committed.ID {
+		t.Fatalf("expected committed GeoLoc ID %d, got %d",
+			committed.ID, found.ID)
+	}
+}
+
+// TestMemStoreTxCanceled checks changes made by a transaction are discarded if
+// its context is canceled before it finishes
+func TestMemStoreTxCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	store := NewMemStore()
+
+	err := store.Tx(ctx, func(tx Store) error {
+		insertTestGeoLoc(t, tx, "3200 CHESTNUT ST")
+		cancel()
+
+		return nil
+	})
+	if err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got: %v", err)
+	}
+
+	err = store.QueryGeoLoc(context.Background(),
+		NewGeoLoc("3200 CHESTNUT ST"))
+	if err != sql.ErrNoRows {
+		t.Fatalf("expected GeoLoc to be discarded, got: %v", err)
+	}
+}
+
+// TestMemStoreTxIsolation checks a transaction works on a copy of the data.
+// Changes made to the copy after it is discarded do not alter the store.
+func TestMemStoreTxIsolation(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemStore()
+
+	start := time.Date(2017, 10, 1, 0, 0, 0, 0, time.UTC)
+	report := insertTestReport(t, store, start, start.AddDate(0, 0, 7))
+
+	// Keep transaction store after it is rolled back
+	var leaked Store
+
+	err := store.Tx(ctx, func(tx Store) error {
+		leaked = tx
+		return errTestRollback
+	})
+	if err != errTestRollback {
+		t.Fatalf("expected transaction error returned as is, got: %v",
+			err)
+	}
+
+	// Change the report, and insert a GeoLoc
+	superseding := insertTestReport(t, leaked, start, start.AddDate(0, 0, 7))
+
+	if err := leaked.SupersedeReport(ctx, *superseding, report); err != nil {
+		t.Fatalf("error superseding report: %s", err.Error())
+	}
+
+	insertTestGeoLoc(t, leaked, "3200 CHESTNUT ST")
+
+	// Check store unchanged
+	found, err := store.QueryReport(ctx, report.ID)
+	if err != nil {
+		t.Fatalf("error querying for report: %s", err.Error())
+	}
+
+	if found.SupersededBy.Valid {
+		t.Fatalf("expected report not to be superseded, superseded "+
+			"by: %d", found.SupersededBy.Int64)
+	}
+
+	if _, err := store.QueryReport(ctx, superseding.ID); err != sql.ErrNoRows {
+		t.Fatalf("expected no report with ID %d, got: %v",
+			superseding.ID, err)
+	}
+
+	err = store.QueryGeoLoc(ctx, NewGeoLoc("3200 CHESTNUT ST"))
+	if err != sql.ErrNoRows {
+		t.Fatalf("expected no GeoLoc, got: %v", err)
+	}
+}
+
+// TestMemStoreLocalizeReportNoRange checks the times of a report which failed
+// to parse, and so has no range, can be corrected
+func TestMemStoreLocalizeReportNoRange(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemStore()
+
+	now := time.Now()
+	report := NewReport(UniversityDrexel, &now, nil, nil, 1)
+
+	if err := store.InsertReport(ctx, report); err != nil {
+		t.Fatalf("error inserting report: %s", err.Error())
+	}
+
+	loc, err := UniversityDrexel.Location()
+	if err != nil {
+		t.Fatalf("error loading time zone: %s", err.Error())
+	}
+
+	if err = store.LocalizeReport(ctx, report, loc); err != nil {
+		t.Fatalf("error localizing report: %s", err.Error())
+	}
+
+	found, err := store.QueryReport(ctx, report.ID)
+	if err != nil {
+		t.Fatalf("error querying for report: %s", err.Error())
+	}
+
+	if found.TimeZone != loc.String() {
+		t.Fatalf("expected time zone %s, got %s", loc.String(),
+			found.TimeZone)
+	}
+
+	if found.RangeStartDate != nil || found.RangeEndDate != nil {
+		t.Fatalf("expected report to have no range")
+	}
+}
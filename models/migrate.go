@@ -1,37 +1,51 @@
 package models
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
-	"github.com/Noah-Huppert/crime-map/dstore"
 	"github.com/mattes/migrate"
 	"github.com/mattes/migrate/database/postgres"
 	_ "github.com/mattes/migrate/source/file"
 )
 
-// Migrate will attempt to create all tables defined by models. And return an
-// error if one occurs, nil otherwise.
-func Migrate() error {
-	// Make db instance
-	db, err := dstore.NewDB()
-	if err != nil {
-		return fmt.Errorf("error making db instance: %s", err.Error())
-	}
+// migrationsSource is the location of the migration files
+const migrationsSource string = "file://./migrations"
 
+// ErrNoMigrations is returned by MigrationVersion if no migrations have been
+// applied to the database yet
+var ErrNoMigrations error = errors.New("no migrations have been applied")
+
+// newMigrator creates a migrate.Migrate instance which runs the migrations in
+// the migrations directory against the provided database. An error is returned
+// if one occurs, nil on success.
+func newMigrator(db *sql.DB) (*migrate.Migrate, error) {
 	// Make db driver for migration
 	driver, err := postgres.WithInstance(db, &postgres.Config{})
 	if err != nil {
-		return fmt.Errorf("error making db driver: %s", err.Error())
+		return nil, fmt.Errorf("error making db driver: %s", err.Error())
 	}
 
 	// Create migrator
-	migrator, err := migrate.NewWithDatabaseInstance(
-		"file://./migrations",
+	migrator, err := migrate.NewWithDatabaseInstance(migrationsSource,
 		"postgres", driver)
 	if err != nil {
-		return fmt.Errorf("error making migrator instance: %s",
+		return nil, fmt.Errorf("error making migrator instance: %s",
 			err.Error())
 	}
 
+	return migrator, nil
+}
+
+// Migrate will attempt to create all tables defined by models in the provided
+// database. And return an error if one occurs, nil otherwise.
+func Migrate(db *sql.DB) error {
+	// Create migrator
+	migrator, err := newMigrator(db)
+	if err != nil {
+		return err
+	}
+
 	// Run
 	if err = migrator.Up(); err != nil && err != migrate.ErrNoChange {
 		return fmt.Errorf("error running migrations: %s", err.Error())
@@ -39,3 +53,53 @@ func Migrate() error {
 
 	return nil
 }
+
+// MigrateDown will revert the specified number of migrations. If steps is 0
+// all migrations are reverted. An error is returned if one occurs, nil
+// otherwise.
+func MigrateDown(db *sql.DB, steps uint) error {
+	// Create migrator
+	migrator, err := newMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	// Run
+	if steps == 0 {
+		err = migrator.Down()
+	} else {
+		err = migrator.Steps(-int(steps))
+	}
+
+	if err != nil && err != migrate.ErrNoChange {
+		return fmt.Errorf("error reverting migrations: %s", err.Error())
+	}
+
+	return nil
+}
+
+// MigrationVersion returns the version of the last migration applied to the
+// database. Along with a boolean which indicates if the last migration failed
+// part way through, leaving the database dirty.
+//
+// An error is returned if one occurs, nil on success. ErrNoMigrations is
+// returned if no migrations have been applied.
+func MigrationVersion(db *sql.DB) (uint, bool, error) {
+	// Create migrator
+	migrator, err := newMigrator(db)
+	if err != nil {
+		return 0, false, err
+	}
+
+	// Get version
+	version, dirty, err := migrator.Version()
+	if err == migrate.ErrNilVersion {
+		// Return error so we can identify
+		return 0, false, ErrNoMigrations
+	} else if err != nil {
+		return 0, false, fmt.Errorf("error retrieving migration "+
+			"version: %s", err.Error())
+	}
+
+	return version, dirty, nil
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 
@@ -15,19 +16,52 @@ const (
 	// TypeBadRangeEnd signifies that a Crime's date range's end date
 	// occurred before a range's start date.
 	TypeBadRangeEnd ParseErrorType = "BAD_RANGE_END"
+
+	// TypeMissingRange signifies that a Crime's date occurred range was
+	// missing, so the date reported was used in its place.
+	TypeMissingRange ParseErrorType = "MISSING_RANGE"
+
+	// TypeBadReportID signifies that a record's report ID could not be
+	// parsed, so the record was skipped
+	TypeBadReportID ParseErrorType = "BAD_REPORT_ID"
+
+	// TypeUnknownField signifies that text in a report could not be
+	// assigned to any field, so it was ignored
+	TypeUnknownField ParseErrorType = "UNKNOWN_FIELD"
+
+	// TypeCountMismatch signifies that the number of crimes parsed from a
+	// report did not match the number the report lists, or the listed
+	// number could not be found
+	TypeCountMismatch ParseErrorType = "COUNT_MISMATCH"
+
+	// TypeBadDate signifies that a date in a record could not be parsed,
+	// so the record was skipped
+	TypeBadDate ParseErrorType = "BAD_DATE"
+
+	// TypeTruncatedRecord signifies that a record was missing required
+	// fields, so it was skipped
+	TypeTruncatedRecord ParseErrorType = "TRUNCATED_RECORD"
 )
 
 // ParseError structs holds details about errors which occur while parsing
 // crimes from reports. And the actions that take place to fix them.
 //
 // This information is recorded just in case the crime was fixed incorrectly.
+// Parse errors which do not refer to one crime, ex: records which were skipped
+// when parsing leniently, refer to the report instead.
 type ParseError struct {
 	// ID is the unique identifier of the parse error
 	ID int
 
-	// CrimeID is ID of the Crime which the parse error refers to
+	// CrimeID is ID of the Crime which the parse error refers to. 0 if
+	// the parse error refers to a report.
 	CrimeID int
 
+	// ReportID is the ID of the Report which the parse error refers to.
+	// If the parse error refers to a crime, the report which was being
+	// parsed. 0 if unknown.
+	ReportID int
+
 	// Field holds the name of the crime field which was corrected
 	Field string
 
@@ -46,11 +80,13 @@ type ParseError struct {
 func (e ParseError) String() string {
 	return fmt.Sprintf("ID: %d\n"+
 		"Crime ID: %d\n"+
+		"Report ID: %d\n"+
 		"Field: %s\n"+
 		"Original: %s\n"+
 		"Corrected: %s\n"+
 		"ErrType: %s",
-		e.ID, e.CrimeID, e.Field, e.Original, e.Corrected, e.ErrType)
+		e.ID, e.CrimeID, e.ReportID, e.Field, e.Original, e.Corrected,
+		e.ErrType)
 }
 
 // StringParseErrors converts a slice of Parse Errors to a slice of strings
@@ -78,21 +114,16 @@ func StringParseErrors(errs []ParseError) []string {
 //
 // The ParseError.ID field will be set to record the ID of the row in the
 // database.
-func (e *ParseError) Query() error {
-	// Get database instance
-	db, err := dstore.NewDB()
-	if err != nil {
-		return fmt.Errorf("error retrieving database instance: %s",
-			err.Error())
-	}
-
+func (e *ParseError) Query(ctx context.Context, db dstore.Querier) error {
 	// Query
-	row := db.QueryRow("SELECT id FROM parse_errors WHERE crime_id = $1 AND "+
-		"field = $2 AND original = $3 AND corrected = $4 AND err_type = $5",
-		e.CrimeID, e.Field, e.Original, e.Corrected, e.ErrType)
+	row := db.QueryRowContext(ctx, "SELECT id FROM parse_errors WHERE crime_id IS NOT "+
+		"DISTINCT FROM $1 AND report_id IS NOT DISTINCT FROM $2 AND "+
+		"field = $3 AND original = $4 AND corrected = $5 AND "+
+		"err_type = $6", nullID(e.CrimeID), nullID(e.ReportID),
+		e.Field, e.Original, e.Corrected, e.ErrType)
 
 	// Get ID
-	err = row.Scan(&e.ID)
+	err := row.Scan(&e.ID)
 
 	// Check if not found
 	if err == sql.ErrNoRows {
@@ -113,21 +144,15 @@ func (e *ParseError) Query() error {
 //
 // The ParseError.ID field will be set to record the ID of the newly inserted
 // row.
-func (e *ParseError) Insert() error {
-	// Get db
-	db, err := dstore.NewDB()
-	if err != nil {
-		return fmt.Errorf("error retrieving database instance: %s",
-			err.Error())
-	}
-
+func (e *ParseError) Insert(ctx context.Context, db dstore.Querier) error {
 	// Insert
-	row := db.QueryRow("INSERT INTO parse_errors (crime_id, field, original, "+
-		"corrected, err_type) VALUES ($1, $2, $3, $4, $5) RETURNING id",
-		e.CrimeID, e.Field, e.Original, e.Corrected, e.ErrType)
+	row := db.QueryRowContext(ctx, "INSERT INTO parse_errors (crime_id, report_id, "+
+		"field, original, corrected, err_type) VALUES ($1, $2, $3, $4, "+
+		"$5, $6) RETURNING id", nullID(e.CrimeID), nullID(e.ReportID),
+		e.Field, e.Original, e.Corrected, e.ErrType)
 
 	// Get ID
-	err = row.Scan(&e.ID)
+	err := row.Scan(&e.ID)
 	if err != nil {
 		return fmt.Errorf("error inserting ParseError model: %s",
 			err.Error())
@@ -142,14 +167,14 @@ func (e *ParseError) Insert() error {
 // ParseError.ID field.
 //
 // An error will be returned if one occurs, or nil on success
-func (e *ParseError) InsertIfNew() error {
+func (e *ParseError) InsertIfNew(ctx context.Context, db dstore.Querier) error {
 	// Query
-	err := e.Query()
+	err := e.Query(ctx, db)
 
 	// Check if doesn't exist
 	if err == sql.ErrNoRows {
 		// Insert
-		if err = e.Insert(); err != nil {
+		if err = e.Insert(ctx, db); err != nil {
 			return fmt.Errorf("error inserting non-existent "+
 				"ParseError: %s", err.Error())
 		}
@@ -160,3 +185,12 @@ func (e *ParseError) InsertIfNew() error {
 	// Success
 	return nil
 }
+
+// nullID converts a foreign key ID into a nullable column value. Where 0
+// indicates no row is referenced.
+func nullID(id int) sql.NullInt64 {
+	return sql.NullInt64{
+		Int64: int64(id),
+		Valid: id != 0,
+	}
+}
@@ -0,0 +1,325 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Noah-Huppert/crime-map/dstore"
+)
+
+// PgStore implements Store using a Postgres database
+type PgStore struct {
+	// db is the database models are saved in
+	db *sql.DB
+
+	// queryTimeout is the maximum amount of time each query may run for.
+	// 0 if queries may run for any amount of time.
+	queryTimeout time.Duration
+
+	// tx is the transaction changes are made in. Nil if the PgStore is not
+	// a transaction.
+	tx *sql.Tx
+}
+
+// NewPgStore creates a PgStore which saves models in the provided database.
+// Queries are canceled if they run for longer than queryTimeout, unless it is
+// 0.
+func NewPgStore(db *sql.DB, queryTimeout time.Duration) *PgStore {
+	return &PgStore{
+		db:           db,
+		queryTimeout: queryTimeout,
+	}
+}
+
+// querier returns the transaction if the PgStore is one, otherwise the
+// database
+func (s *PgStore) querier() dstore.Querier {
+	if s.tx != nil {
+		return s.tx
+	}
+
+	return s.db
+}
+
+// timeout returns a context which is canceled once the query timeout passes.
+// The returned cancel function must be called once the query finishes.
+func (s *PgStore) timeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.queryTimeout == 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, s.queryTimeout)
+}
+
+// Tx implements Store.Tx. The query timeout applies to each query in the
+// transaction, not the transaction as a whole.
+func (s *PgStore) Tx(ctx context.Context, fn func(s Store) error) error {
+	// Join existing transaction
+	if s.tx != nil {
+		return fn(s)
+	}
+
+	// Start
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %s", err.Error())
+	}
+
+	// Run
+	err = fn(&PgStore{
+		db:           s.db,
+		queryTimeout: s.queryTimeout,
+		tx:           tx,
+	})
+	if err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("error rolling back transaction: %s, "+
+				"after error: %s", rbErr.Error(), err.Error())
+		}
+
+		// Return error as is so it can be identified
+		return err
+	}
+
+	// Save
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %s",
+			err.Error())
+	}
+
+	return nil
+}
+
+// QueryCrime implements CrimeStore.QueryCrime
+func (s *PgStore) QueryCrime(ctx context.Context, c *Crime) error {
+	ctx, cancel := s.timeout(ctx)
+	defer cancel()
+
+	return c.Query(ctx, s.querier())
+}
+
+// InsertCrime implements CrimeStore.InsertCrime
+func (s *PgStore) InsertCrime(ctx context.Context, c *Crime) error {
+	ctx, cancel := s.timeout(ctx)
+	defer cancel()
+
+	return c.Insert(ctx, s.querier())
+}
+
+// InsertCrimeIfNew implements CrimeStore.InsertCrimeIfNew
+func (s *PgStore) InsertCrimeIfNew(ctx context.Context, c *Crime) error {
+	ctx, cancel := s.timeout(ctx)
+	defer cancel()
+
+	return c.InsertIfNew(ctx, s.querier())
+}
+
+// InsertCrimes implements CrimeStore.InsertCrimes. Uses COPY, which requires
+// a transaction, so one is started if the PgStore is not a transaction.
+func (s *PgStore) InsertCrimes(ctx context.Context, crimes []Crime) error {
+	if s.tx != nil {
+		ctx, cancel := s.timeout(ctx)
+		defer cancel()
+
+		return InsertCrimes(ctx, s.tx, crimes)
+	}
+
+	return s.Tx(ctx, func(tx Store) error {
+		return tx.InsertCrimes(ctx, crimes)
+	})
+}
+
+// QueryAllCrimes implements CrimeStore.QueryAllCrimes
+func (s *PgStore) QueryAllCrimes(ctx context.Context, offset uint, limit uint, orderBy OrderByType) ([]*Crime, error) {
+	ctx, cancel := s.timeout(ctx)
+	defer cancel()
+
+	return QueryAllCrimes(ctx, s.querier(), offset, limit, orderBy)
+}
+
+// QueryCrimeSightings implements CrimeStore.QueryCrimeSightings
+func (s *PgStore) QueryCrimeSightings(ctx context.Context, crimeID int) ([]*CrimeSighting, error) {
+	ctx, cancel := s.timeout(ctx)
+	defer cancel()
+
+	return QueryCrimeSightings(ctx, s.querier(), crimeID)
+}
+
+// QueryCrimeRevisions implements CrimeStore.QueryCrimeRevisions
+func (s *PgStore) QueryCrimeRevisions(ctx context.Context, crimeID int) ([]*CrimeRevision, error) {
+	ctx, cancel := s.timeout(ctx)
+	defer cancel()
+
+	return QueryCrimeRevisions(ctx, s.querier(), crimeID)
+}
+
+// SetCrimeCategories implements CrimeStore.SetCrimeCategories
+func (s *PgStore) SetCrimeCategories(ctx context.Context, crimes []Crime) error {
+	ctx, cancel := s.timeout(ctx)
+	defer cancel()
+
+	return SetCrimeCategories(ctx, s.querier(), crimes)
+}
+
+// QueryIncidentCounts implements CrimeStore.QueryIncidentCounts
+func (s *PgStore) QueryIncidentCounts(ctx context.Context) (map[string]uint, error) {
+	ctx, cancel := s.timeout(ctx)
+	defer cancel()
+
+	return QueryIncidentCounts(ctx, s.querier())
+}
+
+// QueryReport implements ReportStore.QueryReport
+func (s *PgStore) QueryReport(ctx context.Context, id int) (*Report, error) {
+	ctx, cancel := s.timeout(ctx)
+	defer cancel()
+
+	return QueryReport(ctx, s.querier(), id)
+}
+
+// QueryReportByFile implements ReportStore.QueryReportByFile
+func (s *PgStore) QueryReportByFile(ctx context.Context, r *Report) error {
+	ctx, cancel := s.timeout(ctx)
+	defer cancel()
+
+	return r.Query(ctx, s.querier())
+}
+
+// QueryPreviousReport implements ReportStore.QueryPreviousReport
+func (s *PgStore) QueryPreviousReport(ctx context.Context, r Report) (*Report, error) {
+	ctx, cancel := s.timeout(ctx)
+	defer cancel()
+
+	return r.QueryPrevious(ctx, s.querier())
+}
+
+// InsertReport implements ReportStore.InsertReport
+func (s *PgStore) InsertReport(ctx context.Context, r *Report) error {
+	ctx, cancel := s.timeout(ctx)
+	defer cancel()
+
+	return r.Insert(ctx, s.querier())
+}
+
+// UpdateReportPostParseFields implements
+// ReportStore.UpdateReportPostParseFields
+func (s *PgStore) UpdateReportPostParseFields(ctx context.Context, r Report) error {
+	ctx, cancel := s.timeout(ctx)
+	defer cancel()
+
+	return r.UpdatePostParseFields(ctx, s.querier())
+}
+
+// UpdateReportFileFields implements ReportStore.UpdateReportFileFields
+func (s *PgStore) UpdateReportFileFields(ctx context.Context, r Report) error {
+	ctx, cancel := s.timeout(ctx)
+	defer cancel()
+
+	return r.UpdateFileFields(ctx, s.querier())
+}
+
+// SupersedeReport implements ReportStore.SupersedeReport
+func (s *PgStore) SupersedeReport(ctx context.Context, r Report, old *Report) error {
+	ctx, cancel := s.timeout(ctx)
+	defer cancel()
+
+	return r.Supersede(ctx, s.querier(), old)
+}
+
+// DeleteReportCrimes implements ReportStore.DeleteReportCrimes
+func (s *PgStore) DeleteReportCrimes(ctx context.Context, r *Report) error {
+	ctx, cancel := s.timeout(ctx)
+	defer cancel()
+
+	return r.DeleteCrimes(ctx, s.querier())
+}
+
+// LocalizeReport implements ReportStore.LocalizeReport
+func (s *PgStore) LocalizeReport(ctx context.Context, r *Report, loc *time.Location) error {
+	ctx, cancel := s.timeout(ctx)
+	defer cancel()
+
+	return r.Localize(ctx, s.querier(), loc)
+}
+
+// QueryAllReports implements ReportStore.QueryAllReports
+func (s *PgStore) QueryAllReports(ctx context.Context) ([]*Report, error) {
+	ctx, cancel := s.timeout(ctx)
+	defer cancel()
+
+	return QueryAllReports(ctx, s.querier())
+}
+
+// QueryGeoLoc implements GeoLocStore.QueryGeoLoc
+func (s *PgStore) QueryGeoLoc(ctx context.Context, l *GeoLoc) error {
+	ctx, cancel := s.timeout(ctx)
+	defer cancel()
+
+	return l.Query(ctx, s.querier())
+}
+
+// InsertGeoLoc implements GeoLocStore.InsertGeoLoc
+func (s *PgStore) InsertGeoLoc(ctx context.Context, l *GeoLoc) error {
+	ctx, cancel := s.timeout(ctx)
+	defer cancel()
+
+	return l.Insert(ctx, s.querier())
+}
+
+// InsertGeoLocIfNew implements GeoLocStore.InsertGeoLocIfNew
+func (s *PgStore) InsertGeoLocIfNew(ctx context.Context, l *GeoLoc) error {
+	ctx, cancel := s.timeout(ctx)
+	defer cancel()
+
+	return l.InsertIfNew(ctx, s.querier())
+}
+
+// UpdateGeoLoc implements GeoLocStore.UpdateGeoLoc
+func (s *PgStore) UpdateGeoLoc(ctx context.Context, l GeoLoc) error {
+	ctx, cancel := s.timeout(ctx)
+	defer cancel()
+
+	return l.Update(ctx, s.querier())
+}
+
+// QueryUnlocatedGeoLocs implements GeoLocStore.QueryUnlocatedGeoLocs
+func (s *PgStore) QueryUnlocatedGeoLocs(ctx context.Context) ([]*GeoLoc, error) {
+	ctx, cancel := s.timeout(ctx)
+	defer cancel()
+
+	return QueryUnlocatedGeoLocs(ctx, s.querier())
+}
+
+// InsertGeoBoundIfNew implements GeoLocStore.InsertGeoBoundIfNew
+func (s *PgStore) InsertGeoBoundIfNew(ctx context.Context, b *GeoBound) error {
+	ctx, cancel := s.timeout(ctx)
+	defer cancel()
+
+	return b.InsertIfNew(ctx, s.querier())
+}
+
+// QueryParseError implements ParseErrorStore.QueryParseError
+func (s *PgStore) QueryParseError(ctx context.Context, e *ParseError) error {
+	ctx, cancel := s.timeout(ctx)
+	defer cancel()
+
+	return e.Query(ctx, s.querier())
+}
+
+// InsertParseError implements ParseErrorStore.InsertParseError
+func (s *PgStore) InsertParseError(ctx context.Context, e *ParseError) error {
+	ctx, cancel := s.timeout(ctx)
+	defer cancel()
+
+	return e.Insert(ctx, s.querier())
+}
+
+// InsertParseErrorIfNew implements ParseErrorStore.InsertParseErrorIfNew
+func (s *PgStore) InsertParseErrorIfNew(ctx context.Context, e *ParseError) error {
+	ctx, cancel := s.timeout(ctx)
+	defer cancel()
+
+	return e.InsertIfNew(ctx, s.querier())
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"time"
@@ -17,6 +18,10 @@ const (
 	// UniversityDrexel indicates that a report was published by Drexel
 	UniversityDrexel UniversityType = "Drexel University"
 
+	// UniversityPenn indicates that a report was published by the
+	// University of Pennsylvania
+	UniversityPenn UniversityType = "University of Pennsylvania"
+
 	// UniversityErr indicates that a report was provided with an invalid
 	// value
 	UniversityErr UniversityType = "Err"
@@ -27,12 +32,40 @@ const (
 func NewUniversityType(raw string) (UniversityType, error) {
 	if raw == string(UniversityDrexel) {
 		return UniversityDrexel, nil
+	} else if raw == string(UniversityPenn) {
+		return UniversityPenn, nil
 	} else {
 		return UniversityErr, fmt.Errorf("error creating UniversityType"+
 			" from value, invalid: %s", raw)
 	}
 }
 
+// universityTimeZones holds the name of the IANA time zone database location
+// each university's reports are written in
+var universityTimeZones map[UniversityType]string = map[UniversityType]string{
+	UniversityDrexel: "America/New_York",
+	UniversityPenn:   "America/New_York",
+}
+
+// Location loads the location the university's reports are written in. Times
+// in reports do not state a time zone, so they must be interpreted in this
+// location. An error is returned if one occurs, nil on success.
+func (u UniversityType) Location() (*time.Location, error) {
+	name, ok := universityTimeZones[u]
+	if !ok {
+		return nil, fmt.Errorf("no time zone known for university: %s",
+			u)
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("error loading time zone location for "+
+			"university: %s", err.Error())
+	}
+
+	return loc, nil
+}
+
 // Report holds information about documents parsed by crime-map to extract
 // Crime models. These documents are typically published as an obligation to
 // the Clery Act. And hold multiple individual crime reports.
@@ -46,6 +79,11 @@ type Report struct {
 	// ParseSuccess indicates if the report was successfully parsed
 	ParseSuccess bool
 
+	// ParsePartial indicates that only part of the report was parsed.
+	// Ex: pages could not be read, or records were skipped when parsing
+	// leniently. Set along with ParseSuccess.
+	ParsePartial bool
+
 	// University indicates which institution published the crime report
 	// document
 	University UniversityType
@@ -63,8 +101,47 @@ type Report struct {
 
 	// CrimesCount holds the number of crimes parsed from the report
 	CrimesCount uint
+
+	// PagesFailed holds the number of pages text could not be extracted
+	// from. Crimes on these pages are missing, but crimes from the rest
+	// of the report are still saved.
+	PagesFailed uint
+
+	// Diagnostics describes why each failed page could not be read, one
+	// line per page. Empty if no pages failed.
+	Diagnostics string
+
+	// FileHash holds the hex encoded SHA-256 hash of the report file. Used
+	// to identify reports. Empty for reports parsed before file hashes
+	// were recorded.
+	FileHash string
+
+	// FileName holds the name of the report file when it was parsed
+	FileName string
+
+	// FileSize holds the size of the report file in bytes
+	FileSize int64
+
+	// SupersededBy holds the ID of the Report which replaced this report.
+	// Set when a report covering the same date range, but with different
+	// contents, is parsed. Crimes from superseded reports are not
+	// returned by QueryAllCrimes.
+	SupersededBy sql.NullInt64
+
+	// TimeZone holds the name of the IANA time zone database location
+	// the report's times were written in, ex: America/New_York. Empty for
+	// reports parsed before time zones were recorded, whose times were
+	// saved as if written in UTC. See Localize.
+	TimeZone string
 }
 
+// reportCols is the list of columns selected by queries which retrieve
+// Report models. Rows from these queries can be parsed by NewReportFromRow.
+const reportCols string = "id, parsed_on, parse_success, university, " +
+	"covers_range, pages, crimes_count, file_sha256, file_name, " +
+	"file_size, superseded_by, pages_failed, diagnostics, parse_partial, " +
+	"time_zone"
+
 // NewReport will create a new Report model.
 func NewReport(univ UniversityType, parsedOn *time.Time, start *time.Time,
 	end *time.Time, pages uint) *Report {
@@ -79,16 +156,17 @@ func NewReport(univ UniversityType, parsedOn *time.Time, start *time.Time,
 }
 
 // NewReportFromRow creates a new Report model from a database row. This row
-// should be from a query which selects the id, parsed_on, parse_success,
-// university, covers_range, pages and crimes_count fields. Additionally an
-// error is returned if one occurs, nil on success.
+// should be from a query which selects the columns in reportCols.
+// Additionally an error is returned if one occurs, nil on success.
 func NewReportFromRow(rows *sql.Rows) (*Report, error) {
 	// Scan
 	r := &Report{}
 	var dRange string
 
 	err := rows.Scan(&r.ID, &r.ParsedOn, &r.ParseSuccess, &r.University,
-		&dRange, &r.Pages, &r.CrimesCount)
+		&dRange, &r.Pages, &r.CrimesCount, &r.FileHash, &r.FileName,
+		&r.FileSize, &r.SupersededBy, &r.PagesFailed, &r.Diagnostics,
+		&r.ParsePartial, &r.TimeZone)
 
 	if err != nil {
 		return nil, fmt.Errorf("error parsing Report from database row"+
@@ -113,36 +191,34 @@ func (r Report) String() string {
 	return fmt.Sprintf("ID: %d\n"+
 		"ParsedOn: %s\n"+
 		"ParseSuccess: %t\n"+
+		"ParsePartial: %t\n"+
 		"University: %s\n"+
 		"Range: [%s, %s]\n"+
 		"Pages: %d\n"+
-		"CrimesCount: %d",
-		r.ID, r.ParsedOn, r.ParseSuccess, r.University,
-		r.RangeStartDate, r.RangeEndDate, r.Pages, r.CrimesCount)
+		"CrimesCount: %d\n"+
+		"PagesFailed: %d\n"+
+		"File: %s (%d bytes, sha256: %s)\n"+
+		"SupersededBy: %d\n"+
+		"TimeZone: %s",
+		r.ID, r.ParsedOn, r.ParseSuccess, r.ParsePartial, r.University,
+		r.RangeStartDate, r.RangeEndDate, r.Pages, r.CrimesCount,
+		r.PagesFailed, r.FileName, r.FileSize, r.FileHash,
+		r.SupersededBy.Int64, r.TimeZone)
 }
 
-// Query attempts to find a Report with the same parse_success, university,
-// covers_range, and pages field values. The parsed_on, parse_success, and
-// crimes_count fields are left out of the query.
+// Query attempts to find a Report with the same file_sha256 field value. So
+// the same report file is only parsed once.
 //
-// It populates the Report.ID and Report.ParseSucces fields with the database
-// row. An error is returned if one occurs, or nil on success.
-func (r *Report) Query() error {
-	// Get db instance
-	db, err := dstore.NewDB()
-	if err != nil {
-		return fmt.Errorf("error retrieving database instance: %s",
-			err.Error())
-	}
-
+// It populates the Report.ID, Report.ParseSuccess and Report.CrimesCount fields
+// with the database row. An error is returned if one occurs, sql.ErrNoRows if
+// no Report with the same file hash exists. Nil on success.
+func (r *Report) Query(ctx context.Context, db dstore.Querier) error {
 	// Query
-	row := db.QueryRow("SELECT id, parse_success FROM reports WHERE "+
-		"university=$1 AND covers_range=tstzrange($2, $3, '()') AND "+
-		"pages=$4", r.University, r.RangeStartDate, r.RangeEndDate,
-		r.Pages)
+	row := db.QueryRowContext(ctx, "SELECT id, parse_success, crimes_count FROM "+
+		"reports WHERE file_sha256 = $1", r.FileHash)
 
 	// Get ID
-	err = row.Scan(&r.ID, &r.ParseSuccess)
+	err := row.Scan(&r.ID, &r.ParseSuccess, &r.CrimesCount)
 
 	// Check if no rows
 	if err == sql.ErrNoRows {
@@ -157,27 +233,65 @@ func (r *Report) Query() error {
 	return nil
 }
 
-// Insert adds a Report model to the database. An error is returned if one
-// occurs, or nil on success.
+// QueryPrevious finds the most recent Report which has the same university
+// and covers_range field values, and has not been superseded. This report
+// will be superseded if the current report has different contents.
 //
-// The ID of the newly inserted row will be saved in the Report.ID field.
-func (r *Report) Insert() error {
-	// Get db instance
-	db, err := dstore.NewDB()
+// An error is returned if one occurs, sql.ErrNoRows if no such Report exists.
+// Nil on success.
+func (r Report) QueryPrevious(ctx context.Context, db dstore.Querier) (*Report, error) {
+	// Query
+	rows, err := db.QueryContext(ctx, "SELECT "+reportCols+" FROM reports WHERE "+
+		"university = $1 AND covers_range = tstzrange($2, $3, '()') "+
+		"AND superseded_by IS NULL AND id <> $4 ORDER BY id DESC "+
+		"LIMIT 1", r.University, r.RangeStartDate, r.RangeEndDate,
+		r.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying for previous Report: %s",
+			err.Error())
+	}
+	defer rows.Close()
+
+	// Check if found
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return nil, fmt.Errorf("error reading Report row: %s",
+				err.Error())
+		}
+
+		// Return error so we can identify
+		return nil, sql.ErrNoRows
+	}
+
+	// Parse
+	prev, err := NewReportFromRow(rows)
 	if err != nil {
-		return fmt.Errorf("error retrieving database instance: %s",
+		return nil, fmt.Errorf("error parsing report row: %s",
 			err.Error())
 	}
 
+	// Success
+	return prev, nil
+}
+
+// Insert adds a Report model to the database. An error is returned if one
+// occurs, or nil on success.
+//
+// The ID of the newly inserted row will be saved in the Report.ID field.
+func (r *Report) Insert(ctx context.Context, db dstore.Querier) error {
 	// Insert
-	row := db.QueryRow("INSERT INTO reports (parsed_on, parse_success, "+
-		"university, covers_range, pages, crimes_count) VALUES ($1, "+
-		"$2, $3, tstzrange($4, $5, '()'), $6, $7) RETURNING id",
+	row := db.QueryRowContext(ctx, "INSERT INTO reports (parsed_on, parse_success, "+
+		"university, covers_range, pages, crimes_count, file_sha256, "+
+		"file_name, file_size, pages_failed, diagnostics, time_zone) "+
+		"VALUES ($1, $2, $3, tstzrange($4, $5, '()'), $6, $7, $8, $9, "+
+		"$10, $11, $12, $13) RETURNING id",
 		r.ParsedOn, r.ParseSuccess, r.University, r.RangeStartDate,
-		r.RangeEndDate, r.Pages, r.CrimesCount)
+		r.RangeEndDate, r.Pages, r.CrimesCount, r.FileHash,
+		r.FileName, r.FileSize, r.PagesFailed, r.Diagnostics,
+		r.TimeZone)
 
 	// Get ID
-	err = row.Scan(&r.ID)
+	err := row.Scan(&r.ID)
 	if err != nil {
 		return fmt.Errorf("error inserting Report model: %s",
 			err.Error())
@@ -187,44 +301,78 @@ func (r *Report) Insert() error {
 	return nil
 }
 
-// UpdatePostParseFields updates the parse_success and crimes_count fields for
-// the database row with a matching Report.ID field.
+// UpdatePostParseFields updates the parse_success, parse_partial, crimes_count,
+// pages_failed and diagnostics fields for the database row with a matching
+// Report.ID field.
 //
-// These 2 fields are updated after a report has been parsed. As their values
+// These fields are updated after a report has been parsed. As their values
 // can only be know after all crimes have been extracted.
-func (r Report) UpdatePostParseFields() error {
-	// Get database instance
-	db, err := dstore.NewDB()
+func (r Report) UpdatePostParseFields(ctx context.Context, db dstore.Querier) error {
+	// Update
+	_, err := db.ExecContext(ctx, "UPDATE reports SET parse_success=$1, "+
+		"parse_partial=$2, crimes_count=$3, pages_failed=$4, "+
+		"diagnostics=$5 WHERE id=$6", r.ParseSuccess, r.ParsePartial,
+		r.CrimesCount, r.PagesFailed, r.Diagnostics, r.ID)
+
 	if err != nil {
-		return fmt.Errorf("error retrieving database instance: %s",
+		return fmt.Errorf("error running update query: %s",
 			err.Error())
 	}
 
+	// Success
+	return nil
+}
+
+// UpdateFileFields updates the file_sha256, file_name and file_size fields for
+// the database row with a matching Report.ID field. Used to record file
+// information for reports parsed before it was recorded. An error is returned
+// if one occurs, nil on success.
+func (r Report) UpdateFileFields(ctx context.Context, db dstore.Querier) error {
 	// Update
-	_, err = db.Exec("UPDATE reports SET parse_success=$1, "+
-		"crimes_count=$2 WHERE id=$3", r.ParseSuccess,
-		r.CrimesCount, r.ID)
+	_, err := db.ExecContext(ctx, "UPDATE reports SET file_sha256 = $1, file_name = "+
+		"$2, file_size = $3 WHERE id = $4", r.FileHash, r.FileName,
+		r.FileSize, r.ID)
+	if err != nil {
+		return fmt.Errorf("error running update query: %s",
+			err.Error())
+	}
 
+	// Success
+	return nil
+}
+
+// Supersede marks the old Report as replaced by the current Report. The
+// Report.SupersededBy field of old is set. An error is returned if one occurs,
+// nil on success.
+func (r Report) Supersede(ctx context.Context, db dstore.Querier, old *Report) error {
+	// Update
+	_, err := db.ExecContext(ctx, "UPDATE reports SET superseded_by = $1 WHERE id = $2",
+		r.ID, old.ID)
 	if err != nil {
 		return fmt.Errorf("error running update query: %s",
 			err.Error())
 	}
 
+	old.SupersededBy = sql.NullInt64{
+		Int64: int64(r.ID),
+		Valid: true,
+	}
+
 	// Success
 	return nil
 }
 
-// InsertIfNew adds a Report model to the database if one with existing values
-// does not exist yet. The ID of the queried/inserted row is saved in the
+// InsertIfNew adds a Report model to the database if one with the same file
+// hash does not exist yet. The ID of the queried/inserted row is saved in the
 // Report.ID field. An error is returned if one occurs, nil on success.
-func (r *Report) InsertIfNew() error {
+func (r *Report) InsertIfNew(ctx context.Context, db dstore.Querier) error {
 	// Query
-	err := r.Query()
+	err := r.Query(ctx, db)
 
 	// If doesn't exist
 	if err == sql.ErrNoRows {
 		// Insert
-		if err = r.Insert(); err != nil {
+		if err = r.Insert(ctx, db); err != nil {
 			return fmt.Errorf("error inserting non-existing "+
 				"Report model: %s", err.Error())
 		}
@@ -238,24 +386,231 @@ func (r *Report) InsertIfNew() error {
 	return nil
 }
 
+// QueryReport finds the Report model with the provided ID. An error is
+// returned if one occurs, sql.ErrNoRows if no Report with the ID exists. Nil
+// on success.
+func QueryReport(ctx context.Context, db dstore.Querier, id int) (*Report, error) {
+	// Query
+	rows, err := db.QueryContext(ctx, "SELECT "+reportCols+" FROM reports WHERE id "+
+		"= $1", id)
+	if err != nil {
+		return nil, fmt.Errorf("error querying for Report: %s",
+			err.Error())
+	}
+	defer rows.Close()
+
+	// Check if found
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return nil, fmt.Errorf("error reading Report row: %s",
+				err.Error())
+		}
+
+		// Return error so we can identify
+		return nil, sql.ErrNoRows
+	}
+
+	// Parse
+	report, err := NewReportFromRow(rows)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing report row: %s",
+			err.Error())
+	}
+
+	// Success
+	return report, nil
+}
+
+// crimesUnsightedQuery is the SQL query which selects the IDs of crimes no
+// report lists
+const crimesUnsightedQuery string = "SELECT id FROM crimes WHERE NOT EXISTS (" +
+	"SELECT 1 FROM crime_sightings WHERE crime_sightings.crime_id = crimes.id)"
+
+// DeleteCrimes removes the Report's CrimeSighting models, and their
+// CrimeRevision models. Crime models which no other report lists are removed,
+// along with their ParseError models and incident categories. As are
+// ParseError models from parsing the Report. Crimes other reports list keep
+// their fields. The Report.ParseSuccess, Report.ParsePartial,
+// Report.CrimesCount, Report.PagesFailed and Report.Diagnostics fields are
+// reset, so the report can be parsed again. An error is returned if one
+// occurs, nil on success.
+func (r *Report) DeleteCrimes(ctx context.Context, db dstore.Querier) error {
+	// Delete revisions
+	_, err := db.ExecContext(ctx, "DELETE FROM crime_revisions WHERE "+
+		"sighting_id IN (SELECT id FROM crime_sightings WHERE "+
+		"report_id = $1)", r.ID)
+	if err != nil {
+		return fmt.Errorf("error deleting report's crime revisions: %s",
+			err.Error())
+	}
+
+	// Delete sightings
+	_, err = db.ExecContext(ctx, "DELETE FROM crime_sightings WHERE "+
+		"report_id = $1", r.ID)
+	if err != nil {
+		return fmt.Errorf("error deleting report's crime sightings: %s",
+			err.Error())
+	}
+
+	// Delete parse errors
+	_, err = db.ExecContext(ctx, "DELETE FROM parse_errors WHERE report_id = $1 OR "+
+		"crime_id IN ("+crimesUnsightedQuery+")", r.ID)
+	if err != nil {
+		return fmt.Errorf("error deleting report's parse errors: %s",
+			err.Error())
+	}
+
+	// Delete incident categories
+	_, err = db.ExecContext(ctx, "DELETE FROM crime_incident_categories "+
+		"WHERE crime_id IN ("+crimesUnsightedQuery+")")
+	if err != nil {
+		return fmt.Errorf("error deleting report's crime incident "+
+			"categories: %s", err.Error())
+	}
+
+	// Delete crimes
+	_, err = db.ExecContext(ctx, "DELETE FROM crimes WHERE id IN ("+
+		crimesUnsightedQuery+")")
+	if err != nil {
+		return fmt.Errorf("error deleting report's crimes: %s",
+			err.Error())
+	}
+
+	// Reset post parse fields
+	r.ParseSuccess = false
+	r.ParsePartial = false
+	r.CrimesCount = 0
+	r.PagesFailed = 0
+	r.Diagnostics = ""
+
+	if err = r.UpdatePostParseFields(ctx, db); err != nil {
+		return fmt.Errorf("error resetting report post parse fields: %s",
+			err.Error())
+	}
+
+	// Success
+	return nil
+}
+
+// Localize corrects the times of a Report, and of the Crimes parsed from it,
+// which were saved before report time zones were recorded. These times were
+// written in the provided location, but saved as if written in UTC. The wall
+// clock date and time of each is interpreted in the location instead, see
+// date.Relocate.
+//
+// The Report.TimeZone field must be empty. It is set to the location's name,
+// and the Report.RangeStartDate and Report.RangeEndDate fields are corrected.
+// An error is returned if one occurs, nil on success.
+func (r *Report) Localize(ctx context.Context, db dstore.Querier, loc *time.Location) error {
+	// Check not already localized, correcting again would shift times
+	// twice
+	if len(r.TimeZone) > 0 {
+		return fmt.Errorf("report times already in time zone: %s",
+			r.TimeZone)
+	}
+
+	// Query crime times. All rows are read before updating, as a
+	// transaction can not run other queries while rows are open.
+	// Occurred ranges which start and end at the same time are empty,
+	// their bounds are NULL.
+	type crimeTimes struct {
+		id       int
+		reported time.Time
+		start    *time.Time
+		end      *time.Time
+	}
+
+	rows, err := db.QueryContext(ctx, "SELECT id, date_reported, "+
+		"lower(date_occurred), upper(date_occurred) FROM crimes WHERE "+
+		"report_id = $1", r.ID)
+	if err != nil {
+		return fmt.Errorf("error querying for report's crimes: %s",
+			err.Error())
+	}
+
+	crimes := []crimeTimes{}
+	for rows.Next() {
+		var c crimeTimes
+		if err = rows.Scan(&c.id, &c.reported, &c.start, &c.end); err != nil {
+			rows.Close()
+			return fmt.Errorf("error parsing crime times row: %s",
+				err.Error())
+		}
+
+		crimes = append(crimes, c)
+	}
+
+	if err = rows.Close(); err != nil {
+		return fmt.Errorf("error closing crimes query: %s",
+			err.Error())
+	}
+
+	// Update crimes
+	for _, c := range crimes {
+		if c.start == nil || c.end == nil {
+			_, err = db.ExecContext(ctx, "UPDATE crimes SET date_reported = "+
+				"$1 WHERE id = $2", date.Relocate(c.reported, loc),
+				c.id)
+		} else {
+			_, err = db.ExecContext(ctx, "UPDATE crimes SET date_reported = "+
+				"$1, date_occurred = tstzrange($2, $3, '()') WHERE "+
+				"id = $4", date.Relocate(c.reported, loc),
+				date.Relocate(*c.start, loc),
+				date.Relocate(*c.end, loc), c.id)
+		}
+		if err != nil {
+			return fmt.Errorf("error updating crime times, id: %d"+
+				": %s", c.id, err.Error())
+		}
+	}
+
+	// Update report, reports which failed to parse may have no range
+	if r.RangeStartDate == nil || r.RangeEndDate == nil {
+		_, err = db.ExecContext(ctx, "UPDATE reports SET time_zone = "+
+			"$1 WHERE id = $2", loc.String(), r.ID)
+		if err != nil {
+			return fmt.Errorf("error updating report time zone: %s",
+				err.Error())
+		}
+
+		r.TimeZone = loc.String()
+
+		return nil
+	}
+
+	start := date.Relocate(*r.RangeStartDate, loc)
+	end := date.Relocate(*r.RangeEndDate, loc)
+
+	_, err = db.ExecContext(ctx, "UPDATE reports SET covers_range = "+
+		"tstzrange($1, $2, '()'), time_zone = $3 WHERE id = $4",
+		start, end, loc.String(), r.ID)
+	if err != nil {
+		return fmt.Errorf("error updating report times: %s",
+			err.Error())
+	}
+
+	r.RangeStartDate = &start
+	r.RangeEndDate = &end
+	r.TimeZone = loc.String()
+
+	// Success
+	return nil
+}
+
 // QueryAllReports finds all Report models from the database. And returns them
 // with their Report.ID fields populated. Additionally an error is returned if
 // one occurs. Nil on success.
-func QueryAllReports() ([]*Report, error) {
+func QueryAllReports(ctx context.Context, db dstore.Querier) ([]*Report, error) {
 	reports := []*Report{}
 
-	// Get db
-	db, err := dstore.NewDB()
+	// Query
+	rows, err := db.QueryContext(ctx, "SELECT "+reportCols+" FROM reports ORDER "+
+		"BY parsed_on DESC")
 	if err != nil {
-		return reports, fmt.Errorf("error retrieving database "+
-			"instance: %s", err.Error())
+		return reports, fmt.Errorf("error querying for reports: %s",
+			err.Error())
 	}
 
-	// Query
-	rows, err := db.Query("SELECT id, parsed_on, parse_success, university" +
-		", covers_range, pages, crimes_count FROM reports ORDER BY " +
-		"parsed_on DESC")
-
 	// Parse
 	for rows.Next() {
 		report, err := NewReportFromRow(rows)
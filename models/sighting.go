@@ -0,0 +1,325 @@
+package models
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Noah-Huppert/crime-map/dstore"
+)
+
+// CrimeFieldType is a string type alias, used to represent the crime fields
+// which are compared between the reports which list a crime
+type CrimeFieldType string
+
+const (
+	// FieldDateReported identifies the Crime.DateReported field
+	FieldDateReported CrimeFieldType = "date_reported"
+
+	// FieldDateOccurred identifies the Crime.DateOccurredStart and
+	// Crime.DateOccurredEnd fields
+	FieldDateOccurred CrimeFieldType = "date_occurred"
+
+	// FieldGeoLocID identifies the Crime.GeoLocID field
+	FieldGeoLocID CrimeFieldType = "geo_loc_id"
+
+	// FieldIncidents identifies the Crime.Incidents field
+	FieldIncidents CrimeFieldType = "incidents"
+
+	// FieldDescriptions identifies the Crime.Descriptions field
+	FieldDescriptions CrimeFieldType = "descriptions"
+
+	// FieldRemediation identifies the Crime.Remediation field
+	FieldRemediation CrimeFieldType = "remediation"
+)
+
+// CrimeSighting records that a Report listed a Crime. Crimes are identified by
+// their university and police report ID, so a crime listed by overlapping
+// reports is saved once, with a sighting for each report.
+type CrimeSighting struct {
+	// ID is a unique identifier
+	ID int
+
+	// CrimeID is the ID of the Crime which was listed
+	CrimeID int
+
+	// ReportID is the ID of the Report which listed the crime
+	ReportID int
+
+	// Page is the page of the report which listed the crime
+	Page int
+}
+
+// CrimeRevision records that a report listed a crime with a different value
+// for a field than the crime had when the report was saved.
+//
+// Values are formatted as text. Times are in RFC 3339, in UTC. The date
+// occurred is its start and end separated by a slash, or empty if the crime
+// occurred at one instant. Incidents and descriptions are JSON arrays.
+type CrimeRevision struct {
+	// ID is a unique identifier
+	ID int
+
+	// SightingID is the ID of the CrimeSighting which listed the new value
+	SightingID int
+
+	// Field is the field which changed
+	Field CrimeFieldType
+
+	// Previous is the value of the field before the sighting
+	Previous string
+
+	// Value is the value of the field listed by the sighting
+	Value string
+
+	// ReportID is the ID of the Report which listed the new value. Only
+	// set when queried.
+	ReportID int
+}
+
+// revisionTimeFormat is the format of times in CrimeRevision values
+const revisionTimeFormat string = "2006-01-02T15:04:05Z"
+
+// revisionTimeSQL is the SQL format string, for to_char, equivalent to
+// revisionTimeFormat
+const revisionTimeSQL string = "'YYYY-MM-DD\"T\"HH24:MI:SS\"Z\"'"
+
+// formatRevisionTime formats a time as a CrimeRevision value
+func formatRevisionTime(t time.Time) string {
+	return t.UTC().Format(revisionTimeFormat)
+}
+
+// formatRevisionArray formats an array as a CrimeRevision value
+func formatRevisionArray(values []string) string {
+	if values == nil {
+		values = []string{}
+	}
+
+	// Do not escape HTML characters, the same as Postgres
+	buf := &bytes.Buffer{}
+
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+
+	if err := encoder.Encode(values); err != nil {
+		// Only fails for values which can not be encoded as JSON
+		panic(fmt.Sprintf("error encoding revision value: %s",
+			err.Error()))
+	}
+
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// revisionValues formats the compared fields of a Crime as CrimeRevision
+// values. Keyed by field.
+func (c Crime) revisionValues() map[CrimeFieldType]string {
+	// Occurred ranges which start and end at the same time are empty
+	occurred := ""
+	if c.DateOccurredStart.Before(c.DateOccurredEnd) {
+		occurred = formatRevisionTime(c.DateOccurredStart) + "/" +
+			formatRevisionTime(c.DateOccurredEnd)
+	}
+
+	return map[CrimeFieldType]string{
+		FieldDateReported: formatRevisionTime(c.DateReported),
+		FieldDateOccurred: occurred,
+		FieldGeoLocID:     strconv.Itoa(c.GeoLocID),
+		FieldIncidents:    formatRevisionArray(c.Incidents),
+		FieldDescriptions: formatRevisionArray(c.Descriptions),
+		FieldRemediation:  c.Remediation,
+	}
+}
+
+// revisionFields holds the compared fields, in the order revisions are made
+var revisionFields []CrimeFieldType = []CrimeFieldType{
+	FieldDateReported,
+	FieldDateOccurred,
+	FieldGeoLocID,
+	FieldIncidents,
+	FieldDescriptions,
+	FieldRemediation,
+}
+
+// Revisions compares the Crime with the same crime listed by a newer report.
+// A CrimeRevision is returned for each field which differs. The
+// CrimeRevision.SightingID fields are not set.
+func (c Crime) Revisions(next Crime) []CrimeRevision {
+	revisions := []CrimeRevision{}
+
+	prevValues := c.revisionValues()
+	nextValues := next.revisionValues()
+
+	for _, field := range revisionFields {
+		if prevValues[field] == nextValues[field] {
+			continue
+		}
+
+		revisions = append(revisions, CrimeRevision{
+			Field:    field,
+			Previous: prevValues[field],
+			Value:    nextValues[field],
+		})
+	}
+
+	return revisions
+}
+
+// revisionValuesSQL returns a SQL VALUES list comparing the fields of a crime,
+// aliased c, and a crimes_staging row, aliased s. Formatted the same as
+// Crime.revisionValues. Each row holds its position in revisionFields, a
+// field, its value in the crime, and its value in the staged row.
+func revisionValuesSQL() string {
+	timeSQL := func(expr string) string {
+		return "to_char(" + expr + " AT TIME ZONE 'UTC', " +
+			revisionTimeSQL + ")"
+	}
+
+	rangeSQL := func(expr string) string {
+		return "CASE WHEN isempty(" + expr + ") THEN '' ELSE " +
+			timeSQL("lower("+expr+")") + " || '/' || " +
+			timeSQL("upper("+expr+")") + " END"
+	}
+
+	// Expressions for the crime's value, then the staged row's value,
+	// keyed by field
+	exprs := map[CrimeFieldType][2]string{
+		FieldDateReported: {
+			timeSQL("c.date_reported"),
+			timeSQL("s.date_reported"),
+		},
+		FieldDateOccurred: {
+			rangeSQL("c.date_occurred"),
+			rangeSQL("tstzrange(s.date_occurred_start, " +
+				"s.date_occurred_end, '()')"),
+		},
+		FieldGeoLocID: {
+			"c.geo_loc_id::TEXT",
+			"s.geo_loc_id::TEXT",
+		},
+		FieldIncidents: {
+			"array_to_json(c.incidents)::TEXT",
+			"array_to_json(s.incidents)::TEXT",
+		},
+		FieldDescriptions: {
+			"array_to_json(c.descriptions)::TEXT",
+			"array_to_json(s.descriptions)::TEXT",
+		},
+		FieldRemediation: {
+			"c.remediation",
+			"s.remediation",
+		},
+	}
+
+	rows := []string{}
+
+	for i, field := range revisionFields {
+		rows = append(rows, fmt.Sprintf("(%d, '%s', %s, %s)", i, field,
+			exprs[field][0], exprs[field][1]))
+	}
+
+	return "(VALUES " + strings.Join(rows, ", ") + ")"
+}
+
+// QueryCrimeSightings retrieves the sightings of a Crime, ordered by the end
+// of the listing report's range, oldest first. An error is returned if one
+// occurs, nil on success.
+func QueryCrimeSightings(ctx context.Context, db dstore.Querier, crimeID int) ([]*CrimeSighting, error) {
+	sightings := []*CrimeSighting{}
+
+	// Query
+	rows, err := db.QueryContext(ctx, "SELECT crime_sightings.id, "+
+		"crime_sightings.crime_id, crime_sightings.report_id, "+
+		"crime_sightings.page FROM crime_sightings JOIN reports ON "+
+		"reports.id = crime_sightings.report_id WHERE "+
+		"crime_sightings.crime_id = $1 ORDER BY "+
+		"upper(reports.covers_range), crime_sightings.id", crimeID)
+	if err != nil {
+		return sightings, fmt.Errorf("error querying database for "+
+			"crime sightings: %s", err.Error())
+	}
+
+	// Parse
+	for rows.Next() {
+		sighting := &CrimeSighting{}
+
+		err = rows.Scan(&sighting.ID, &sighting.CrimeID,
+			&sighting.ReportID, &sighting.Page)
+		if err != nil {
+			rows.Close()
+			return sightings, fmt.Errorf("error parsing crime "+
+				"sighting row: %s", err.Error())
+		}
+
+		sightings = append(sightings, sighting)
+	}
+
+	if err = rows.Err(); err != nil {
+		rows.Close()
+		return sightings, fmt.Errorf("error reading crime sighting "+
+			"rows: %s", err.Error())
+	}
+
+	// Close query
+	if err = rows.Close(); err != nil {
+		return sightings, fmt.Errorf("error closing crime sightings "+
+			"query: %s", err.Error())
+	}
+
+	return sightings, nil
+}
+
+// QueryCrimeRevisions retrieves the revisions of a Crime's fields, ordered by
+// the end of the listing report's range, oldest first. An error is returned if
+// one occurs, nil on success.
+func QueryCrimeRevisions(ctx context.Context, db dstore.Querier, crimeID int) ([]*CrimeRevision, error) {
+	revisions := []*CrimeRevision{}
+
+	// Query
+	rows, err := db.QueryContext(ctx, "SELECT crime_revisions.id, "+
+		"crime_revisions.sighting_id, crime_revisions.field, "+
+		"crime_revisions.previous, crime_revisions.value, "+
+		"crime_sightings.report_id FROM crime_revisions "+
+		"JOIN crime_sightings ON crime_sightings.id = "+
+		"crime_revisions.sighting_id JOIN reports ON reports.id = "+
+		"crime_sightings.report_id WHERE crime_sightings.crime_id = $1 "+
+		"ORDER BY upper(reports.covers_range), crime_revisions.id",
+		crimeID)
+	if err != nil {
+		return revisions, fmt.Errorf("error querying database for "+
+			"crime revisions: %s", err.Error())
+	}
+
+	// Parse
+	for rows.Next() {
+		revision := &CrimeRevision{}
+
+		err = rows.Scan(&revision.ID, &revision.SightingID,
+			&revision.Field, &revision.Previous, &revision.Value,
+			&revision.ReportID)
+		if err != nil {
+			rows.Close()
+			return revisions, fmt.Errorf("error parsing crime "+
+				"revision row: %s", err.Error())
+		}
+
+		revisions = append(revisions, revision)
+	}
+
+	if err = rows.Err(); err != nil {
+		rows.Close()
+		return revisions, fmt.Errorf("error reading crime revision "+
+			"rows: %s", err.Error())
+	}
+
+	// Close query
+	if err = rows.Close(); err != nil {
+		return revisions, fmt.Errorf("error closing crime revisions "+
+			"query: %s", err.Error())
+	}
+
+	return revisions, nil
+}
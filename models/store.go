@@ -0,0 +1,188 @@
+package models
+
+import (
+	"context"
+	"time"
+)
+
+// CrimeStore saves and retrieves Crime models
+type CrimeStore interface {
+	// QueryCrime finds a crime with the same university and police
+	// report ID and sets the Crime.ID field. sql.ErrNoRows is returned if
+	// none is found. Another error is returned if one occurs, nil on
+	// success.
+	QueryCrime(ctx context.Context, c *Crime) error
+
+	// InsertCrime saves a crime, and a sighting for its report, and sets
+	// the Crime.ID field. An error is returned if one occurs, nil on
+	// success.
+	InsertCrime(ctx context.Context, c *Crime) error
+
+	// InsertCrimeIfNew saves a crime if one with the same university and
+	// police report ID does not exist. The Crime.ID field is set to the found / inserted crime's
+	// ID. An error is returned if one occurs, nil on success.
+	InsertCrimeIfNew(ctx context.Context, c *Crime) error
+
+	// InsertCrimes saves many crimes at once. Crimes which already exist
+	// are sighted again, and their fields revised if the crime's report is
+	// at least as recent as the report they were parsed from, see
+	// InsertCrimes. Each Crime.ID field is set to the found / inserted
+	// crime's ID, and the Crime.ReportID and Crime.Page fields to the
+	// report its saved fields were parsed from. Transactions saving the
+	// same crimes at once wait for each other. An error is returned if
+	// one occurs, nil on success.
+	InsertCrimes(ctx context.Context, crimes []Crime) error
+
+	// QueryAllCrimes retrieves crimes listed by at least one report which
+	// has not been superseded. Ordered by the orderBy field, newest first. An error is
+	// returned if one occurs, nil on success.
+	QueryAllCrimes(ctx context.Context, offset uint, limit uint, orderBy OrderByType) ([]*Crime, error)
+
+	// QueryCrimeSightings retrieves the sightings of the crime with the
+	// provided ID. Ordered by the end of the listing report's range,
+	// oldest first. An error is returned if one occurs, nil on success.
+	QueryCrimeSightings(ctx context.Context, crimeID int) ([]*CrimeSighting, error)
+
+	// QueryCrimeRevisions retrieves the revisions of the fields of the
+	// crime with the provided ID. Ordered by the end of the listing
+	// report's range, oldest first. An error is returned if one occurs,
+	// nil on success.
+	QueryCrimeRevisions(ctx context.Context, crimeID int) ([]*CrimeRevision, error)
+
+	// SetCrimeCategories replaces the incident categories of saved
+	// crimes with their Crime.Categories fields. Each category must be in
+	// IncidentTaxonomy. Either all crimes are changed or none are. An
+	// error is returned if one occurs, nil on success.
+	SetCrimeCategories(ctx context.Context, crimes []Crime) error
+
+	// QueryIncidentCounts counts the crimes each raw incident was
+	// recorded for, keyed by incident. Crimes only listed by superseded
+	// reports are not included. An error is returned if one occurs, nil on success.
+	QueryIncidentCounts(ctx context.Context) (map[string]uint, error)
+}
+
+// ReportStore saves and retrieves Report models
+type ReportStore interface {
+	// QueryReport finds the report with the provided ID. sql.ErrNoRows is
+	// returned if none exists. Another error is returned if one occurs,
+	// nil on success.
+	QueryReport(ctx context.Context, id int) (*Report, error)
+
+	// QueryReportByFile finds a report with the same file hash and sets
+	// the Report.ID, Report.ParseSuccess and Report.CrimesCount fields.
+	// sql.ErrNoRows is returned if none is found. Another error is
+	// returned if one occurs, nil on success.
+	QueryReportByFile(ctx context.Context, r *Report) error
+
+	// QueryPreviousReport finds a report which has not been superseded,
+	// from the same university, covering the same date range.
+	// sql.ErrNoRows is returned if none is found. Another error is
+	// returned if one occurs, nil on success.
+	QueryPreviousReport(ctx context.Context, r Report) (*Report, error)
+
+	// InsertReport saves a report and sets the Report.ID field. An error
+	// is returned if one occurs, nil on success.
+	InsertReport(ctx context.Context, r *Report) error
+
+	// UpdateReportPostParseFields saves the Report.ParseSuccess,
+	// Report.ParsePartial, Report.CrimesCount, Report.PagesFailed and
+	// Report.Diagnostics fields. An error is returned if one occurs, nil
+	// on success.
+	UpdateReportPostParseFields(ctx context.Context, r Report) error
+
+	// UpdateReportFileFields saves the Report.FileHash, Report.FileName
+	// and Report.FileSize fields. An error is returned if one occurs, nil
+	// on success.
+	UpdateReportFileFields(ctx context.Context, r Report) error
+
+	// SupersedeReport marks the old report as replaced by the report r.
+	// The old Report.SupersededBy field is set. An error is returned if
+	// one occurs, nil on success.
+	SupersedeReport(ctx context.Context, r Report, old *Report) error
+
+	// DeleteReportCrimes removes the report's crime sightings, and their
+	// revisions. Crimes no other report lists are removed, along with
+	// their parse errors and incident categories. As are parse errors
+	// from parsing the report. The report's post parse fields are reset.
+	// An error is returned if one occurs, nil on success.
+	DeleteReportCrimes(ctx context.Context, r *Report) error
+
+	// LocalizeReport corrects the times of a report, and its crimes,
+	// which were saved as if written in UTC before report time zones were
+	// recorded. Their wall clock times are interpreted in the provided
+	// location, and the Report.TimeZone field is set. An error is returned
+	// if one occurs, nil on success.
+	LocalizeReport(ctx context.Context, r *Report, loc *time.Location) error
+
+	// QueryAllReports retrieves all reports. An error is returned if one
+	// occurs, nil on success.
+	QueryAllReports(ctx context.Context) ([]*Report, error)
+}
+
+// GeoLocStore saves and retrieves GeoLoc models, and the GeoBound models
+// they reference
+type GeoLocStore interface {
+	// QueryGeoLoc finds a GeoLoc with the same raw location and sets the
+	// GeoLoc.ID field. sql.ErrNoRows is returned if none is found.
+	// Another error is returned if one occurs, nil on success.
+	QueryGeoLoc(ctx context.Context, l *GeoLoc) error
+
+	// InsertGeoLoc saves a GeoLoc and sets the GeoLoc.ID field. An error
+	// is returned if one occurs, nil on success.
+	InsertGeoLoc(ctx context.Context, l *GeoLoc) error
+
+	// InsertGeoLocIfNew saves an unlocated GeoLoc if one with the same
+	// raw location does not exist. The GeoLoc.ID field is set to the found
+	// / inserted GeoLoc's ID. Safe to call from concurrent transactions
+	// with the same raw location. An error is returned if one occurs, nil
+	// on success.
+	InsertGeoLocIfNew(ctx context.Context, l *GeoLoc) error
+
+	// UpdateGeoLoc saves the located fields of a GeoLoc. An error is
+	// returned if one occurs, nil on success.
+	UpdateGeoLoc(ctx context.Context, l GeoLoc) error
+
+	// QueryUnlocatedGeoLocs retrieves GeoLocs which have not been located
+	// yet. An error is returned if one occurs, nil on success.
+	QueryUnlocatedGeoLocs(ctx context.Context) ([]*GeoLoc, error)
+
+	// InsertGeoBoundIfNew saves a GeoBound if one with the same corners
+	// does not exist. The GeoBound.ID field is set to the found /
+	// inserted bound's ID. An error is returned if one occurs, nil on
+	// success.
+	InsertGeoBoundIfNew(ctx context.Context, b *GeoBound) error
+}
+
+// ParseErrorStore saves and retrieves ParseError models
+type ParseErrorStore interface {
+	// QueryParseError finds a parse error with the same fields and sets
+	// the ParseError.ID field. sql.ErrNoRows is returned if none is found.
+	// Another error is returned if one occurs, nil on success.
+	QueryParseError(ctx context.Context, e *ParseError) error
+
+	// InsertParseError saves a parse error and sets the ParseError.ID
+	// field. An error is returned if one occurs, nil on success.
+	InsertParseError(ctx context.Context, e *ParseError) error
+
+	// InsertParseErrorIfNew saves a parse error if one with the same
+	// fields does not exist. The ParseError.ID field is set to the found /
+	// inserted parse error's ID. An error is returned if one occurs, nil
+	// on success.
+	InsertParseErrorIfNew(ctx context.Context, e *ParseError) error
+}
+
+// Store saves and retrieves all models. Every method takes a context which can
+// be used to cancel the operation.
+type Store interface {
+	CrimeStore
+	ReportStore
+	GeoLocStore
+	ParseErrorStore
+
+	// Tx runs fn with a Store which makes all changes in a single
+	// transaction. If fn returns an error the changes are discarded and
+	// the error is returned. Otherwise the changes are saved. If the
+	// Store is already a transaction fn joins it. If ctx is canceled
+	// before the transaction finishes the changes are discarded.
+	Tx(ctx context.Context, fn func(s Store) error) error
+}
@@ -1,6 +1,7 @@
 package parsers
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
@@ -10,8 +11,10 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Noah-Huppert/crime-map/date"
 	"github.com/Noah-Huppert/crime-map/geo"
 	"github.com/Noah-Huppert/crime-map/models"
+	"github.com/Noah-Huppert/crime-map/pdf"
 )
 
 // DrexelUName holds the Drexel University's name
@@ -26,21 +29,83 @@ var dateExpr *regexp.Regexp = regexp.MustCompile("^([0-9]{2})\\/([0-9]{2})\\/([0
 // dateRangeExpr is the regexp used to extract 2 dates in a report date range
 var dateRangeExpr *regexp.Regexp = regexp.MustCompile("^(.*[0-9]) - ([0-9].*)$")
 
-// footerPageNumExpr holds the regexp used to match page numbers at the bottom
-// of the header
+// footerPageNumExpr holds the regexp used to match page numbers in the footer
 var footerPageNumExpr *regexp.Regexp = regexp.MustCompile("^[0-9]+$")
 
 // monthAbbrvs holds valid month abbreviations
 var monthAbbrvs []string = []string{"Jan", "Feb", "Mar", "Apr", "May", "Jun",
 	"Jul", "Aug", "Sep", "Oct", "Nov", "Dec"}
 
-// Field labels
-const fieldLabelReported string = "Date Reported:"
-const fieldLabelIncidents string = "Incident(s):"
-const fieldLabelOccurred string = "Date and Time Occurred From - Occurred To:"
-const fieldLabelFix string = "Disposition:"
+// fieldLabelCrimeCount is the label printed before the number of crimes a
+// report lists
 const fieldLabelCrimeCount string = "Incident(s) Listed."
 
+// Column names of the Drexel report table
+const (
+	columnReported    string = "reported"
+	columnLocation    string = "location"
+	columnReportID    string = "report_id"
+	columnIncidents   string = "incidents"
+	columnOccurred    string = "occurred"
+	columnSynopsis    string = "synopsis"
+	columnDisposition string = "disposition"
+)
+
+// drexelLayout describes the table Drexel reports list crimes in. Each crime
+// is a record with its own labels.
+var drexelLayout pdf.TableLayout = pdf.TableLayout{
+	Columns: []pdf.TableColumn{
+		pdf.TableColumn{
+			Name:   columnReported,
+			Labels: []string{"Date Reported:"},
+		},
+		pdf.TableColumn{
+			Name:   columnLocation,
+			Labels: []string{"Location :", "Location:"},
+		},
+		pdf.TableColumn{
+			Name:   columnReportID,
+			Labels: []string{"Report #:"},
+		},
+		pdf.TableColumn{
+			Name:   columnIncidents,
+			Labels: []string{"Incident(s):"},
+		},
+		pdf.TableColumn{
+			Name: columnOccurred,
+			Labels: []string{"Date and Time Occurred From - " +
+				"Occurred To:"},
+		},
+		pdf.TableColumn{
+			Name:   columnSynopsis,
+			Labels: []string{"Synopsis:"},
+		},
+		pdf.TableColumn{
+			Name:   columnDisposition,
+			Labels: []string{"Disposition:"},
+		},
+	},
+	Furniture: []*regexp.Regexp{
+		// Header
+		headerDateRangeExpr,
+		regexp.MustCompile("^" + DrexelUName + "$"),
+		regexp.MustCompile("^Public Safety$"),
+		regexp.MustCompile("^Student Right To Know Case Log Daily " +
+			"Report$"),
+
+		// Footer
+		footerPageNumExpr,
+		regexp.MustCompile("^Page No\\.$"),
+		regexp.MustCompile("^Print Date and Time$"),
+		regexp.MustCompile("^[0-9]{2}/[0-9]{2}/[0-9]{4}$"),
+		regexp.MustCompile("^[0-9]{2}:[0-9]{2}:[0-9]{2}$"),
+
+		// Count of crimes, parsed separately
+		regexp.MustCompile("^" + regexp.QuoteMeta(fieldLabelCrimeCount) +
+			"$"),
+	},
+}
+
 // errNotHeaderDateRange indicates that the provided field was not a date range
 // present in the report header
 var errNotHeaderDateRange error = errors.New("provided field was not a header" +
@@ -49,357 +114,232 @@ var errNotHeaderDateRange error = errors.New("provided field was not a header" +
 // DrexelParser implements the Parser interface for Drexel University Clery
 // crime logs
 type DrexelParser struct {
+	pdfParser
+
 	// logger is used to output debug information
 	logger *log.Logger
+}
 
-	// geoCache is used to cache GeoLoc queryies to the database
-	geoCache *geo.GeoCache
-
-	// fields holds the text fields from the pdf we are parsing
-	fields []string
+// DrexelParserName is the name DrexelParser is registered with
+const DrexelParserName string = "drexel"
+
+func init() {
+	Register(Registration{
+		Name:       DrexelParserName,
+		University: models.UniversityDrexel,
+		Detect:     detectDrexel,
+		New: func(geoCache *geo.GeoCacheTx, pages pdf.PageSource, mode Mode, location *time.Location) Parser {
+			return NewDrexelParser(geoCache, pages, mode, location)
+		},
+	})
+}
 
-	// parsedCrimes indicates if a report's crime models have been parsed
-	// out yet
-	parsedCrimes bool
+// detectDrexel scores how likely a report is a Drexel University report, from
+// the text on its first page
+func detectDrexel(fields []string) float64 {
+	return detectReport(fields, DrexelUName, headerDateRangeExpr,
+		drexelLayout)
+}
 
-	// parsedRange indicates if a report's date range has been parsed
-	// out yet
-	parsedRange bool
+// NewDrexelParser creates a new DrexelParser instance which parses the
+// provided pages. Problems in the report are handled as the mode specifies.
+// Report times are interpreted in the provided location.
+func NewDrexelParser(geoCache *geo.GeoCacheTx, pages pdf.PageSource, mode Mode, location *time.Location) *DrexelParser {
+	return &DrexelParser{
+		pdfParser: newPdfParser(geoCache, pages, mode, location),
+		logger:    log.New(os.Stdout, "parsers/drexel", 0),
+	}
+}
 
-	// crimes holds the Crimes which were parsed from a report, empty if
-	// parsedCrimes == false
-	crimes []models.Crime
+// Range implements the Range method for Parser. It reads pages until the date
+// range the report covers is found. Which is usually in the first page's
+// header. Pages read are kept for Parse.
+func (p *DrexelParser) Range() (*time.Time, *time.Time, error) {
+	return p.findRange(func(text string) (bool, error) {
+		err := p.parseHeaderRange(text)
+		if err == errNotHeaderDateRange {
+			return false, nil
+		} else if err != nil {
+			return false, fmt.Errorf("error parsing header date "+
+				"range: %s", err.Error())
+		}
 
-	// startRange holds the start of the time range which the report covers
-	startRange *time.Time
+		return true, nil
+	})
+}
 
-	// endRange holds the end of the time range which the report covers
-	endRange *time.Time
+// Parse interprets a pdf's text runs into Crime structs. For the style of
+// report Drexel University releases.
+func (p *DrexelParser) Parse(ctx context.Context, reportID int) ([]models.Crime, error) {
+	return p.parseTable(ctx, reportID, pdfTable{
+		layout:       drexelLayout,
+		countLabel:   fieldLabelCrimeCount,
+		missingField: missingField,
+		parseRecord:  p.parseRecord,
+	})
 }
 
-// NewDrexelParser creates a new DrexelParser instance
-func NewDrexelParser(geoCache *geo.GeoCache, fields []string) *DrexelParser {
-	return &DrexelParser{
-		logger:       log.New(os.Stdout, "parsers/drexel", 0),
-		fields:       fields,
-		geoCache:     geoCache,
-		parsedCrimes: false,
-		parsedRange:  false,
-		crimes:       []models.Crime{},
+// parseRecord converts one record in a report's table into a Crime. An error
+// is returned if one occurs, nil on success.
+func (p *DrexelParser) parseRecord(ctx context.Context, record pdf.TableRecord) (*models.Crime, error) {
+	c := &models.Crime{
+		// Pages are counted from 0
+		Page: int(record.Page) - 1,
 	}
-}
 
-// Range implements the Range method for Parser. It parses the fields far
-// enough to determine the date range the report covers
-func (p *DrexelParser) Range() (*time.Time, *time.Time, error) {
-	// Check if already parsed range
-	if p.parsedRange {
-		// If so, return
-		return p.startRange, p.endRange, nil
+	// Check all fields present
+	if missing := missingField(record); len(missing) > 0 {
+		return nil, newRecordError(models.TypeTruncatedRecord,
+			"%s field missing", missing)
 	}
 
-	// Loop through fields until we parse a header date range
-	for _, field := range p.fields {
-		// If parsed header date range
-		if _, err := p.parseHeaderRange(field); err != errNotHeaderDateRange {
-			// If parse error
-			if err != nil {
-				return nil, nil, fmt.Errorf("error parsing "+
-					"header date range: %s", err.Error())
-			}
-
-			// Success
-			return p.startRange, p.endRange, nil
-		}
+	// Date reported
+	d, err := parseDate(record.Get(columnReported), p.location)
+	if err != nil {
+		return nil, newRecordError(models.TypeBadDate,
+			"error parsing reported at field: %s", err.Error())
 	}
+	c.DateReported = *d
 
-	// If looped through all fields and not found, error
-	return nil, nil, errors.New("error finding header date range, not found")
-}
+	// Location. Gets GeoLoc with just a populated ID field. This allows us
+	// to set the crime foreign key, but not know anything about the
+	// location.
+	loc, err := p.geoCache.InsertIfNew(ctx, record.Get(columnLocation))
+	if err != nil {
+		return nil, fmt.Errorf("error getting cached GeoLoc: %s",
+			err.Error())
+	}
+	c.GeoLocID = loc.ID
+
+	// Report ID, split by dash
+	field := record.Get(columnReportID)
+	parts := strings.Split(field, "-")
+
+	// Check correct number of parts
+	if len(parts) != 2 {
+		return nil, newRecordError(models.TypeBadReportID,
+			"report ID field has incorrect number of parts, "+
+				"field: %s, parts: %d, expected parts: 2",
+			field, len(parts))
+	}
 
-// Count returns the number of crimes parsed in the report.
-func (p DrexelParser) Count() (uint, error) {
-	// Check if not parsed yet
-	if !p.parsedCrimes {
-		return 0, ErrReportNotParsed
+	// Parse both ids
+	id, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return nil, newRecordError(models.TypeBadReportID,
+			"error parsing report super ID into uint: %s",
+			err.Error())
 	}
+	c.ReportSuperID = uint(id)
 
-	// If parsed, return count
-	l := len(p.crimes)
+	id, err = strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return nil, newRecordError(models.TypeBadReportID,
+			"error parsing report Id into uint: %s", err.Error())
+	}
+	c.ReportSubID = uint(id)
 
-	return uint(l), nil
-}
+	// Incidents
+	c.Incidents = record.Lines(columnIncidents)
+	if c.Incidents == nil {
+		c.Incidents = []string{}
+	}
 
-// Parse interprets a pdf's text fields into Crime structs. For the style of
-// report Drexel University releases.
-func (p *DrexelParser) Parse(reportID int) ([]models.Crime, error) {
-	// Check if already parsed
-	if p.parsedCrimes {
-		// Return results
-		return p.crimes, ErrReportParsed
+	// Date occurred
+	if err = p.parseOccurred(c, record.Get(columnOccurred)); err != nil {
+		return nil, err
 	}
 
-	// c holds the Crime struct currently being parsed
-	var c models.Crime
+	// Synopsis
+	c.Descriptions = record.Lines(columnSynopsis)
+	if c.Descriptions == nil {
+		c.Descriptions = []string{}
+	}
 
-	// skip indicates how many fields the parser should skip
-	var skip int
+	// Disposition
+	c.Remediation = record.Get(columnDisposition)
 
-	// consume indicates how many fields the parser should consume, if
-	// multiple fields need to be consumed in a row
-	var consume int
+	return c, nil
+}
+
+// parseOccurred parses the date occurred range field and sets the
+// Crime.DateOccurredStart and Crime.DateOccurredEnd fields, see setOccurred.
+// Crime.DateReported must already be set. If the field is empty the date
+// reported is used, and recorded as a ParseError on the Crime.
+//
+// An error is returned if one occurs, nil on success.
+func (p DrexelParser) parseOccurred(c *models.Crime, field string) error {
+	// Some records leave the range empty
+	if len(field) == 0 {
+		c.ParseErrors = append(c.ParseErrors, models.ParseError{
+			Field:    "date_occurred",
+			Original: field,
+			Corrected: fmt.Sprintf("%s - %s", c.DateReported.String(),
+				c.DateReported.String()),
+			ErrType: models.TypeMissingRange,
+		})
+
+		c.DateOccurredStart = c.DateReported
+		c.DateOccurredEnd = c.DateReported
+
+		return nil
+	}
 
-	// consumeGlob1 indicates if the date reported, location, report ID,
-	// and incidents field values come after the skipping is done
-	var consumeGlob1 bool
+	// Split dates
+	matches := dateRangeExpr.FindStringSubmatch(field)
 
-	// consumeDOccurred indicates if the date occurred field follows the skipped
-	// fields
-	var consumeDOccurred bool
+	// Check correct number of dates
+	if len(matches) != 3 {
+		return newRecordError(models.TypeBadDate, "error parsing "+
+			"date occurred, incorrect number of dates, "+
+			"field: %s, expected 2, got: %d",
+			field, len(matches)-1)
+	}
 
-	// consumeDesc indicates if the synopsis field is being consumed
-	var consumeDesc bool
+	// Parse dates
+	start, err := parseDate(matches[1], p.location)
+	if err != nil {
+		return newRecordError(models.TypeBadDate, "error parsing "+
+			"occurred start date, field: %s, err: %s",
+			field, err.Error())
+	}
 
-	// consumeFix indicates that the remediation field is coming up next
-	var consumeFix bool
+	end, err := parseDate(matches[2], p.location)
+	if err != nil {
+		return newRecordError(models.TypeBadDate, "error parsing "+
+			"occurred end date, field: %s, err: %s",
+			field, err.Error())
+	}
 
-	// consumeCrimeCount indicates that the total crime count field is
-	// coming up next
-	var consumeCrimeCount bool
+	setOccurred(c, field, *start, *end)
 
-	// pageNum holds the current page number
-	pageNum := 0
+	return nil
+}
 
-	// Loop through fields
-	for _, field := range p.fields {
-		// Check if we are skipping fields
-		if skip > 0 {
-			skip -= 1
-			continue
-		}
+// drexelRequiredColumns holds the columns every record must have a value for.
+// Older reports leave the other fields of some records empty.
+var drexelRequiredColumns []string = []string{columnReported, columnReportID}
 
-		// Check if first line of header
-		if newSkip, err := p.parseHeaderRange(field); err != errNotHeaderDateRange {
-			// Check if error occurred
-			if err != nil {
-				return p.crimes, fmt.Errorf("error parsing "+
-					"header date range: %s", err.Error())
-			}
-
-			// Set new skip value if not 0
-			if newSkip > 0 {
-				skip = newSkip
-			}
-		} else if footerPageNumExpr.MatchString(field) { // Check if
-			// first line of footer
-			skip = 5
-			pageNum += 1
-		} else if consumeGlob1 { // Check if we are consuming glob 1
-			// If consuming date reported field
-			if consume == 4 {
-				d, err := parseDate(field)
-				if err != nil {
-					return p.crimes, fmt.Errorf("error parsing"+
-						" reported at field: %s",
-						err.Error())
-				}
-
-				c.Page = pageNum
-				c.DateReported = *d
-				consume--
-			} else if consume == 3 { // If consuming location field
-				// Gets GeoLoc with just a populated ID field.
-				// This allows us to set the crime foreign key,
-				// but not know anything about the location
-				loc, err := p.geoCache.InsertIfNew(field)
-
-				if err != nil {
-					return p.crimes, fmt.Errorf("error "+
-						"getting cached GeoLoc: %s",
-						err.Error())
-				}
-
-				// Set GeoLoc FK
-				c.GeoLocID = loc.ID
-
-				consume--
-			} else if consume == 2 { // If consuming report ID field
-				// Split by dash
-				parts := strings.Split(field, "-")
-
-				// Check correct number of parts
-				if len(parts) != 2 {
-					return p.crimes, fmt.Errorf("report ID "+
-						"field has incorrect number of"+
-						" parts, field: %s, parts: %d, "+
-						" expected parts: 2",
-						field, len(parts))
-				}
-
-				// Parse both ids
-				id, err := strconv.ParseUint(parts[0], 10, 64)
-				if err != nil {
-					return p.crimes, fmt.Errorf("error parsing "+
-						"report super ID into uint: %s",
-						err.Error())
-				}
-				c.ReportSuperID = uint(id)
-
-				id, err = strconv.ParseUint(parts[1], 10, 64)
-				if err != nil {
-					return p.crimes, fmt.Errorf("error parsing "+
-						"report Id into uint: %s",
-						err.Error())
-				}
-				c.ReportSubID = uint(id)
-
-				consume--
-			} else if consume == 1 { // If consuming incidents field
-				c.Incidents = []string{field}
-
-				// And reset glob 1 parser flags
-				consumeGlob1 = false
-				consume = 0
-			}
-		} else if consumeDOccurred { // Check if we are consuming date
-			// occurred
-
-			// Split dates
-			matches := dateRangeExpr.FindStringSubmatch(field)
-
-			// Check correct number of dates
-			if len(matches) != 3 {
-				return p.crimes, fmt.Errorf("error parsing date "+
-					"occurred, incorrect number of dates, "+
-					"field: %s, expected 2, got: %d",
-					field, len(matches)-1)
-			}
-
-			// Parse dates
-			start, err := parseDate(matches[1])
-			if err != nil {
-				return p.crimes, fmt.Errorf("error parsing occurred"+
-					" start date, field: %s, err: %s",
-					field, err.Error())
-			}
-
-			end, err := parseDate(matches[2])
-			if err != nil {
-				return p.crimes, fmt.Errorf("error parsing occurred"+
-					" end date, field: %s, err: %s",
-					field, err.Error())
-			}
-
-			// Check start date is after end date
-			if start.After(*end) {
-				// If so, add 12 hours to end date
-				fixedEnd := end.Add(time.Hour *
-					time.Duration(12))
-
-				// Check again
-				if start.After(fixedEnd) {
-					// We don't know how to fix, error
-					return p.crimes, fmt.Errorf("error "+
-						"parsing occurred date, start "+
-						"date is before end date, "+
-						"after correction, field: %s",
-						field)
-				}
-
-				// Note parse error
-				pErr := models.ParseError{
-					Field:    "date_occurred",
-					Original: field,
-					Corrected: fmt.Sprintf("%s - %s",
-						start.String(),
-						fixedEnd.String()),
-					ErrType: models.TypeBadRangeEnd,
-				}
-
-				// Save parse error
-				c.ParseErrors = append(c.ParseErrors, pErr)
-
-				// If success, replace
-				end = &fixedEnd
-			}
-
-			// Save
-			c.DateOccurredStart = *start
-			c.DateOccurredEnd = *end
-
-			consumeDOccurred = false
-		} else if consumeDesc { // Check if consuming synopsis
-			// Check if end of consuming synopsis
-			if field == fieldLabelFix {
-				// End of synopsis field values
-				consumeDesc = false
-
-				// Beginning of fix field
-				consumeFix = true
-			} else { // Otherwise, consume
-				c.Descriptions = append(c.Descriptions, field)
-			}
-		} else if consumeFix { // Check if we should consume the
-			// remediation field
-			c.Remediation = field
-			consumeFix = false
-
-			// And add crime to list
-			c.ReportID = reportID
-
-			p.crimes = append(p.crimes, c)
-
-			c = models.Crime{}
-		} else if field == fieldLabelReported { // Check if beginning
-			// of glob 1
-			skip = 2
-			consumeGlob1 = true
-			consume = 4
-		} else if field == fieldLabelIncidents { // Check if date
-			// occurred field will come next
-			consumeDOccurred = true
-		} else if field == fieldLabelOccurred { // Check if synopsis
-			// field will come next
-			skip = 1
-			consumeDesc = true
-			c.Descriptions = []string{}
-		} else if field == fieldLabelCrimeCount { // Check if incidents
-			// listed count comes next
-			consumeCrimeCount = true
-		} else if consumeCrimeCount { // Check if consuming crime count
-			count, err := strconv.Atoi(field[1:])
-			if err != nil {
-				return p.crimes, fmt.Errorf("error parsing number"+
-					" of listed crimes: %s", err.Error())
-			}
-
-			consumeCrimeCount = false
-
-			// Check count matches
-			if len(p.crimes) != count {
-				return p.crimes, fmt.Errorf("number of listed "+
-					"crimes and number of crimes parsed "+
-					"does not match: listed: %d, parsed: %d",
-					count,
-					len(p.crimes))
-			}
-		} else {
-			return p.crimes, fmt.Errorf("error parsing field, "+
-				"unknown value: %s", field)
+// missingField returns the name of the first required column which a record
+// has no value for. Empty if all are present.
+func missingField(record pdf.TableRecord) string {
+	for _, column := range drexelRequiredColumns {
+		if len(record.Get(column)) == 0 {
+			return column
 		}
 	}
 
-	// Success
-	p.parsedCrimes = true
-	return p.crimes, nil
-
+	return ""
 }
 
-// parseDate Creates a time struct from a drexel date on a report. The offset
-// var specifies the offset to add to match indexes. An error is returned if one
-// occurs, nil otherwise.
-func parseDate(field string) (*time.Time, error) {
+// parseDate Creates a time struct from a drexel date on a report, written in
+// the provided location. An error is returned if one occurs, nil otherwise.
+func parseDate(field string, location *time.Location) (*time.Time, error) {
 	matches := dateExpr.FindStringSubmatch(field)
+	if matches == nil {
+		return nil, fmt.Errorf("date not in expected format: %s",
+			field)
+	}
 
 	year, err := strconv.ParseInt(matches[3], 10, 64)
 	if err != nil {
@@ -431,12 +371,19 @@ func parseDate(field string) (*time.Time, error) {
 			err.Error())
 	}
 
-	d := time.Date(int(year),
+	// Years are written with 2 digits
+	if err := date.Check(2000+int(year), time.Month(month), int(day),
+		int(hour), int(minute)); err != nil {
+		return nil, fmt.Errorf("invalid date: %s: %s", field,
+			err.Error())
+	}
+
+	d := date.Local(2000+int(year),
 		time.Month(month),
 		int(day),
 		int(hour),
 		int(minute),
-		0, 0, time.UTC)
+		0, location)
 
 	return &d, nil
 }
@@ -488,8 +435,8 @@ func (p DrexelParser) parseHeaderDate(matches []string, offset uint) (*time.Time
 	}
 
 	// Success
-	t := time.Date(int(year), time.Month(month), int(day), 0, 0, 0, 0,
-		time.UTC)
+	t := date.Local(int(year), time.Month(month), int(day), 0, 0, 0,
+		p.location)
 	return &t, nil
 }
 
@@ -511,28 +458,22 @@ func (p DrexelParser) parseMonthAbbrv(abbrv string) (uint, error) {
 // parseHeaderRange determines if the field provided is the report date range.
 // If it is, the field is parsed and saved so the Range method can return it.
 //
-// The number of fields to skip after parseRange is called is returned. If 0,
-// the existing skip variable should not be modified.
-//
-// Additionally an error is returned if one occurs, nil on success. The
+// An error is returned if one occurs, nil on success. The
 // errNotHeaderDateRange error will be returned if the provided field was not
 // in the header date range format.
-func (p *DrexelParser) parseHeaderRange(field string) (int, error) {
-	validSkip := 3
-	errSkip := -1
-
+func (p *DrexelParser) parseHeaderRange(field string) error {
 	// Check if field is header date range
 	if matches := headerDateRangeExpr.FindStringSubmatch(field); matches != nil {
 		// Check if already parsed
 		if p.parsedRange {
 			// Exit
-			return validSkip, nil
+			return nil
 		}
 
 		// Convert start date
 		startRange, err := p.parseHeaderDate(matches, 0)
 		if err != nil {
-			return errSkip, fmt.Errorf("error converting start "+
+			return fmt.Errorf("error converting start "+
 				"header date to time.Time: %s",
 				err.Error())
 		}
@@ -541,19 +482,18 @@ func (p *DrexelParser) parseHeaderRange(field string) (int, error) {
 		// Convert end date
 		endRange, err := p.parseHeaderDate(matches, 1)
 		if err != nil {
-			return errSkip, fmt.Errorf("error converting end "+
+			return fmt.Errorf("error converting end "+
 				"header date to time.Time: %s",
 				err.Error())
 		}
 		p.endRange = endRange
 
-		// Success, indicate we should skip a couple fields
+		// Mark as parsed
 		p.parsedRange = true
 
-		// Mark as parsed and skip fields
-		return validSkip, nil
+		return nil
 	}
 
 	// If not a header date range
-	return 0, errNotHeaderDateRange
+	return errNotHeaderDateRange
 }
@@ -0,0 +1,220 @@
+//go:build go1.18
+// +build go1.18
+
+package parsers
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Noah-Huppert/crime-map/geo"
+	"github.com/Noah-Huppert/crime-map/models"
+	"github.com/Noah-Huppert/crime-map/pdf"
+)
+
+// fuzzLocation loads the location Drexel report times are interpreted in, so
+// fuzzing covers daylight saving time changes
+func fuzzLocation(f *testing.F) *time.Location {
+	loc, err := models.UniversityDrexel.Location()
+	if err != nil {
+		f.Fatalf("error loading location: %s", err.Error())
+	}
+
+	return loc
+}
+
+// drexelFuzzReport is a short Drexel report in the field sequence format read
+// by fuzzPages. Used to seed FuzzDrexelParse.
+const drexelFuzzReport string = `223.2|719.8|From Oct 14, 2017 to Dec 14, 2017.
+264.1|765.4|Drexel University
+12.5|687.6|Date Reported:
+198.1|687.6| Report #:
+12.5|674.0|Location :
+84.5|687.9|10/14/17 - SAT at 00:09
+61.5|673.9|NORTH HALL - On Campus - in any student residential facility
+237.3|687.9|1710-05589
+69.5|640.8|POLICY VIOLATION-DRUGS
+23.8|640.5|Incident(s):
+209.0|655.8|10/14/17 - SAT at 00:09 - 10/14/17 - SAT at 01:44
+12.5|655.5|Date and Time Occurred From - Occurred To:
+28.5|629.1|Synopsis:
+69.5|629.4|RLO# 201700225
+12.5|606.4|Disposition:
+71.3|606.2|STUDENT CONDUCT
+---
+12.5|687.6|Date Reported:
+198.1|687.6| Report #:
+12.5|674.0|Location :
+84.5|687.9|11/05/17 - SUN at 01:30
+61.5|673.9|33RD AND MARKET
+237.3|687.9|1711-00012
+69.5|640.8|THEFT
+23.8|640.5|Incident(s):
+209.0|655.8|11/05/17 - SUN at 01:00 - 11/05/17 - SUN at 00:30
+12.5|655.5|Date and Time Occurred From - Occurred To:
+28.5|629.1|Synopsis:
+12.5|606.4|Disposition:
+71.3|606.2|CLOSED
+109.1|360.2|Incident(s) Listed.
+89.9|360.2| 2
+553.5|10.2|29
+504.6|10.2|Page No.`
+
+// fuzzPages implements pdf.PageSource for a field sequence. Each line is a text
+// run, written as "x|y|text". Lines without a position are placed one below
+// the other. A line of "---" starts a new page.
+type fuzzPages struct {
+	// pages holds the pages which have not been returned by Next yet
+	pages []*pdf.Page
+}
+
+// newFuzzPages creates a fuzzPages from a field sequence
+func newFuzzPages(data string) *fuzzPages {
+	src := &fuzzPages{}
+	page := &pdf.Page{Number: 1}
+
+	for i, line := range strings.Split(data, "\n") {
+		if line == "---" {
+			src.pages = append(src.pages, page)
+			page = &pdf.Page{Number: page.Number + 1}
+			continue
+		}
+
+		run := pdf.TextRun{
+			Page:     page.Number,
+			X:        12.5,
+			Y:        float64(700 - 12*i),
+			FontSize: 8,
+			Text:     line,
+		}
+
+		parts := strings.SplitN(line, "|", 3)
+		if len(parts) == 3 {
+			x, xErr := strconv.ParseFloat(parts[0], 64)
+			y, yErr := strconv.ParseFloat(parts[1], 64)
+
+			if xErr == nil && yErr == nil {
+				run.X = x
+				run.Y = y
+				run.Text = parts[2]
+			}
+		}
+
+		page.Runs = append(page.Runs, run)
+	}
+
+	src.pages = append(src.pages, page)
+
+	return src
+}
+
+// Next implements pdf.PageSource.Next
+func (s *fuzzPages) Next() (*pdf.Page, error) {
+	if len(s.pages) == 0 {
+		return nil, io.EOF
+	}
+
+	page := s.pages[0]
+	s.pages = s.pages[1:]
+
+	return page, nil
+}
+
+// Diagnostics implements pdf.PageSource.Diagnostics
+func (s fuzzPages) Diagnostics() pdf.Diagnostics {
+	return pdf.Diagnostics{}
+}
+
+// FuzzParseDate checks parseDate returns an error, rather than panicking, for
+// fields which are not Drexel dates. And that dates which are parsed fall on
+// the day written, rather than being normalized into another.
+func FuzzParseDate(f *testing.F) {
+	f.Add("10/14/17 - SAT at 00:09")
+	f.Add("11/05/17 - SUN at 01:30")
+	f.Add("00/00/00 - X at 99:99")
+	f.Add("13/45/17 - X at 25:70")
+	f.Add("02/30/17 - THU at 12:00")
+	f.Add("10/14/17 - SAT at 24:00")
+	f.Add("")
+
+	loc := fuzzLocation(f)
+
+	f.Fuzz(func(t *testing.T, field string) {
+		d, err := parseDate(field, loc)
+		if err != nil {
+			return
+		} else if d == nil {
+			t.Fatalf("no date or error returned for: %q", field)
+		}
+
+		if day := d.Format("01/02/06"); !strings.HasPrefix(field, day) {
+			t.Fatalf("date %s parsed from: %q", d, field)
+		}
+	})
+}
+
+// FuzzParseHeaderRange checks parseHeaderRange returns an error, rather than
+// panicking, for fields which are not report header date ranges
+func FuzzParseHeaderRange(f *testing.F) {
+	f.Add("From Oct 14, 2017 to Dec 14, 2017.")
+	f.Add("From Foo 99, 0000 to Bar 00, 9999.")
+	f.Add("From Oct 14, 2017")
+	f.Add("")
+
+	loc := fuzzLocation(f)
+
+	f.Fuzz(func(t *testing.T, field string) {
+		p := NewDrexelParser(nil, newFuzzPages(""), ModeStrict, loc)
+
+		err := p.parseHeaderRange(field)
+		if err == nil && (p.startRange == nil || p.endRange == nil) {
+			t.Fatalf("no range or error returned for: %q", field)
+		}
+	})
+}
+
+// FuzzDrexelParse checks DrexelParser returns errors, or records parse
+// errors, rather than panicking, for any sequence of fields. In both parse
+// modes.
+func FuzzDrexelParse(f *testing.F) {
+	f.Add(drexelFuzzReport, false)
+	f.Add(drexelFuzzReport, true)
+	f.Add(strings.Replace(drexelFuzzReport, "1710-05589", "1710", 1), true)
+	f.Add(strings.Replace(drexelFuzzReport, " 2\n", " two\n", 1), true)
+	f.Add("Incident(s) Listed.", false)
+	f.Add("", false)
+
+	loc := fuzzLocation(f)
+
+	f.Fuzz(func(t *testing.T, data string, lenient bool) {
+		mode := ModeStrict
+		if lenient {
+			mode = ModeLenient
+		}
+
+		store := models.NewMemStore()
+		p := NewDrexelParser(geo.NewGeoCache(store).Begin(store),
+			newFuzzPages(data), mode, loc)
+
+		if _, _, err := p.Range(); err != nil {
+			return
+		}
+
+		crimes, err := p.Parse(context.Background(), 1)
+		if err != nil {
+			return
+		}
+
+		for _, c := range crimes {
+			if c.DateOccurredEnd.Before(c.DateOccurredStart) {
+				t.Fatalf("crime %d-%d occurred range ends "+
+					"before it starts", c.ReportSuperID,
+					c.ReportSubID)
+			}
+		}
+	})
+}
@@ -0,0 +1,157 @@
+package parsers
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Noah-Huppert/crime-map/geo"
+	"github.com/Noah-Huppert/crime-map/models"
+)
+
+// drexelFixtures holds the Drexel reports in the data directory, and the
+// number of crimes each lists in its "Incident(s) Listed." total. The layout
+// of the reports changed slightly each year.
+var drexelFixtures = []struct {
+	file  string
+	count int
+}{
+	{"2010_SRTK_LOG.pdf", 785},
+	{"2011_SRTK_LOG.pdf", 839},
+	{"2012_SRTK_LOG.pdf", 822},
+	{"2013_SRTK_LOG.pdf", 867},
+	{"2014_SRTK_LOG.pdf", 963},
+	{"2015_SRTK_LOG.pdf", 762},
+	{"2016_SRTK_LOG.pdf", 791},
+	{"2017_SRTK_LOG.pdf", 898},
+	{"2017-10-12.pdf", 204},
+}
+
+// TestDrexelFixtures checks each Drexel report in the data directory is
+// detected, and parses fully
+func TestDrexelFixtures(t *testing.T) {
+	for _, fixture := range drexelFixtures {
+		t.Run(fixture.file, func(t *testing.T) {
+			src := NewPdfSource(filepath.Join("..", "data",
+				fixture.file), nil)
+
+			if err := src.Open(); err != nil {
+				t.Fatalf("error opening report: %s", err.Error())
+			}
+			defer src.Close()
+
+			// Detect
+			choice, err := src.Choose("")
+			if err != nil {
+				t.Fatalf("error choosing parser: %s", err.Error())
+			}
+
+			if choice.University != models.UniversityDrexel {
+				t.Fatalf("wrong parser chosen, university: %s",
+					choice.University)
+			}
+
+			store := models.NewMemStore()
+			parser := choice.New(geo.NewGeoCache(store).Begin(store),
+				ModeStrict)
+
+			// Range
+			start, end, err := parser.Range()
+			if err != nil {
+				t.Fatalf("error parsing range: %s", err.Error())
+			}
+
+			// Crimes, Parse checks the number parsed matches the
+			// listed total
+			crimes, err := parser.Parse(context.Background(), 1)
+			if err != nil {
+				t.Fatalf("error parsing crimes: %s", err.Error())
+			}
+
+			if len(crimes) != fixture.count {
+				t.Fatalf("wrong number of crimes, expected: %d, "+
+					"got: %d", fixture.count, len(crimes))
+			}
+
+			count, err := parser.Count()
+			if err != nil {
+				t.Fatalf("error counting crimes: %s", err.Error())
+			} else if int(count) != fixture.count {
+				t.Fatalf("wrong count, expected: %d, got: %d",
+					fixture.count, count)
+			}
+
+			// Crimes were reported during the report's range
+			endDay := end.Add(24 * time.Hour)
+
+			for _, c := range crimes {
+				if c.DateReported.Before(*start) ||
+					!c.DateReported.Before(endDay) {
+					t.Errorf("crime %d-%d reported outside "+
+						"report range, reported: %s",
+						c.ReportSuperID, c.ReportSubID,
+						c.DateReported)
+				}
+
+				if c.DateOccurredEnd.Before(c.DateOccurredStart) {
+					t.Errorf("crime %d-%d occurred range "+
+						"ends before it starts",
+						c.ReportSuperID, c.ReportSubID)
+				}
+			}
+		})
+	}
+}
+
+// TestParseDate checks dates in reports are parsed in the report's location,
+// and dates which do not exist are rejected rather than normalized
+func TestParseDate(t *testing.T) {
+	loc, err := models.UniversityDrexel.Location()
+	if err != nil {
+		t.Fatalf("error loading location: %s", err.Error())
+	}
+
+	tests := []struct {
+		field string
+		valid bool
+	}{
+		{"10/14/17 - SAT at 00:09", true},
+		{"12/31/17 - SUN at 23:59", true},
+		{"02/29/16 - MON at 12:00", true},
+		{"02/29/17 - WED at 12:00", false},
+		{"02/30/17 - THU at 12:00", false},
+		{"04/31/17 - MON at 12:00", false},
+		{"13/45/17 - X at 25:70", false},
+		{"00/14/17 - SAT at 00:09", false},
+		{"10/00/17 - SAT at 00:09", false},
+		{"10/14/17 - SAT at 24:00", false},
+		{"10/14/17 - SAT at 00:60", false},
+		{"10/14/17 at 00:09", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.field, func(t *testing.T) {
+			d, err := parseDate(test.field, loc)
+
+			if !test.valid {
+				if err == nil {
+					t.Fatalf("expected error, got date: %s", d)
+				}
+
+				return
+			} else if err != nil {
+				t.Fatalf("error parsing date: %s", err.Error())
+			}
+
+			got := d.Format("01/02/06 - MON at 15:04")
+			got = strings.Replace(got, "MON",
+				strings.ToUpper(d.Format("Mon")), 1)
+
+			if got != test.field {
+				t.Fatalf("expected %s, got %s", test.field, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,317 @@
+package parsers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Noah-Huppert/crime-map/geo"
+	"github.com/Noah-Huppert/crime-map/models"
+)
+
+// update indicates that golden files should be regenerated from the current
+// parser output, instead of compared with it
+var update = flag.Bool("update", false, "regenerate golden files in "+
+	goldenDir)
+
+// goldenDir is the directory golden files are kept in. Each report in the data
+// directory, and pennDir, has a golden file with the same name, and a .json
+// extension.
+const goldenDir string = "testdata/golden"
+
+// goldenReport is the output of parsing a report, as saved in a golden file
+type goldenReport struct {
+	// University is the institution the report was detected as being
+	// published by
+	University models.UniversityType
+
+	// RangeStart is the start of the date range the report covers
+	RangeStart time.Time
+
+	// RangeEnd is the end of the date range the report covers
+	RangeEnd time.Time
+
+	// Crimes holds the crimes parsed from the report, in order
+	Crimes []goldenCrime
+
+	// ParseErrors holds parse errors about the report as a whole
+	ParseErrors []goldenParseError
+}
+
+// goldenCrime is a Crime, as saved in a golden file. Database IDs are left
+// out, and the location is the raw text from the report. So unrelated changes
+// do not alter golden files.
+type goldenCrime struct {
+	Page              int
+	ReportID          string
+	DateReported      time.Time
+	DateOccurredStart time.Time
+	DateOccurredEnd   time.Time
+	Location          string
+	Incidents         []string
+	Descriptions      []string
+	Remediation       string
+	ParseErrors       []goldenParseError
+}
+
+// goldenParseError is a ParseError, as saved in a golden file
+type goldenParseError struct {
+	Field     string
+	Original  string
+	Corrected string
+	ErrType   models.ParseErrorType
+}
+
+// pennDir is the directory synthetic University of Pennsylvania reports are
+// kept in. They are not real reports, so are not in the data directory.
+const pennDir string = "testdata/penn"
+
+// TestGolden parses each pdf report in the data directory, and the synthetic
+// Penn reports, and compares the crimes and parse errors with the report's
+// golden file. Run:
+//
+//	go test ./parsers/ -run TestGolden -update
+//
+// to regenerate golden files after intentionally changing parser output, then
+// review the diff.
+func TestGolden(t *testing.T) {
+	files := []string{}
+
+	for _, dir := range []string{filepath.Join("..", "data"), pennDir} {
+		matches, err := filepath.Glob(filepath.Join(dir, "*.pdf"))
+		if err != nil {
+			t.Fatalf("error listing reports: %s", err.Error())
+		}
+
+		files = append(files, matches...)
+	}
+
+	for _, file := range files {
+		file := file
+
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			src := NewPdfSource(file, nil)
+
+			if err := src.Open(); err != nil {
+				t.Fatalf("error opening report: %s", err.Error())
+			}
+			defer src.Close()
+
+			choice, err := src.Choose("")
+			if err != nil {
+				t.Fatalf("error choosing parser: %s", err.Error())
+			}
+
+			got, err := parseGolden(choice)
+			if err != nil {
+				t.Fatalf("%s", err.Error())
+			}
+
+			name := strings.TrimSuffix(filepath.Base(file),
+				filepath.Ext(file)) + ".json"
+
+			checkGolden(t, filepath.Join(goldenDir, name), got)
+		})
+	}
+}
+
+// tablesDir is the directory csv and html crime logs used by tests are kept in.
+// Each format of a log has the same name, and the log's columns are described
+// by the mapping file.
+const tablesDir string = "testdata/tables"
+
+// TestGoldenTables parses each format of the crime log in the tables directory,
+// and checks they all produce the same crimes and parse errors. The output is
+// compared with the crime log's golden file.
+func TestGoldenTables(t *testing.T) {
+	mapping, err := LoadTableMapping(filepath.Join(tablesDir,
+		"mapping.toml"))
+	if err != nil {
+		t.Fatalf("error loading table mapping: %s", err.Error())
+	}
+
+	files := []string{"crime_log.csv", "crime_log.html"}
+	outputs := map[string][]byte{}
+
+	for _, name := range files {
+		src, err := NewSource(filepath.Join(tablesDir, name),
+			SourceOptions{Mapping: mapping})
+		if err != nil {
+			t.Fatalf("error creating source for %s: %s", name,
+				err.Error())
+		}
+
+		if err := src.Open(); err != nil {
+			t.Fatalf("error opening %s: %s", name, err.Error())
+		}
+
+		choice, err := src.Choose("")
+		if err != nil {
+			src.Close()
+			t.Fatalf("error choosing parser for %s: %s", name,
+				err.Error())
+		}
+
+		got, err := parseGolden(choice)
+		src.Close()
+		if err != nil {
+			t.Fatalf("%s: %s", name, err.Error())
+		}
+
+		outputs[name] = got
+	}
+
+	// Check formats agree
+	want := outputs[files[0]]
+
+	for _, name := range files[1:] {
+		if !bytes.Equal(outputs[name], want) {
+			t.Fatalf("%s output differs from %s output\n%s:\n%s\n%s:\n%s",
+				name, files[0], files[0], want, name,
+				outputs[name])
+		}
+	}
+
+	checkGolden(t, filepath.Join(goldenDir, "crime_log.json"), want)
+}
+
+// parseGolden parses a report with the chosen parser, and encodes the result
+// as the contents of a golden file. An error is returned if one occurs, nil on
+// success.
+func parseGolden(choice ParserChoice) ([]byte, error) {
+	ctx := context.Background()
+	store := models.NewMemStore()
+
+	parser := choice.New(geo.NewGeoCache(store).Begin(store), ModeStrict)
+
+	// Parse
+	start, end, err := parser.Range()
+	if err != nil {
+		return nil, fmt.Errorf("error parsing range: %s", err.Error())
+	}
+
+	crimes, err := parser.Parse(ctx, 1)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing crimes: %s", err.Error())
+	}
+
+	// Raw locations by ID, none have been located
+	locs, err := store.QueryUnlocatedGeoLocs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error querying for locations: %s",
+			err.Error())
+	}
+
+	raw := map[int]string{}
+	for _, loc := range locs {
+		raw[loc.ID] = loc.Raw
+	}
+
+	// Convert
+	report := goldenReport{
+		University:  choice.University,
+		RangeStart:  *start,
+		RangeEnd:    *end,
+		Crimes:      []goldenCrime{},
+		ParseErrors: newGoldenParseErrors(parser.ParseErrors()),
+	}
+
+	for _, c := range crimes {
+		report.Crimes = append(report.Crimes, goldenCrime{
+			Page: c.Page,
+			ReportID: fmt.Sprintf("%d-%d", c.ReportSuperID,
+				c.ReportSubID),
+			DateReported:      c.DateReported,
+			DateOccurredStart: c.DateOccurredStart,
+			DateOccurredEnd:   c.DateOccurredEnd,
+			Location:          raw[c.GeoLocID],
+			Incidents:         c.Incidents,
+			Descriptions:      c.Descriptions,
+			Remediation:       c.Remediation,
+			ParseErrors:       newGoldenParseErrors(c.ParseErrors),
+		})
+	}
+
+	out, err := json.MarshalIndent(report, "", "\t")
+	if err != nil {
+		return nil, fmt.Errorf("error encoding golden file: %s",
+			err.Error())
+	}
+
+	return append(out, '\n'), nil
+}
+
+// newGoldenParseErrors converts ParseErrors to their golden file form
+func newGoldenParseErrors(pErrs []models.ParseError) []goldenParseError {
+	out := []goldenParseError{}
+
+	for _, pErr := range pErrs {
+		out = append(out, goldenParseError{
+			Field:     pErr.Field,
+			Original:  pErr.Original,
+			Corrected: pErr.Corrected,
+			ErrType:   pErr.ErrType,
+		})
+	}
+
+	return out
+}
+
+// checkGolden compares parser output with the contents of a golden file. Or
+// if the -update flag is provided, saves the output as the golden file.
+func checkGolden(t *testing.T, path string, got []byte) {
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("error making golden directory: %s", err.Error())
+		}
+
+		if err := ioutil.WriteFile(path, got, 0644); err != nil {
+			t.Fatalf("error writing golden file: %s", err.Error())
+		}
+
+		return
+	}
+
+	want, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		t.Fatalf("golden file does not exist, run with -update to "+
+			"create: %s", path)
+	} else if err != nil {
+		t.Fatalf("error reading golden file: %s", err.Error())
+	}
+
+	if bytes.Equal(got, want) {
+		return
+	}
+
+	// Show the first line which differs
+	gotLines := strings.Split(string(got), "\n")
+	wantLines := strings.Split(string(want), "\n")
+
+	for i := 0; i < len(gotLines) || i < len(wantLines); i++ {
+		var gotLine, wantLine string
+
+		if i < len(gotLines) {
+			gotLine = gotLines[i]
+		}
+
+		if i < len(wantLines) {
+			wantLine = wantLines[i]
+		}
+
+		if gotLine != wantLine {
+			t.Fatalf("output differs from %s at line %d, run with "+
+				"-update if the change is intended\n"+
+				"expected: %s\ngot:      %s", path, i+1,
+				wantLine, gotLine)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package parsers
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+
+	"github.com/Noah-Huppert/crime-map/models"
+)
+
+// IncidentMapping normalizes the incidents written in reports to categories of
+// the incident taxonomy, see models.IncidentTaxonomy. Reports from each
+// university, and from different years, describe the same crimes with
+// different text. So incidents are matched with rules.
+type IncidentMapping struct {
+	// Rules are tried in order for each incident. The first rule whose
+	// pattern matches determines the incident's categories.
+	Rules []IncidentRule `mapstructure:"rules"`
+}
+
+// IncidentRule assigns incident categories to the incidents which match a
+// pattern
+type IncidentRule struct {
+	// Pattern is a regular expression, in the syntax accepted by the
+	// regexp package. Matched against incidents with surrounding spaces
+	// removed. Use (?i) to match case insensitively.
+	Pattern string `mapstructure:"pattern"`
+
+	// Categories holds the names of the categories matching incidents
+	// belong to. May be empty if matching incidents belong to no
+	// category, ex: a report of a fire. These incidents are still
+	// considered mapped.
+	Categories []string `mapstructure:"categories"`
+
+	// expr is the compiled Pattern. Nil until the mapping is validated.
+	expr *regexp.Regexp
+}
+
+// LoadIncidentMapping reads an IncidentMapping from a toml, yaml or json file.
+// An error is returned if one occurs, or the mapping is invalid. Nil on
+// success.
+func LoadIncidentMapping(path string) (*IncidentMapping, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("error reading incident mapping file: %s",
+			err.Error())
+	}
+
+	mapping := &IncidentMapping{}
+	if err := v.Unmarshal(mapping); err != nil {
+		return nil, fmt.Errorf("error parsing incident mapping file: %s",
+			err.Error())
+	}
+
+	if errs := mapping.Validate(); len(errs) > 0 {
+		errsArr := []string{}
+		for _, err := range errs {
+			errsArr = append(errsArr, err.Error())
+		}
+
+		return nil, fmt.Errorf("invalid incident mapping: %s",
+			strings.Join(errsArr, ", "))
+	}
+
+	return mapping, nil
+}
+
+// Validate checks each rule's pattern is a valid regular expression, and that
+// each category is in the incident taxonomy. Patterns are compiled so the
+// mapping can be used. The problems found are returned, empty if valid.
+func (m *IncidentMapping) Validate() []error {
+	errs := []error{}
+
+	if len(m.Rules) == 0 {
+		errs = append(errs, errors.New("at least 1 rule must be set"))
+	}
+
+	for i := range m.Rules {
+		rule := &m.Rules[i]
+
+		if len(rule.Pattern) == 0 {
+			errs = append(errs, fmt.Errorf("rules[%d].pattern must "+
+				"be set", i))
+		} else if expr, err := regexp.Compile(rule.Pattern); err != nil {
+			errs = append(errs, fmt.Errorf("rules[%d].pattern: %s",
+				i, err.Error()))
+		} else {
+			rule.expr = expr
+		}
+
+		for _, category := range rule.Categories {
+			if _, err := models.LookupIncidentCategory(category); err != nil {
+				errs = append(errs, fmt.Errorf("rules[%d]."+
+					"categories: %s", i, err.Error()))
+			}
+		}
+	}
+
+	return errs
+}
+
+// Categorize finds the categories of a crime's incidents. The names of the
+// categories are returned, sorted without duplicates. Followed by the
+// incidents no rule matched. The mapping must be valid, see Validate.
+func (m IncidentMapping) Categorize(incidents []string) ([]string, []string) {
+	categories := []string{}
+	unmapped := []string{}
+
+	seen := map[string]bool{}
+
+	for _, incident := range incidents {
+		rule := m.match(incident)
+		if rule == nil {
+			unmapped = append(unmapped, incident)
+			continue
+		}
+
+		for _, category := range rule.Categories {
+			if !seen[category] {
+				seen[category] = true
+				categories = append(categories, category)
+			}
+		}
+	}
+
+	sort.Strings(categories)
+
+	return categories, unmapped
+}
+
+// match returns the first rule whose pattern matches the incident, nil if
+// none does
+func (m IncidentMapping) match(incident string) *IncidentRule {
+	incident = strings.TrimSpace(incident)
+
+	for i := range m.Rules {
+		if m.Rules[i].expr.MatchString(incident) {
+			return &m.Rules[i]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,236 @@
+package parsers
+
+import (
+	"reflect"
+	"testing"
+)
+
+// newTestIncidentMapping creates a valid IncidentMapping with the rules. The
+// test fails if the rules are invalid.
+func newTestIncidentMapping(t *testing.T, rules []IncidentRule) IncidentMapping {
+	t.Helper()
+
+	mapping := IncidentMapping{Rules: rules}
+
+	if errs := mapping.Validate(); len(errs) > 0 {
+		t.Fatalf("invalid incident mapping: %v", errs)
+	}
+
+	return mapping
+}
+
+// TestIncidentMappingCategorize checks incidents are assigned the categories of
+// the first rule which matches them, and unmatched incidents are returned
+func TestIncidentMappingCategorize(t *testing.T) {
+	mapping := newTestIncidentMapping(t, []IncidentRule{
+		{
+			Pattern:    `(?i)^AUTO THEFT-`,
+			Categories: []string{"Motor Vehicle Theft"},
+		},
+		{
+			Pattern:    `(?i)THEFT`,
+			Categories: []string{"Larceny-Theft"},
+		},
+		{
+			Pattern: `^ASSAULT-AGG .*DOMESTIC`,
+			Categories: []string{"Domestic Violence",
+				"Aggravated Assault"},
+		},
+		{
+			Pattern:    `^ASSAULT-AGG `,
+			Categories: []string{"Aggravated Assault"},
+		},
+		{
+			Pattern:    `^PRIORITY [0-9]+-REPORT OF A FIRE$`,
+			Categories: []string{},
+		},
+	})
+
+	tests := []struct {
+		name       string
+		incidents  []string
+		categories []string
+		unmapped   []string
+	}{
+		{
+			name:       "no incidents",
+			incidents:  []string{},
+			categories: []string{},
+			unmapped:   []string{},
+		},
+		{
+			name:       "one rule",
+			incidents:  []string{"THEFT-Bicycles"},
+			categories: []string{"Larceny-Theft"},
+			unmapped:   []string{},
+		},
+		{
+			// Earlier rules take precedence, AUTO THEFT also
+			// matches the theft rule
+			name:       "first rule",
+			incidents:  []string{"AUTO THEFT-Passenger Vehicle"},
+			categories: []string{"Motor Vehicle Theft"},
+			unmapped:   []string{},
+		},
+		{
+			name:       "case insensitive",
+			incidents:  []string{"Theft from Building"},
+			categories: []string{"Larceny-Theft"},
+			unmapped:   []string{},
+		},
+		{
+			name:       "case sensitive",
+			incidents:  []string{"assault-agg knife"},
+			categories: []string{},
+			unmapped:   []string{"assault-agg knife"},
+		},
+		{
+			name:       "surrounding spaces",
+			incidents:  []string{"  PRIORITY 2-REPORT OF A FIRE \n"},
+			categories: []string{},
+			unmapped:   []string{},
+		},
+		{
+			name: "many categories sorted",
+			incidents: []string{
+				"ASSAULT-AGG ASSAULT - DOMESTIC"},
+			categories: []string{"Aggravated Assault",
+				"Domestic Violence"},
+			unmapped: []string{},
+		},
+		{
+			name: "duplicate categories",
+			incidents: []string{"THEFT-Bicycles",
+				"ASSAULT-AGG ASSAULT - DOMESTIC",
+				"RETAIL THEFT", "ASSAULT-AGG KNIFE"},
+			categories: []string{"Aggravated Assault",
+				"Domestic Violence", "Larceny-Theft"},
+			unmapped: []string{},
+		},
+		{
+			// Incidents matching a rule with no categories are
+			// mapped, others are not
+			name: "unmapped",
+			incidents: []string{"PRIORITY 1-REPORT OF A FIRE",
+				"HARASSMENT", "THEFT-Laptop", "MISSING PERSON"},
+			categories: []string{"Larceny-Theft"},
+			unmapped:   []string{"HARASSMENT", "MISSING PERSON"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			categories, unmapped := mapping.Categorize(test.incidents)
+
+			if !reflect.DeepEqual(categories, test.categories) {
+				t.Errorf("expected categories %v, got %v",
+					test.categories, categories)
+			}
+
+			if !reflect.DeepEqual(unmapped, test.unmapped) {
+				t.Errorf("expected unmapped %v, got %v",
+					test.unmapped, unmapped)
+			}
+		})
+	}
+}
+
+// TestIncidentMappingValidate checks invalid rules are reported
+func TestIncidentMappingValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []IncidentRule
+		errs  int
+	}{
+		{
+			name:  "no rules",
+			rules: []IncidentRule{},
+			errs:  1,
+		},
+		{
+			name: "empty pattern",
+			rules: []IncidentRule{
+				{Pattern: "", Categories: []string{"Fraud"}},
+			},
+			errs: 1,
+		},
+		{
+			name: "invalid pattern",
+			rules: []IncidentRule{
+				{Pattern: "(THEFT", Categories: []string{"Fraud"}},
+			},
+			errs: 1,
+		},
+		{
+			name: "unknown categories",
+			rules: []IncidentRule{
+				{Pattern: "^THEFT", Categories: []string{"Theft",
+					"Larceny-Theft", "Larceny"}},
+			},
+			errs: 2,
+		},
+		{
+			name: "valid",
+			rules: []IncidentRule{
+				{Pattern: "^THEFT", Categories: []string{
+					"Larceny-Theft"}},
+				{Pattern: "FIRE", Categories: []string{}},
+			},
+			errs: 0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mapping := IncidentMapping{Rules: test.rules}
+
+			if errs := mapping.Validate(); len(errs) != test.errs {
+				t.Fatalf("expected %d errors, got: %v", test.errs,
+					errs)
+			}
+		})
+	}
+}
+
+// TestIncidentMappingFile checks the incident mapping file in the repository
+// is valid, and maps incidents written by each university
+func TestIncidentMappingFile(t *testing.T) {
+	mapping, err := LoadIncidentMapping("../incidents.toml")
+	if err != nil {
+		t.Fatalf("error loading incident mapping: %s", err.Error())
+	}
+
+	tests := []struct {
+		incident   string
+		categories []string
+	}{
+		{"HOMICIDE-NEGLIGENT MANSLAUGHTER",
+			[]string{"Manslaughter by Negligence"}},
+		{"HOMICIDE-MURDER",
+			[]string{"Murder and Non-Negligent Manslaughter"}},
+		{"AUTO THEFT-Passenger Vehicle",
+			[]string{"Motor Vehicle Theft"}},
+		{"THEFT-Bicycles", []string{"Larceny-Theft"}},
+		{"Theft from Building", []string{"Larceny-Theft"}},
+		{"BURGLARY-RESIDENTIAL", []string{"Burglary"}},
+		{"POLICY VIOLATION-ALCOHOL", []string{"Liquor Law Violation"}},
+		{"POLICY VIOLATION-DRUGS", []string{"Drug Law Violation"}},
+		{"PRIORITY 2-REPORT OF A FIRE", []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.incident, func(t *testing.T) {
+			categories, unmapped := mapping.Categorize(
+				[]string{test.incident})
+
+			if len(unmapped) != 0 {
+				t.Fatalf("expected incident to be mapped")
+			}
+
+			if !reflect.DeepEqual(categories, test.categories) {
+				t.Fatalf("expected categories %v, got %v",
+					test.categories, categories)
+			}
+		})
+	}
+}
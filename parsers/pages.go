@@ -0,0 +1,76 @@
+package parsers
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Noah-Huppert/crime-map/pdf"
+)
+
+// pageBuffer wraps a PageSource, so pages read while searching a report for
+// its header can still be parsed afterwards. Implements pdf.PageSource.
+type pageBuffer struct {
+	// pages provides the pages of the report, one at a time
+	pages pdf.PageSource
+
+	// buffered holds pages which were read by Scan, but have not been
+	// returned by Next yet
+	buffered []*pdf.Page
+}
+
+// newPageBuffer creates a pageBuffer which reads the provided pages
+func newPageBuffer(pages pdf.PageSource) *pageBuffer {
+	return &pageBuffer{
+		pages:    pages,
+		buffered: []*pdf.Page{},
+	}
+}
+
+// Scan calls fn with the text of each text run, with surrounding spaces
+// removed, until fn returns true. Pages already buffered are scanned first,
+// then more pages are read and buffered. True is returned if fn returned true,
+// false if there were no more pages.
+//
+// An error is returned if fn returns one, or one occurs reading pages. Nil on
+// success.
+func (b *pageBuffer) Scan(fn func(text string) (bool, error)) (bool, error) {
+	for i := 0; ; i++ {
+		// Read another page
+		if i == len(b.buffered) {
+			page, err := b.pages.Next()
+			if err == io.EOF {
+				return false, nil
+			} else if err != nil {
+				return false, fmt.Errorf("error reading "+
+					"page: %s", err.Error())
+			}
+
+			b.buffered = append(b.buffered, page)
+		}
+
+		for _, run := range b.buffered[i].Runs {
+			found, err := fn(strings.TrimSpace(run.Text))
+			if err != nil || found {
+				return found, err
+			}
+		}
+	}
+}
+
+// Next implements pdf.PageSource.Next. Pages read by Scan are returned first.
+func (b *pageBuffer) Next() (*pdf.Page, error) {
+	if len(b.buffered) > 0 {
+		page := b.buffered[0]
+		b.buffered = b.buffered[1:]
+
+		return page, nil
+	}
+
+	return b.pages.Next()
+}
+
+// Diagnostics implements pdf.PageSource.Diagnostics
+func (b pageBuffer) Diagnostics() pdf.Diagnostics {
+	return b.pages.Diagnostics()
+}
@@ -1,55 +1,189 @@
 package parsers
 
 import (
-	"errors"
-	"strings"
+	"context"
+	"fmt"
 	"time"
 
 	"github.com/Noah-Huppert/crime-map/models"
 )
 
-// Parser provides methods for converting a stream of pdf text fields to Crime
-// structs. This allows multiple different formats of reports to parsed.
+// Parser provides methods for converting the records of a report to Crime
+// structs. This allows multiple different formats of reports to parsed. Each
+// implementation is made available with Register.
+//
+// Reports are read from the source the Parser was created with, ex: a
+// pdf.PageSource which provides a pdf's pages one at a time as they are read,
+// or the rows of a crime log. Range may be called before Parse, it only reads
+// as much of the report as it needs to find the range, ex: its header.
 type Parser interface {
-	// Parse takes the text fields saved in the implementing struct and
-	// parses them into a slice of Crime structs.
+	// Parse reads the report's remaining records from the source and
+	// parses them into a slice of Crime structs. Pages read by Range are
+	// parsed too.
 	//
 	// A Report ID is provided to the Parse method. Which is the ID of the
-	// Report which these crimes belong to.
+	// Report which these crimes belong to. The context is used to cancel
+	// any database queries made while parsing.
 	//
 	// Additionally an error is returned, nil on success.
-	Parse(reportID int) ([]models.Crime, error)
+	Parse(ctx context.Context, reportID int) ([]models.Crime, error)
 
 	// Range returns the date range which the report covers crimes for.
 	// Start time, then end time. Along with an error if one occurs, or nil
 	// on success.
 	//
-	// The parse method is expected to be called before Range(). And an
-	// error will be returned if it has not been.
+	// Range may be called before Parse, so the report can be saved before
+	// its crimes are parsed. The range is read from the report's header,
+	// or its records, not from parsed crimes.
 	Range() (*time.Time, *time.Time, error)
 
 	// Count returns the number of Crime models parsed from a report. An
 	// error is returned if one occurs. Nil on success.
 	Count() (uint, error)
+
+	// ParseErrors returns the problems found in the report as a whole,
+	// rather than in one crime. Ex: records which were skipped. Only
+	// recorded when parsing in ModeLenient.
+	ParseErrors() []models.ParseError
+}
+
+// Mode determines how a Parser handles problems in a report
+type Mode string
+
+const (
+	// ModeStrict indicates that parsing stops at the first problem in a
+	// report
+	ModeStrict Mode = "strict"
+
+	// ModeLenient indicates that problems which only affect part of a
+	// report are recorded as ParseErrors, and parsing continues. Records
+	// which can not be parsed are skipped.
+	ModeLenient Mode = "lenient"
+)
+
+// recordError is an error which stopped one record of a report from being
+// parsed. When parsing in ModeLenient the record is skipped, and recorded as a
+// ParseError of the type errType.
+type recordError struct {
+	// errType classifies the problem
+	errType models.ParseErrorType
+
+	// err describes the problem
+	err error
 }
 
-// determineUniversity figures out which University a crime report was
-// published from. By reading in the text fields present in a report. And
-// searching for the first occurrence of a university name.
+// newRecordError creates a recordError, the message is formatted like
+// fmt.Errorf
+func newRecordError(errType models.ParseErrorType, format string, args ...interface{}) error {
+	return recordError{
+		errType: errType,
+		err:     fmt.Errorf(format, args...),
+	}
+}
+
+// Error implements error
+func (e recordError) Error() string {
+	return e.err.Error()
+}
+
+// anomalies handles problems found while parsing a report, which only affect
+// part of the report. In ModeStrict problems stop parsing. In ModeLenient they
+// are recorded as ParseErrors about the report, and parsing continues.
+type anomalies struct {
+	// mode determines if problems stop parsing
+	mode Mode
+
+	// errs holds the problems recorded in ModeLenient
+	errs []models.ParseError
+}
+
+// newAnomalies creates an anomalies which handles problems as the mode
+// specifies
+func newAnomalies(mode Mode) *anomalies {
+	return &anomalies{
+		mode: mode,
+		errs: []models.ParseError{},
+	}
+}
+
+// add handles a problem, described by err. In ModeStrict err is returned. In
+// ModeLenient pErr is recorded and nil is returned.
+func (a *anomalies) add(pErr models.ParseError, err error) error {
+	if a.mode != ModeLenient {
+		return err
+	}
+
+	a.errs = append(a.errs, pErr)
+
+	return nil
+}
+
+// skip handles an error which stopped a record from being parsed. Original is
+// the text of the record. If the error is a recordError and parsing in
+// ModeLenient, the record is recorded as skipped and nil is returned.
+// Otherwise err is returned, ex: for database errors.
+func (a *anomalies) skip(original string, err error) error {
+	rErr, ok := err.(recordError)
+	if !ok {
+		return err
+	}
+
+	return a.add(models.ParseError{
+		Field:     "record",
+		Original:  original,
+		Corrected: fmt.Sprintf("skipped: %s", rErr.Error()),
+		ErrType:   rErr.errType,
+	}, err)
+}
+
+// setOccurred sets the Crime.DateOccurredStart and Crime.DateOccurredEnd
+// fields. Crime.DateReported must already be set. Field is the original text
+// of the range.
 //
-// A models.UniversityType is returned along with an error. Which will be nil
-// on success.
-func determineUniversity(fields []string) (models.UniversityType, error) {
-	// Attempt to find univ name in fields
-	for _, field := range fields {
-		// Check
-		if strings.Contains(field, string(models.UniversityDrexel)) {
-			// Success
-			return models.UniversityDrexel, nil
+// If the end of the range is before the start the end is corrected, and the
+// correction is recorded as a ParseError on the Crime. Corrections are tried
+// in order:
+//
+//  1. Adding 12 hours to the end, for ends written in 12 hour time
+//  2. Adding a year to the end, for ends written with the wrong year. Only if
+//     the end is on an earlier day than the start, and the corrected end is
+//     not after the date reported.
+//  3. Using the start as the end, for ends which are placeholders, ex:
+//     01/01/00, or can not otherwise be corrected
+func setOccurred(c *models.Crime, field string, start, end time.Time) {
+	// Check start date is after end date
+	if start.After(end) {
+		// If so, try adding 12 hours to end date
+		fixedEnd := end.Add(time.Hour * time.Duration(12))
+
+		// Then a year, if the end is on an earlier day
+		if start.After(fixedEnd) {
+			startYear, startMonth, startDay := start.Date()
+			endYear, endMonth, endDay := end.Date()
+
+			fixedEnd = end.AddDate(1, 0, 0)
+
+			if (startYear == endYear && startMonth == endMonth &&
+				startDay == endDay) || start.After(fixedEnd) ||
+				fixedEnd.After(c.DateReported) {
+				// Otherwise the end is not known
+				fixedEnd = start
+			}
 		}
+
+		// Note parse error
+		c.ParseErrors = append(c.ParseErrors, models.ParseError{
+			Field:    "date_occurred",
+			Original: field,
+			Corrected: fmt.Sprintf("%s - %s", start.String(),
+				fixedEnd.String()),
+			ErrType: models.TypeBadRangeEnd,
+		})
+
+		end = fixedEnd
 	}
 
-	// If none found
-	return models.UniversityErr, errors.New("error determining university," +
-		" no field with university name found")
+	// Save
+	c.DateOccurredStart = start
+	c.DateOccurredEnd = end
 }
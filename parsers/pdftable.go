@@ -0,0 +1,311 @@
+package parsers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Noah-Huppert/crime-map/geo"
+	"github.com/Noah-Huppert/crime-map/models"
+	"github.com/Noah-Huppert/crime-map/pdf"
+)
+
+// pdfTable describes the table a school's pdf reports list crimes in, and how
+// its records are converted into Crimes
+type pdfTable struct {
+	// layout describes the table's columns, and the text around it
+	layout pdf.TableLayout
+
+	// countLabel is the label printed before the number of crimes a report
+	// lists
+	countLabel string
+
+	// missingField returns the name of the first required column which a
+	// record has no value for. Empty if all are present.
+	missingField func(record pdf.TableRecord) string
+
+	// parseRecord converts one record into a Crime. Problems which only
+	// affect the record are returned as recordErrors, so the record can
+	// be skipped when parsing leniently.
+	parseRecord func(ctx context.Context, record pdf.TableRecord) (*models.Crime, error)
+}
+
+// pdfParser holds the state shared by parsers of pdf reports, and reads their
+// header and table. Embedded by each school's Parser, which provides its
+// pdfTable.
+type pdfParser struct {
+	// geoCache is used to cache GeoLoc queries to the database. GeoLocs
+	// are inserted in the same transaction as the report.
+	geoCache *geo.GeoCacheTx
+
+	// pages provides the pages of the pdf we are parsing, one at a time.
+	// Pages read by Range are kept for Parse.
+	pages *pageBuffer
+
+	// parsedCrimes indicates if a report's crime models have been parsed
+	// out yet
+	parsedCrimes bool
+
+	// parsedRange indicates if a report's date range has been parsed out
+	// yet
+	parsedRange bool
+
+	// crimes holds the Crimes which were parsed from a report, empty if
+	// parsedCrimes == false
+	crimes []models.Crime
+
+	// anomalies handles problems in the report, as the parse mode
+	// specifies
+	anomalies *anomalies
+
+	// startRange holds the start of the time range which the report covers
+	startRange *time.Time
+
+	// endRange holds the end of the time range which the report covers
+	endRange *time.Time
+
+	// location is the location which report times are written in
+	location *time.Location
+}
+
+// newPdfParser creates a pdfParser which reads the provided pages. Problems in
+// the report are handled as the mode specifies. Report times are interpreted
+// in the provided location.
+func newPdfParser(geoCache *geo.GeoCacheTx, pages pdf.PageSource, mode Mode, location *time.Location) pdfParser {
+	return pdfParser{
+		geoCache:     geoCache,
+		pages:        newPageBuffer(pages),
+		parsedCrimes: false,
+		parsedRange:  false,
+		crimes:       []models.Crime{},
+		anomalies:    newAnomalies(mode),
+		location:     location,
+	}
+}
+
+// Count implements the Count method for Parser
+func (p pdfParser) Count() (uint, error) {
+	// Check if not parsed yet
+	if !p.parsedCrimes {
+		return 0, ErrReportNotParsed
+	}
+
+	return uint(len(p.crimes)), nil
+}
+
+// ParseErrors implements the ParseErrors method for Parser
+func (p pdfParser) ParseErrors() []models.ParseError {
+	return p.anomalies.errs
+}
+
+// findRange reads pages until the date range the report covers is found.
+// Which is usually in the first page's header. Pages read are kept for Parse.
+//
+// parseHeader is called with each text run. If the text is the header range
+// it must set the pdfParser.startRange and pdfParser.endRange fields, and
+// return true. An error is returned if one occurs, or the range is not found.
+// Nil on success.
+func (p *pdfParser) findRange(parseHeader func(text string) (bool, error)) (*time.Time, *time.Time, error) {
+	// Check if already parsed range
+	if p.parsedRange {
+		return p.startRange, p.endRange, nil
+	}
+
+	// Loop through text until we find the header date range
+	found, err := p.pages.Scan(parseHeader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// If looped through all pages and not found, error
+	if !found {
+		return nil, nil, errors.New("error finding header date " +
+			"range, not found")
+	}
+
+	// Success
+	p.parsedRange = true
+	return p.startRange, p.endRange, nil
+}
+
+// parseTable implements the Parse method for Parser. It groups the text of
+// each page into the table's records, one page at a time so only one page is
+// held in memory, and converts them into Crimes.
+func (p *pdfParser) parseTable(ctx context.Context, reportID int, table pdfTable) ([]models.Crime, error) {
+	// Check if already parsed
+	if p.parsedCrimes {
+		return p.crimes, ErrReportParsed
+	}
+
+	tables := pdf.NewTableReader(table.layout)
+
+	// count holds the number of crimes the report lists, -1 until found
+	count := -1
+
+	// countErr holds the error which occurred finding count, nil if none
+	var countErr error
+
+	for {
+		// Stop if canceled
+		if err := ctx.Err(); err != nil {
+			return p.crimes, err
+		}
+
+		page, err := p.pages.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return p.crimes, fmt.Errorf("error reading page: %s",
+				err.Error())
+		}
+
+		records, unassigned := tables.AddRuns(page.Runs)
+		for _, run := range unassigned {
+			err = p.anomalies.add(models.ParseError{
+				Field:     fmt.Sprintf("page #%d", page.Number),
+				Original:  run.Text,
+				Corrected: "ignored",
+				ErrType:   models.TypeUnknownField,
+			}, fmt.Errorf("error parsing field on page #%d, "+
+				"unknown value: %s", page.Number, run.Text))
+			if err != nil {
+				return p.crimes, err
+			}
+		}
+
+		if err = p.parseRecords(ctx, reportID, table, records); err != nil {
+			return p.crimes, err
+		}
+
+		// Find listed count, on last page
+		pageCount, found, err := listedCount(page.Runs,
+			table.countLabel)
+		if err != nil {
+			countErr = err
+		} else if found {
+			count = pageCount
+		}
+	}
+
+	err := p.parseRecords(ctx, reportID, table, tables.Flush())
+	if err != nil {
+		return p.crimes, err
+	}
+
+	// Check count matches listed count. Unless pages could not be read, in
+	// which case their crimes, or the count, are missing.
+	if !p.pages.Diagnostics().Partial() {
+		if err := checkListedCount(p.anomalies, count, countErr,
+			len(p.crimes)); err != nil {
+			return p.crimes, err
+		}
+	}
+
+	// Success
+	p.parsedCrimes = true
+	return p.crimes, nil
+}
+
+// parseRecords converts records in a report's table into Crimes, and adds
+// them to the pdfParser.crimes field. Records which can not be parsed are
+// skipped if parsing leniently. An error is returned if one occurs, nil on
+// success.
+func (p *pdfParser) parseRecords(ctx context.Context, reportID int, table pdfTable, records []pdf.TableRecord) error {
+	for _, record := range records {
+		// Records next to pages which could not be read may be
+		// missing fields, skip them
+		if len(table.missingField(record)) > 0 &&
+			p.pages.Diagnostics().Partial() {
+			continue
+		}
+
+		c, err := table.parseRecord(ctx, record)
+		if err != nil {
+			err = p.anomalies.skip(record.String(), err)
+			if err != nil {
+				return fmt.Errorf("error parsing record, %s, "+
+					"err: %s", record, err.Error())
+			}
+
+			continue
+		}
+
+		c.ReportID = reportID
+		p.crimes = append(p.crimes, *c)
+	}
+
+	return nil
+}
+
+// checkListedCount checks the number of crimes parsed from a report matches
+// the number the report lists. Listed is -1 if the listed number was not found,
+// and listedErr is the error which occurred finding it, nil if none. A mismatch
+// is handled by the anomalies, see anomalies.add. An error is returned if one
+// occurs, nil on success.
+func checkListedCount(a *anomalies, listed int, listedErr error, parsed int) error {
+	var err error
+
+	if listedErr != nil {
+		err = fmt.Errorf("error parsing number of listed crimes: %s",
+			listedErr.Error())
+	} else if listed < 0 {
+		err = errors.New("error parsing number of listed crimes: " +
+			"listed count label not found")
+	} else if listed != parsed {
+		err = fmt.Errorf("number of listed crimes and number of "+
+			"crimes parsed does not match: listed: %d, parsed: %d",
+			listed, parsed)
+	} else {
+		return nil
+	}
+
+	// Listed number is unknown if not found
+	original := ""
+	if listedErr == nil && listed >= 0 {
+		original = strconv.Itoa(listed)
+	}
+
+	return a.add(models.ParseError{
+		Field:     "crimes_count",
+		Original:  original,
+		Corrected: strconv.Itoa(parsed),
+		ErrType:   models.TypeCountMismatch,
+	}, err)
+}
+
+// listedCount finds the number of crimes a report says it lists, in a page's
+// text runs. Which is printed on the same line as the provided label. The
+// count is returned along with true if the label is on the page. An error is
+// returned if one occurs, nil on success.
+func listedCount(runs []pdf.TextRun, label string) (int, bool, error) {
+	for _, line := range pdf.GroupLines(runs, 0) {
+		// Find line with label
+		found := false
+		for _, text := range line.Texts() {
+			if strings.TrimSpace(text) == label {
+				found = true
+			}
+		}
+
+		if !found {
+			continue
+		}
+
+		// Find count
+		for _, text := range line.Texts() {
+			count, err := strconv.Atoi(strings.TrimSpace(text))
+			if err == nil {
+				return count, true, nil
+			}
+		}
+
+		return 0, true, errors.New("count not found on same line as " +
+			"label")
+	}
+
+	return 0, false, nil
+}
@@ -0,0 +1,316 @@
+package parsers
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Noah-Huppert/crime-map/date"
+	"github.com/Noah-Huppert/crime-map/geo"
+	"github.com/Noah-Huppert/crime-map/models"
+	"github.com/Noah-Huppert/crime-map/pdf"
+)
+
+// PennUName holds the University of Pennsylvania's name
+const PennUName string = "University of Pennsylvania"
+
+// PennParserName is the name PennParser is registered with
+const PennParserName string = "penn"
+
+// pennHeaderRangeExpr is the regexp used to match the date range in a Penn
+// report's header
+var pennHeaderRangeExpr *regexp.Regexp = regexp.MustCompile("^Incidents Reported ([0-9]{2})/([0-9]{2})/([0-9]{4}) - ([0-9]{2})/([0-9]{2})/([0-9]{4})$")
+
+// pennDateExpr is the regexp used to match a date and 24 hour time in a Penn
+// report
+var pennDateExpr *regexp.Regexp = regexp.MustCompile("^([0-9]{2})/([0-9]{2})/([0-9]{4}) ([0-9]{2}):([0-9]{2})$")
+
+// pennFieldLabelCrimeCount is the label printed before the number of crimes
+// a Penn report lists
+const pennFieldLabelCrimeCount string = "Total Incidents:"
+
+// Column names of the Penn report table
+const (
+	pennColumnIncidentID  string = "incident_id"
+	pennColumnReported    string = "reported"
+	pennColumnOccurred    string = "occurred"
+	pennColumnType        string = "type"
+	pennColumnLocation    string = "location"
+	pennColumnDisposition string = "disposition"
+)
+
+// pennLayout describes the table Penn reports list crimes in. Each page has one
+// header line of labels, and each crime's values are drawn below them.
+var pennLayout pdf.TableLayout = pdf.TableLayout{
+	Columns: []pdf.TableColumn{
+		pdf.TableColumn{
+			Name:   pennColumnIncidentID,
+			Labels: []string{"Incident #"},
+		},
+		pdf.TableColumn{
+			Name:   pennColumnReported,
+			Labels: []string{"Reported"},
+		},
+		pdf.TableColumn{
+			Name:   pennColumnOccurred,
+			Labels: []string{"Occurred"},
+		},
+		pdf.TableColumn{
+			Name:   pennColumnType,
+			Labels: []string{"Incident Type"},
+		},
+		pdf.TableColumn{
+			Name:   pennColumnLocation,
+			Labels: []string{"Location"},
+		},
+		pdf.TableColumn{
+			Name:   pennColumnDisposition,
+			Labels: []string{"Disposition"},
+		},
+	},
+	Furniture: []*regexp.Regexp{
+		// Header
+		pennHeaderRangeExpr,
+		regexp.MustCompile("^" + PennUName + "$"),
+		regexp.MustCompile("^Division of Public Safety$"),
+		regexp.MustCompile("^Daily Crime Log$"),
+
+		// Footer
+		regexp.MustCompile("^Page [0-9]+ of [0-9]+$"),
+
+		// Count of crimes, parsed separately
+		regexp.MustCompile("^" + pennFieldLabelCrimeCount + "$"),
+	},
+}
+
+// PennParser implements the Parser interface for University of Pennsylvania
+// daily crime logs
+type PennParser struct {
+	pdfParser
+}
+
+func init() {
+	Register(Registration{
+		Name:       PennParserName,
+		University: models.UniversityPenn,
+		Detect:     detectPenn,
+		New: func(geoCache *geo.GeoCacheTx, pages pdf.PageSource, mode Mode, location *time.Location) Parser {
+			return NewPennParser(geoCache, pages, mode, location)
+		},
+	})
+}
+
+// detectPenn scores how likely a report is a University of Pennsylvania
+// report, from the text on its first page
+func detectPenn(fields []string) float64 {
+	return detectReport(fields, PennUName, pennHeaderRangeExpr,
+		pennLayout)
+}
+
+// NewPennParser creates a new PennParser instance which parses the provided
+// pages. Report times are interpreted in the provided location.
+func NewPennParser(geoCache *geo.GeoCacheTx, pages pdf.PageSource, mode Mode, location *time.Location) *PennParser {
+	return &PennParser{
+		pdfParser: newPdfParser(geoCache, pages, mode, location),
+	}
+}
+
+// Range implements the Range method for Parser. It reads pages until the date
+// range in the report header is found. Pages read are kept for Parse.
+func (p *PennParser) Range() (*time.Time, *time.Time, error) {
+	return p.findRange(func(text string) (bool, error) {
+		matches := pennHeaderRangeExpr.FindStringSubmatch(text)
+		if matches == nil {
+			return false, nil
+		}
+
+		start, err := pennHeaderDate(matches[1:4], p.location)
+		if err != nil {
+			return false, fmt.Errorf("error parsing header start "+
+				"date: %s", err.Error())
+		}
+
+		end, err := pennHeaderDate(matches[4:7], p.location)
+		if err != nil {
+			return false, fmt.Errorf("error parsing header end "+
+				"date: %s", err.Error())
+		}
+
+		p.startRange = start
+		p.endRange = end
+
+		return true, nil
+	})
+}
+
+// Parse implements the Parse method for Parser. For the style of report the
+// University of Pennsylvania releases.
+func (p *PennParser) Parse(ctx context.Context, reportID int) ([]models.Crime, error) {
+	return p.parseTable(ctx, reportID, pdfTable{
+		layout:       pennLayout,
+		countLabel:   pennFieldLabelCrimeCount,
+		missingField: pennMissingField,
+		parseRecord:  p.parseRecord,
+	})
+}
+
+// parseRecord converts one record in a report's table into a Crime. An error
+// is returned if one occurs, nil on success.
+func (p *PennParser) parseRecord(ctx context.Context, record pdf.TableRecord) (*models.Crime, error) {
+	c := &models.Crime{
+		// Pages are counted from 0
+		Page: int(record.Page) - 1,
+
+		// Penn reports do not describe crimes
+		Descriptions: []string{},
+	}
+
+	// Check all fields present
+	if missing := pennMissingField(record); len(missing) > 0 {
+		return nil, newRecordError(models.TypeTruncatedRecord,
+			"%s field missing", missing)
+	}
+
+	// Incident ID, year then number, ex: 17-01234
+	field := record.Get(pennColumnIncidentID)
+	parts := strings.Split(field, "-")
+
+	if len(parts) != 2 {
+		return nil, newRecordError(models.TypeBadReportID,
+			"incident # field has incorrect number of parts, "+
+				"field: %s, parts: %d, expected parts: 2",
+			field, len(parts))
+	}
+
+	id, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return nil, newRecordError(models.TypeBadReportID,
+			"error parsing incident # year into uint: %s",
+			err.Error())
+	}
+	c.ReportSuperID = uint(id)
+
+	id, err = strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return nil, newRecordError(models.TypeBadReportID,
+			"error parsing incident # number into uint: %s",
+			err.Error())
+	}
+	c.ReportSubID = uint(id)
+
+	// Date reported
+	d, err := pennParseDate(record.Get(pennColumnReported), p.location)
+	if err != nil {
+		return nil, newRecordError(models.TypeBadDate,
+			"error parsing reported field: %s", err.Error())
+	}
+	c.DateReported = *d
+
+	// Date occurred. The start is drawn on the first line, and the end
+	// on the second. If there is no second line the crime occurred at
+	// one time.
+	lines := record.Lines(pennColumnOccurred)
+	if len(lines) > 2 {
+		return nil, newRecordError(models.TypeBadDate,
+			"occurred field has too many lines, field: %s, "+
+				"expected at most 2",
+			record.Get(pennColumnOccurred))
+	}
+
+	start, err := pennParseDate(lines[0], p.location)
+	if err != nil {
+		return nil, newRecordError(models.TypeBadDate,
+			"error parsing occurred start date: %s", err.Error())
+	}
+
+	end, err := pennParseDate(lines[len(lines)-1], p.location)
+	if err != nil {
+		return nil, newRecordError(models.TypeBadDate,
+			"error parsing occurred end date: %s", err.Error())
+	}
+
+	setOccurred(c, record.Get(pennColumnOccurred), *start, *end)
+
+	// Incidents, multiple are separated by semicolons
+	c.Incidents = []string{}
+	for _, incident := range strings.Split(record.Get(pennColumnType), ";") {
+		if incident = strings.TrimSpace(incident); len(incident) > 0 {
+			c.Incidents = append(c.Incidents, incident)
+		}
+	}
+
+	// Location
+	loc, err := p.geoCache.InsertIfNew(ctx, record.Get(pennColumnLocation))
+	if err != nil {
+		return nil, fmt.Errorf("error getting cached GeoLoc: %s",
+			err.Error())
+	}
+	c.GeoLocID = loc.ID
+
+	// Disposition
+	c.Remediation = record.Get(pennColumnDisposition)
+
+	return c, nil
+}
+
+// pennMissingField returns the name of the first column which a record has no
+// value for. Empty if all are present.
+func pennMissingField(record pdf.TableRecord) string {
+	for _, column := range pennLayout.Columns {
+		if len(record.Get(column.Name)) == 0 {
+			return column.Name
+		}
+	}
+
+	return ""
+}
+
+// pennParseDate creates a time struct from a date and 24 hour time in a Penn
+// report, ex: 10/01/2017 14:35, written in the provided location. An error is
+// returned if one occurs, nil on success.
+func pennParseDate(field string, location *time.Location) (*time.Time, error) {
+	matches := pennDateExpr.FindStringSubmatch(field)
+	if matches == nil {
+		return nil, fmt.Errorf("date not in MM/DD/YYYY HH:MM format: "+
+			"%s", field)
+	}
+
+	// Parse components, all are known to be digits
+	parts := []int{}
+	for _, match := range matches[1:] {
+		part, err := strconv.Atoi(match)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing date component"+
+				": %s", err.Error())
+		}
+
+		parts = append(parts, part)
+	}
+
+	if err := date.Check(parts[2], time.Month(parts[0]), parts[1],
+		parts[3], parts[4]); err != nil {
+		return nil, fmt.Errorf("invalid date: %s: %s", field,
+			err.Error())
+	}
+
+	d := date.Local(parts[2], time.Month(parts[0]), parts[1], parts[3],
+		parts[4], 0, location)
+
+	return &d, nil
+}
+
+// pennHeaderDate creates a time struct from the month, day and year matched
+// by pennHeaderRangeExpr, in the provided location. An error is returned if one
+// occurs, nil on success.
+func pennHeaderDate(matches []string, location *time.Location) (*time.Time, error) {
+	d, err := pennParseDate(fmt.Sprintf("%s/%s/%s 00:00", matches[0],
+		matches[1], matches[2]), location)
+	if err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
@@ -0,0 +1,53 @@
+package parsers
+
+import (
+	"testing"
+
+	"github.com/Noah-Huppert/crime-map/models"
+)
+
+// TestPennParseDate checks dates in Penn reports are parsed in the report's
+// location, and dates which do not exist are rejected rather than normalized
+func TestPennParseDate(t *testing.T) {
+	loc, err := models.UniversityPenn.Location()
+	if err != nil {
+		t.Fatalf("error loading location: %s", err.Error())
+	}
+
+	tests := []struct {
+		field string
+		valid bool
+	}{
+		{"10/01/2017 00:09", true},
+		{"12/31/2017 23:59", true},
+		{"02/29/2016 12:00", true},
+		{"02/29/2017 12:00", false},
+		{"02/30/2017 12:00", false},
+		{"13/45/2017 25:70", false},
+		{"00/01/2017 00:09", false},
+		{"10/00/2017 00:09", false},
+		{"10/01/2017 24:00", false},
+		{"10/01/2017 00:60", false},
+		{"10/01/17 00:09", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.field, func(t *testing.T) {
+			d, err := pennParseDate(test.field, loc)
+
+			if !test.valid {
+				if err == nil {
+					t.Fatalf("expected error, got date: %s", d)
+				}
+
+				return
+			} else if err != nil {
+				t.Fatalf("error parsing date: %s", err.Error())
+			}
+
+			if got := d.Format("01/02/2006 15:04"); got != test.field {
+				t.Fatalf("expected %s, got %s", test.field, got)
+			}
+		})
+	}
+}
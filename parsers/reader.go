@@ -1,7 +1,15 @@
 package parsers
 
 import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
 	"time"
 
 	"github.com/Noah-Huppert/crime-map/geo"
@@ -9,30 +17,105 @@ import (
 	"github.com/Noah-Huppert/crime-map/pdf"
 )
 
-// Reader takes in a Pdf file, and extracts crimes from it. Using the
-// appropriate parser, based on the header.
+// ReportStatus indicates how a report file relates to reports which have
+// already been parsed
+type ReportStatus string
+
+const (
+	// ReportStatusUnknown indicates that the report file has not been
+	// compared to existing reports yet
+	ReportStatusUnknown ReportStatus = ""
+
+	// ReportStatusNew indicates that no report with the same contents, or
+	// covering the same date range, has been parsed
+	ReportStatusNew ReportStatus = "new"
+
+	// ReportStatusIdentical indicates that a file with the exact same
+	// contents has already been parsed. The report will be skipped.
+	ReportStatusIdentical ReportStatus = "identical"
+
+	// ReportStatusSuperseding indicates that a report covering the same
+	// date range has already been parsed, but the file contents are
+	// different. Ex: A corrected re-release. The new report will replace
+	// the old report.
+	ReportStatusSuperseding ReportStatus = "superseding"
+)
+
+// Reader takes in a report file, and extracts crimes from it. Using the parser
+// its Source chooses.
 type Reader struct {
-	// pdf holds the Pdf object used to parse the report file
-	pdf *pdf.Pdf
+	// src reads the report file
+	src Source
 
-	// parsed indicates whether the pdf's text fields have been converted
+	// parsed indicates whether the report's contents have been converted
 	// into Crime structs
 	parsed bool
 
 	// crimes holds all the crimes found in the clery report
 	crimes []models.Crime
 
+	// parseErrors holds the problems found in the report as a whole,
+	// recorded when parsing leniently
+	parseErrors []models.ParseError
+
+	// unmapped holds the incidents in the report which no incident
+	// mapping rule matched. Sorted, without duplicates.
+	unmapped []string
+
+	// report holds the Report model which crimes are being parsed for, nil
+	// if Parse has not determined the report yet
+	report *models.Report
+
+	// status indicates how the report file relates to existing reports
+	status ReportStatus
+
+	// superseded holds the Report which is replaced by the report being
+	// parsed. Nil unless status is ReportStatusSuperseding.
+	superseded *models.Report
+
+	// inserted indicates if the report's row was inserted while parsing,
+	// as opposed to already existing
+	inserted bool
+
+	// store is used to save the report and its crimes
+	store models.Store
+
 	// geoCache is used to cache GeoLoc queries
 	geoCache *geo.GeoCache
+
+	// parserName is the name of the registered parser to use. If empty
+	// the Source chooses the parser.
+	parserName string
+
+	// mode determines how the parser handles problems in the report
+	mode Mode
+
+	// incidents categorizes each crime's incidents, nil if crimes should
+	// not be categorized
+	incidents *IncidentMapping
 }
 
-// NewReader creates a new Reader struct with the given file path.
-func NewReader(path string, geoCache *geo.GeoCache) *Reader {
+// NewReader creates a new Reader struct which reads the provided report file
+// source. Crimes are saved in the provided store.
+//
+// The report is parsed with the registered parser named parserName. If empty
+// the Source chooses the parser, see Source.Choose. Problems in the report are
+// handled as the mode specifies. Crimes are assigned incident categories
+// using the incident mapping, which must be valid. If nil crimes are not
+// categorized.
+func NewReader(src Source, store models.Store, geoCache *geo.GeoCache, parserName string, mode Mode, incidents *IncidentMapping) *Reader {
 	return &Reader{
-		pdf:      pdf.NewPdf(path),
-		parsed:   false,
-		crimes:   []models.Crime{},
-		geoCache: geoCache,
+		src:         src,
+		parsed:      false,
+		crimes:      []models.Crime{},
+		parseErrors: []models.ParseError{},
+		unmapped:    []string{},
+		status:      ReportStatusUnknown,
+		store:       store,
+		geoCache:    geoCache,
+		parserName:  parserName,
+		mode:        mode,
+		incidents:   incidents,
 	}
 }
 
@@ -47,72 +130,302 @@ func (r Reader) Crimes() ([]models.Crime, bool) {
 	return r.crimes, r.IsParsed()
 }
 
-// Parse interprets a crime report file and returns the contained crimes.
-// Additionally an error will be returned, nil on success.
-func (r *Reader) Parse() ([]models.Crime, error) {
+// Report returns the Report model which the crimes were parsed for. Nil if
+// the report could not be determined.
+func (r Reader) Report() *models.Report {
+	return r.report
+}
+
+// Status indicates how the report file relates to reports which have already
+// been parsed. ReportStatusUnknown if Parse has not determined this yet.
+func (r Reader) Status() ReportStatus {
+	return r.status
+}
+
+// Diagnostics returns the problems found reading the report file. Pages which
+// could not be read are skipped, crimes from the rest of the report are still
+// saved.
+func (r Reader) Diagnostics() pdf.Diagnostics {
+	return r.src.Diagnostics()
+}
+
+// ParseErrors returns the problems found in the report as a whole, rather than
+// in one crime. Ex: records which were skipped. Only recorded when parsing in
+// ModeLenient.
+func (r Reader) ParseErrors() []models.ParseError {
+	return r.parseErrors
+}
+
+// Unmapped returns the incidents in the report which the incident mapping has
+// no rule for, sorted without duplicates. Crimes with these incidents are
+// still saved, but are not categorized by them. Empty if crimes are not
+// categorized.
+func (r Reader) Unmapped() []string {
+	return r.unmapped
+}
+
+// Superseded returns the Report which was replaced by the report file. Nil if
+// no report was replaced.
+func (r Reader) Superseded() *models.Report {
+	return r.superseded
+}
+
+// Parse interprets a crime report file and returns the contained crimes. The
+// Report, Crimes, their ParseErrors, and any new GeoLocs are saved in the
+// store in a single transaction. If parsing fails nothing is saved. Pdf files
+// are read one page at a time.
+//
+// Parsing stops if the context is canceled. Additionally an error will be
+// returned, nil on success. ErrReportParsed is returned if a file with the same
+// contents has already been parsed.
+func (r *Reader) Parse(ctx context.Context) ([]models.Crime, error) {
 	// Check if parsed
 	if r.IsParsed() {
 		return r.crimes, ErrReportParsed
 	}
 
-	// Get pdf text fields
-	fields, err := r.pdf.Parse()
-	if err != nil {
-		return r.crimes, fmt.Errorf("error getting pdf fields: %s\n", err.Error())
+	// Open report file, pdf pages are read one at a time while parsing
+	if err := r.src.Open(); err != nil {
+		return r.crimes, err
 	}
+	defer r.src.Close()
 
-	// Figure out which university published report
-	univ, err := determineUniversity(fields)
+	// Choose parser
+	choice, err := r.src.Choose(r.parserName)
 	if err != nil {
-		return r.crimes, fmt.Errorf("error determining university "+
-			"from report fields: %s", err.Error())
+		return r.crimes, err
+	}
+
+	// Check if canceled while reading report file
+	if err = ctx.Err(); err != nil {
+		return r.crimes, err
 	}
 
-	// Use parser based on university
-	var parser Parser
+	// Save report and crimes in one transaction. So if anything fails no
+	// trace of the report is left, and it can be parsed again. Pdf pages
+	// are read while the transaction is open, so only one page is held in
+	// memory at a time.
+	var geoTx *geo.GeoCacheTx
+	var crimes []models.Crime
+	var parseErrors []models.ParseError
+	var unmapped []string
 
-	if univ == models.UniversityDrexel {
-		parser = NewDrexelParser(r.geoCache, fields)
-	} else {
-		return r.crimes, fmt.Errorf("no parser parser for university:"+
-			" %s", univ)
+	err = r.store.Tx(ctx, func(tx models.Store) error {
+		geoTx = r.geoCache.Begin(tx)
+
+		var err error
+		crimes, parseErrors, unmapped, err = r.save(ctx, tx, geoTx,
+			choice)
+
+		// Identical reports are not parsed, but file information may
+		// have been recorded for an existing report, so still save
+		if err == ErrReportParsed {
+			return nil
+		}
+
+		return err
+	})
+
+	if err != nil {
+		// Nil if transaction could not be started
+		if geoTx != nil {
+			geoTx.Rollback()
+		}
+
+		// Report row no longer exists if inserted by transaction
+		if r.inserted {
+			r.report.ID = 0
+		}
+
+		return r.crimes, err
+	}
+	geoTx.Commit()
+
+	if r.status == ReportStatusIdentical {
+		return r.crimes, ErrReportParsed
 	}
 
-	// Save Report model based on info in pdf
-	report, err := r.saveReport(parser, univ)
+	// All done
+	r.crimes = crimes
+	r.parseErrors = parseErrors
+	r.unmapped = unmapped
+	r.parsed = true
+	return r.crimes, nil
+}
+
+// save parses the report's crimes and saves the report, crimes, their incident
+// categories, and parse errors using the provided transaction. The saved
+// crimes, parse errors about the report as a whole, and incidents with no
+// category mapping are returned. An error is returned if one occurs, nil on
+// success. ErrReportParsed is returned if a file with the same contents has
+// already been parsed.
+func (r *Reader) save(ctx context.Context, tx models.Store, geoTx *geo.GeoCacheTx,
+	choice ParserChoice) ([]models.Crime, []models.ParseError, []string, error) {
+
+	parser := choice.New(geoTx, r.mode)
+
+	// Save Report model based on info in report file
+	report, err := r.saveReport(ctx, tx, parser, choice)
 	if err != nil {
-		return r.crimes, fmt.Errorf("error saving report model: %s",
+		return nil, nil, nil, fmt.Errorf("error saving report model: %s",
 			err.Error())
 	}
+	r.report = report
 
 	// Check if report has already been parsed
-	if report.ParseSuccess {
-		return r.crimes, ErrReportParsed
+	if r.status == ReportStatusIdentical {
+		return nil, nil, nil, ErrReportParsed
 	}
 
-	// Parse crimes from fields
-	crimes, err := parser.Parse(report.ID)
+	// Parse crimes from pages
+	crimes, err := parser.Parse(ctx, report.ID)
 	if err != nil {
-		return r.crimes, fmt.Errorf("error parsing report: %s",
+		return nil, nil, nil, fmt.Errorf("error parsing report: %s",
 			err.Error())
 	}
-	r.crimes = crimes
+
+	// Identify crimes by university, and parse dispositions
+	for i := range crimes {
+		crime := &crimes[i]
+		crime.University = report.University
+		crime.DispositionStatus, crime.DispositionCount =
+			models.ParseDisposition(crime.Remediation)
+	}
+
+	// Save crimes
+	if err = tx.InsertCrimes(ctx, crimes); err != nil {
+		return nil, nil, nil, fmt.Errorf("error saving crimes: %s",
+			err.Error())
+	}
+
+	// Categorize crimes
+	unmapped, err := r.categorize(ctx, tx, report.ID, crimes)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Save any parse errors
+	for i := range crimes {
+		crime := &crimes[i]
+
+		for j := range crime.ParseErrors {
+			pErr := &crime.ParseErrors[j]
+
+			// Set Crime and Report FKs. Crimes can be listed by
+			// many reports, the report identifies which parse
+			// found the error.
+			pErr.CrimeID = crime.ID
+			pErr.ReportID = report.ID
+
+			// Save
+			if err = tx.InsertParseErrorIfNew(ctx, pErr); err != nil {
+				return nil, nil, nil, fmt.Errorf("error saving "+
+					"crime parse error, crime: %s, parse "+
+					"err: %s, err: %s", crime, pErr,
+					err.Error())
+			}
+		}
+	}
+
+	// Save parse errors about the report as a whole
+	parseErrors := parser.ParseErrors()
+
+	for i := range parseErrors {
+		pErr := &parseErrors[i]
+
+		// Set Report FK
+		pErr.ReportID = report.ID
+
+		if err = tx.InsertParseErrorIfNew(ctx, pErr); err != nil {
+			return nil, nil, nil, fmt.Errorf("error saving report parse "+
+				"error, parse err: %s, err: %s", pErr,
+				err.Error())
+		}
+	}
 
 	// Save information about parsing process itself in Report model
-	err = r.updateReportPost(parser, report)
+	err = r.updateReportPost(ctx, tx, parser, report)
 	if err != nil {
-		return r.crimes, fmt.Errorf("error updating report model after"+
-			" parsing: %s", err.Error())
+		return nil, nil, nil, fmt.Errorf("error updating report model "+
+			"after parsing: %s", err.Error())
 	}
 
-	// All done
-	return r.crimes, nil
+	return crimes, parseErrors, unmapped, nil
+}
+
+// categorize assigns each saved crime the categories of its incidents, using
+// the incident mapping. Crimes whose saved fields were parsed from a newer
+// report than reportID are not changed. The incidents no rule matched are
+// returned, sorted without duplicates. An error is returned if one occurs, nil
+// on success.
+func (r Reader) categorize(ctx context.Context, tx models.Store, reportID int, crimes []models.Crime) ([]string, error) {
+	unmapped := []string{}
+
+	if r.incidents == nil {
+		return unmapped, nil
+	}
+
+	seen := map[string]bool{}
+
+	// changed holds the crimes whose categories are saved
+	changed := []models.Crime{}
+
+	for i := range crimes {
+		crime := &crimes[i]
+
+		categories, missing := r.incidents.Categorize(crime.Incidents)
+		crime.Categories = categories
+
+		if crime.ReportID == reportID {
+			changed = append(changed, *crime)
+		}
+
+		for _, incident := range missing {
+			if !seen[incident] {
+				seen[incident] = true
+				unmapped = append(unmapped, incident)
+			}
+		}
+	}
+
+	// Save
+	if err := tx.SetCrimeCategories(ctx, changed); err != nil {
+		return nil, fmt.Errorf("error saving crime incident categories: "+
+			"%s", err.Error())
+	}
+
+	sort.Strings(unmapped)
+
+	return unmapped, nil
+}
+
+// HashFile computes the hex encoded SHA-256 hash of a file's contents. The
+// hash and size of the file in bytes are returned. Along with an error if one
+// occurs, nil on success.
+func HashFile(path string) (string, int64, error) {
+	// Open file
+	file, err := os.Open(path)
+	if err != nil {
+		return "", 0, fmt.Errorf("error opening file: %s", err.Error())
+	}
+	defer file.Close()
+
+	// Hash
+	h := sha256.New()
+
+	size, err := io.Copy(h, file)
+	if err != nil {
+		return "", 0, fmt.Errorf("error reading file: %s", err.Error())
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), size, nil
 }
 
 // saveReport retrieves information about the report being parsed, and
-// retrieves / inserts a report with the information. An error is returned if
-// one occurs, nil on success.
-func (r Reader) saveReport(parser Parser, univ models.UniversityType) (*models.Report, error) {
+// retrieves / inserts a report with the information. The Reader.status field
+// is set to indicate how the report relates to existing reports. An error is
+// returned if one occurs, nil on success.
+func (r *Reader) saveReport(ctx context.Context, tx models.Store, parser Parser, choice ParserChoice) (*models.Report, error) {
 	// Get date range report covers
 	startRange, endRange, err := parser.Range()
 	if err != nil {
@@ -121,30 +434,129 @@ func (r Reader) saveReport(parser Parser, univ models.UniversityType) (*models.R
 	}
 
 	// Get number of pages in report
-	pages, parsed := r.pdf.Pages()
-	if !parsed {
-		return nil, fmt.Errorf("error retrieving number of report"+
-			" pages: %s", ErrReportNotParsed)
+	pages := r.src.Pages()
+
+	// Get file information
+	hash, size, err := HashFile(r.src.Path())
+	if err != nil {
+		return nil, fmt.Errorf("error hashing report file: %s",
+			err.Error())
 	}
 
 	// Make report
 	now := time.Now()
-	report := models.NewReport(univ, &now, startRange, endRange,
-		pages)
+	report := models.NewReport(choice.University, &now, startRange,
+		endRange, pages)
+	report.TimeZone = choice.Location.String()
+	report.FileHash = hash
+	report.FileName = filepath.Base(r.src.Path())
+	report.FileSize = size
+
+	// Check if file has been parsed before
+	err = tx.QueryReportByFile(ctx, report)
+	if err == nil {
+		// If parsed successfully before, skip
+		if report.ParseSuccess {
+			r.status = ReportStatusIdentical
+			return report, nil
+		}
+
+		// Otherwise try parsing again
+		r.status = ReportStatusNew
+
+		if err = r.localizeExisting(ctx, tx, report.ID, choice.Location); err != nil {
+			return nil, err
+		}
+
+		return report, nil
+	} else if err != sql.ErrNoRows {
+		return nil, fmt.Errorf("error querying for report with same "+
+			"file hash: %s", err.Error())
+	}
+
+	// Check if a report covering the same range has been parsed
+	prev, err := tx.QueryPreviousReport(ctx, *report)
+	if err == nil {
+		// If the previous report was parsed before file hashes were
+		// recorded, assume it was the same file if it has the same
+		// number of pages. As this is how reports used to be
+		// identified.
+		if len(prev.FileHash) == 0 && prev.Pages == report.Pages {
+			prev.FileHash = report.FileHash
+			prev.FileName = report.FileName
+			prev.FileSize = report.FileSize
+
+			if err = tx.UpdateReportFileFields(ctx, *prev); err != nil {
+				return nil, fmt.Errorf("error recording file "+
+					"information for existing report: %s",
+					err.Error())
+			}
+
+			if prev.ParseSuccess {
+				r.status = ReportStatusIdentical
+				return prev, nil
+			}
+
+			r.status = ReportStatusNew
+
+			if err = r.localizeExisting(ctx, tx, prev.ID, choice.Location); err != nil {
+				return nil, err
+			}
+
+			return prev, nil
+		}
+
+		// Otherwise new report replaces previous report once
+		// parsed
+		r.status = ReportStatusSuperseding
+		r.superseded = prev
+	} else if err == sql.ErrNoRows {
+		r.status = ReportStatusNew
+	} else {
+		return nil, fmt.Errorf("error querying for report covering "+
+			"same range: %s", err.Error())
+	}
 
 	// Save report
-	if err = report.InsertIfNew(); err != nil {
+	if err = tx.InsertReport(ctx, report); err != nil {
 		return nil, fmt.Errorf("error saving Report model: %s",
 			err.Error())
 	}
+	r.inserted = true
 
 	// Success
 	return report, nil
 }
 
-// updateReportPost sets the ParseSuccess and CrimesCount properties of the
-// Report model associated with the parsging job
-func (r Reader) updateReportPost(parser Parser, report *models.Report) error {
+// localizeExisting corrects the times of an existing report, which is about to
+// be parsed again, if they were saved before report time zones were recorded.
+// So the report's range and its newly parsed crimes are in the same time zone.
+// An error is returned if one occurs, nil on success.
+func (r *Reader) localizeExisting(ctx context.Context, tx models.Store, id int, loc *time.Location) error {
+	existing, err := tx.QueryReport(ctx, id)
+	if err != nil {
+		return fmt.Errorf("error querying for existing report: %s",
+			err.Error())
+	}
+
+	if len(existing.TimeZone) > 0 {
+		return nil
+	}
+
+	if err = tx.LocalizeReport(ctx, existing, loc); err != nil {
+		return fmt.Errorf("error correcting existing report times: %s",
+			err.Error())
+	}
+
+	return nil
+}
+
+// updateReportPost sets the ParseSuccess, ParsePartial, CrimesCount,
+// PagesFailed and Diagnostics properties of the Report model associated with
+// the parsing job.
+// If the report supersedes a previous report, the previous report is marked as
+// superseded.
+func (r Reader) updateReportPost(ctx context.Context, tx models.Store, parser Parser, report *models.Report) error {
 	// Get number of crimes parsed
 	count, err := parser.Count()
 	if err != nil {
@@ -153,16 +565,32 @@ func (r Reader) updateReportPost(parser Parser, report *models.Report) error {
 	}
 	report.CrimesCount = count
 
-	// Indicate report parsed successfully
+	// Record pages which could not be read
+	diag := r.src.Diagnostics()
+	report.PagesFailed = uint(len(diag.Failed))
+	report.Diagnostics = diag.String()
+
+	// Indicate report parsed successfully, even if only partially. Ex:
+	// pages could not be read, or parts of the report were skipped when
+	// parsing leniently.
 	report.ParseSuccess = true
+	report.ParsePartial = diag.Partial() || len(parser.ParseErrors()) > 0
 
 	// Save updates
-	err = report.UpdatePostParseFields()
+	err = tx.UpdateReportPostParseFields(ctx, *report)
 	if err != nil {
 		return fmt.Errorf("error saving post parse updates to report "+
 			"model: %s", err.Error())
 	}
 
+	// Replace previous report
+	if r.superseded != nil {
+		if err = tx.SupersedeReport(ctx, *report, r.superseded); err != nil {
+			return fmt.Errorf("error marking previous report as "+
+				"superseded: %s", err.Error())
+		}
+	}
+
 	// Success
 	return nil
 }
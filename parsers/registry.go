@@ -0,0 +1,176 @@
+package parsers
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Noah-Huppert/crime-map/geo"
+	"github.com/Noah-Huppert/crime-map/models"
+	"github.com/Noah-Huppert/crime-map/pdf"
+)
+
+// Registration describes a Parser which can be chosen to parse a report. Each
+// school's parser registers itself from an init function, so Reader does not
+// need to know about it.
+type Registration struct {
+	// Name identifies the parser, ex: on the command line
+	Name string
+
+	// University is the institution whose reports the parser parses
+	University models.UniversityType
+
+	// Detect scores how likely it is that a report can be parsed by the
+	// parser, from 0 (can not) to 1 (certain). Given the text of the
+	// report's first page.
+	Detect func(fields []string) float64
+
+	// New creates a Parser for a report's pages, which handles problems
+	// as the mode specifies, and interprets report times in the location.
+	// GeoLocs must be inserted with the provided GeoCacheTx.
+	New func(geoCache *geo.GeoCacheTx, pages pdf.PageSource, mode Mode, location *time.Location) Parser
+}
+
+// registry holds registered parsers, keyed by name
+var registry map[string]Registration = map[string]Registration{}
+
+// registryLock controls access to registry
+var registryLock sync.RWMutex
+
+// Register makes a parser available to Reader. Panics if a parser with the same
+// name is already registered, or the registration is missing fields. As this
+// is a programming error.
+func Register(reg Registration) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+
+	if len(reg.Name) == 0 || reg.Detect == nil || reg.New == nil {
+		panic(fmt.Sprintf("parser registration missing fields, name: %s",
+			reg.Name))
+	}
+
+	if _, ok := registry[reg.Name]; ok {
+		panic(fmt.Sprintf("parser already registered with name: %s",
+			reg.Name))
+	}
+
+	registry[reg.Name] = reg
+}
+
+// Registered returns all registered parsers, sorted by name
+func Registered() []Registration {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+
+	regs := []Registration{}
+	for _, reg := range registry {
+		regs = append(regs, reg)
+	}
+
+	sort.Slice(regs, func(i, j int) bool {
+		return regs[i].Name < regs[j].Name
+	})
+
+	return regs
+}
+
+// RegisteredNames returns the names of all registered parsers, sorted
+func RegisteredNames() []string {
+	names := []string{}
+	for _, reg := range Registered() {
+		names = append(names, reg.Name)
+	}
+
+	return names
+}
+
+// LookupParser finds the registered parser with the provided name. An error is
+// returned if none is registered, nil on success.
+func LookupParser(name string) (Registration, error) {
+	registryLock.RLock()
+	reg, ok := registry[name]
+	registryLock.RUnlock()
+
+	if !ok {
+		return Registration{}, fmt.Errorf("no parser named: %s, "+
+			"available: %s", name,
+			strings.Join(RegisteredNames(), ", "))
+	}
+
+	return reg, nil
+}
+
+// detectParser scores the text of a report's first page with each registered
+// parser, and returns the parser with the highest score. Ties are broken by
+// name, so the choice is repeatable. An error is returned if no parser scores
+// above 0, nil on success.
+func detectParser(fields []string) (Registration, error) {
+	var best Registration
+	bestScore := 0.0
+
+	for _, reg := range Registered() {
+		score := reg.Detect(fields)
+
+		if score > bestScore {
+			best = reg
+			bestScore = score
+		}
+	}
+
+	if bestScore <= 0 {
+		return Registration{}, fmt.Errorf("no parser recognized report"+
+			", tried: %s", strings.Join(RegisteredNames(), ", "))
+	}
+
+	return best, nil
+}
+
+// detectReport scores how likely a report was published by a university, from
+// the text on its first page. Half the score is given for the university's
+// name, a fifth for a header line matching headerExpr, and the rest for the
+// fraction of the layout's column labels found.
+func detectReport(fields []string, name string, headerExpr *regexp.Regexp, layout pdf.TableLayout) float64 {
+	foundName := false
+	foundHeader := false
+
+	// foundColumns holds the names of columns whose labels were found
+	foundColumns := map[string]bool{}
+
+	for _, field := range fields {
+		field = strings.TrimSpace(field)
+
+		if strings.Contains(field, name) {
+			foundName = true
+		}
+
+		if headerExpr.MatchString(field) {
+			foundHeader = true
+		}
+
+		// Check field labels
+		for _, column := range layout.Columns {
+			for _, label := range column.Labels {
+				if field == label {
+					foundColumns[column.Name] = true
+				}
+			}
+		}
+	}
+
+	// Score
+	score := 0.3 * float64(len(foundColumns)) /
+		float64(len(layout.Columns))
+
+	if foundName {
+		score += 0.5
+	}
+
+	if foundHeader {
+		score += 0.2
+	}
+
+	return score
+}
@@ -0,0 +1,226 @@
+package parsers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Noah-Huppert/crime-map/geo"
+	"github.com/Noah-Huppert/crime-map/models"
+	"github.com/Noah-Huppert/crime-map/pdf"
+)
+
+// Source reads a crime report file. Each file format reports are published in
+// has its own implementation.
+type Source interface {
+	// Path returns the location of the report file
+	Path() string
+
+	// Open opens the report file for reading. The source must be closed
+	// once finished with. An error is returned if one occurs, nil on
+	// success.
+	Open() error
+
+	// Close closes the report file. An error is returned if one occurs,
+	// nil on success.
+	Close() error
+
+	// Pages returns the number of pages in the report file. Formats
+	// without pages have 1 page. Only known once the source is open.
+	Pages() uint
+
+	// Diagnostics returns the problems found reading the report file
+	Diagnostics() pdf.Diagnostics
+
+	// Choose chooses the parser used to parse the report. If name is not
+	// empty the parser registered with the name is used, if the format
+	// supports registered parsers. Must be called after Open. An error is
+	// returned if one occurs, nil on success.
+	Choose(name string) (ParserChoice, error)
+}
+
+// ParserChoice is the parser chosen to parse a report
+type ParserChoice struct {
+	// University is the institution whose reports the parser parses
+	University models.UniversityType
+
+	// Location is the location the university's report times are
+	// written in, and interpreted in by the Parser
+	Location *time.Location
+
+	// New creates the Parser, which handles problems as the mode
+	// specifies. GeoLocs must be inserted with the provided GeoCacheTx.
+	New func(geoCache *geo.GeoCacheTx, mode Mode) Parser
+}
+
+// SourceOptions configures how report files are read
+type SourceOptions struct {
+	// Passwords holds candidate passwords for encrypted pdf report files,
+	// tried after the empty password
+	Passwords []string
+
+	// Mapping describes the columns of csv and html report files. Nil if
+	// these formats should not be read.
+	Mapping *TableMapping
+}
+
+// SourceExts holds the file extensions of report files which can be read.
+// Matched without regard to case.
+var SourceExts []string = []string{".pdf", ".csv", ".html", ".htm"}
+
+// NewSource creates a Source for a report file, based on the file's
+// extension. An error is returned if the format is not supported, or the
+// options do not allow it to be read. Nil on success.
+func NewSource(path string, opts SourceOptions) (Source, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+
+	if ext == ".pdf" {
+		return NewPdfSource(path, opts.Passwords), nil
+	}
+
+	// Table formats need to know which columns hold which crime fields
+	if ext == ".csv" || ext == ".html" || ext == ".htm" {
+		if opts.Mapping == nil {
+			return nil, fmt.Errorf("a table mapping must be "+
+				"configured to read %s report files", ext)
+		}
+
+		if ext == ".csv" {
+			return NewCSVSource(path, *opts.Mapping), nil
+		}
+
+		return NewHTMLSource(path, *opts.Mapping), nil
+	}
+
+	return nil, fmt.Errorf("unsupported report file extension: \"%s\", "+
+		"supported: %s", ext, strings.Join(SourceExts, ", "))
+}
+
+// PdfSource implements Source for pdf report files. The report's parser is
+// chosen from the registered parsers. Pages are read one at a time while
+// parsing.
+type PdfSource struct {
+	// pdf is the report file
+	pdf *pdf.Pdf
+
+	// path is the location of the report file
+	path string
+
+	// pages reads the pdf one page at a time, nil until opened
+	pages *pdf.PageReader
+}
+
+// NewPdfSource creates a PdfSource for a pdf file. If the pdf is encrypted the
+// empty password is tried, then each of the provided passwords.
+func NewPdfSource(path string, passwords []string) *PdfSource {
+	return &PdfSource{
+		pdf:  pdf.NewPdf(path, passwords),
+		path: path,
+	}
+}
+
+// Path implements Source.Path
+func (s PdfSource) Path() string {
+	return s.path
+}
+
+// Open implements Source.Open
+func (s *PdfSource) Open() error {
+	pages, err := s.pdf.Open()
+	if err != nil {
+		return fmt.Errorf("error opening pdf: %s", err.Error())
+	}
+
+	s.pages = pages
+
+	return nil
+}
+
+// Close implements Source.Close
+func (s *PdfSource) Close() error {
+	if s.pages == nil {
+		return nil
+	}
+
+	return s.pages.Close()
+}
+
+// Pages implements Source.Pages
+func (s PdfSource) Pages() uint {
+	pages, _ := s.pdf.Pages()
+	return pages
+}
+
+// Diagnostics implements Source.Diagnostics
+func (s PdfSource) Diagnostics() pdf.Diagnostics {
+	return s.pdf.Diagnostics()
+}
+
+// Choose implements Source.Choose. If no name is provided, the registered
+// parser which best recognizes the text of the report's first page is used.
+func (s *PdfSource) Choose(name string) (ParserChoice, error) {
+	if s.pages == nil {
+		return ParserChoice{}, errors.New("pdf source must be opened " +
+			"before choosing a parser")
+	}
+
+	reg, err := s.registration(name)
+	if err != nil {
+		return ParserChoice{}, err
+	}
+
+	loc, err := reg.University.Location()
+	if err != nil {
+		return ParserChoice{}, err
+	}
+
+	return ParserChoice{
+		University: reg.University,
+		Location:   loc,
+		New: func(geoCache *geo.GeoCacheTx, mode Mode) Parser {
+			return reg.New(geoCache, s.pages, mode, loc)
+		},
+	}, nil
+}
+
+// registration finds the registered parser with the provided name. Or if the
+// name is empty, detects the parser from the report's first page. An error is
+// returned if one occurs, nil on success.
+func (s *PdfSource) registration(name string) (Registration, error) {
+	// Use named parser
+	if len(name) > 0 {
+		reg, err := LookupParser(name)
+		if err != nil {
+			return reg, fmt.Errorf("error finding parser: %s",
+				err.Error())
+		}
+
+		return reg, nil
+	}
+
+	// Otherwise detect from the first page
+	first, err := s.pages.Peek()
+	if err == io.EOF {
+		return Registration{}, errors.New("error detecting parser: " +
+			"report has no pages")
+	} else if err != nil {
+		return Registration{}, fmt.Errorf("error reading first pdf "+
+			"page: %s", err.Error())
+	}
+
+	fields := []string{}
+	for _, run := range first.Runs {
+		fields = append(fields, run.Text)
+	}
+
+	reg, err := detectParser(fields)
+	if err != nil {
+		return reg, fmt.Errorf("error detecting parser: %s",
+			err.Error())
+	}
+
+	return reg, nil
+}
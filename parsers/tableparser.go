@@ -0,0 +1,308 @@
+package parsers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Noah-Huppert/crime-map/date"
+	"github.com/Noah-Huppert/crime-map/geo"
+	"github.com/Noah-Huppert/crime-map/models"
+)
+
+// TableParser implements the Parser interface for csv and html crime logs,
+// which hold one crime per row. The columns holding each crime field are
+// described by a TableMapping.
+//
+// Table crime logs do not have a header stating the date range they cover. So
+// the range is from the day the first crime was reported, to the day the last
+// crime was reported.
+type TableParser struct {
+	// geoCache is used to cache GeoLoc queries to the database. GeoLocs
+	// are inserted in the same transaction as the report.
+	geoCache *geo.GeoCacheTx
+
+	// rows holds the rows of the crime log
+	rows []TableRow
+
+	// mapping describes which columns hold each crime field
+	mapping TableMapping
+
+	// parsedCrimes indicates if a report's crime models have been parsed
+	// out yet
+	parsedCrimes bool
+
+	// crimes holds the Crimes which were parsed from a report, empty if
+	// parsedCrimes == false
+	crimes []models.Crime
+
+	// anomalies handles problems in the crime log, as the parse mode
+	// specifies
+	anomalies *anomalies
+
+	// location is the location which dates without a time zone are
+	// interpreted in
+	location *time.Location
+}
+
+// NewTableParser creates a new TableParser which parses the provided rows. The
+// mapping must be valid, see TableMapping.Validate. Problems in the crime log
+// are handled as the mode specifies. Dates are interpreted in the provided
+// location, unless the mapping's date format includes a time zone.
+func NewTableParser(geoCache *geo.GeoCacheTx, rows []TableRow, mapping TableMapping, mode Mode, location *time.Location) *TableParser {
+	return &TableParser{
+		geoCache:     geoCache,
+		rows:         rows,
+		mapping:      mapping,
+		parsedCrimes: false,
+		crimes:       []models.Crime{},
+		anomalies:    newAnomalies(mode),
+		location:     location,
+	}
+}
+
+// Range implements the Range method for Parser. The range covers the days
+// crimes were reported on. When parsing leniently rows whose date reported
+// can not be parsed are ignored, Parse records them as skipped.
+func (p TableParser) Range() (*time.Time, *time.Time, error) {
+	var start *time.Time
+	var end *time.Time
+
+	for i, row := range p.rows {
+		reported, err := p.parseDate(row[p.mapping.Columns.Reported])
+		if err != nil && p.anomalies.mode == ModeLenient {
+			continue
+		} else if err != nil {
+			return nil, nil, fmt.Errorf("error parsing reported "+
+				"field, row #%d: %s", i+1, err.Error())
+		}
+
+		if start == nil || reported.Before(*start) {
+			start = reported
+		}
+
+		if end == nil || reported.After(*end) {
+			end = reported
+		}
+	}
+
+	if len(p.rows) == 0 {
+		return nil, nil, errors.New("error determining range, crime " +
+			"log has no rows")
+	} else if start == nil {
+		return nil, nil, fmt.Errorf("error determining range, no "+
+			"reported date could be parsed in any of the %d rows",
+			len(p.rows))
+	}
+
+	// Cover whole days
+	startDay := time.Date(start.Year(), start.Month(), start.Day(), 0, 0,
+		0, 0, start.Location())
+	endDay := time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0,
+		end.Location())
+
+	return &startDay, &endDay, nil
+}
+
+// Count implements the Count method for Parser
+func (p TableParser) Count() (uint, error) {
+	// Check if not parsed yet
+	if !p.parsedCrimes {
+		return 0, ErrReportNotParsed
+	}
+
+	return uint(len(p.crimes)), nil
+}
+
+// ParseErrors implements the ParseErrors method for Parser
+func (p TableParser) ParseErrors() []models.ParseError {
+	return p.anomalies.errs
+}
+
+// Parse implements the Parse method for Parser. Each row of the crime log is
+// converted into a Crime. Rows which can not be parsed are skipped if parsing
+// leniently.
+func (p *TableParser) Parse(ctx context.Context, reportID int) ([]models.Crime, error) {
+	// Check if already parsed
+	if p.parsedCrimes {
+		return p.crimes, ErrReportParsed
+	}
+
+	for i, row := range p.rows {
+		// Stop if canceled
+		if err := ctx.Err(); err != nil {
+			return p.crimes, err
+		}
+
+		c, err := p.parseRow(ctx, row)
+		if err != nil {
+			original := fmt.Sprintf("row #%d: %v", i+1, row)
+			if err = p.anomalies.skip(original, err); err != nil {
+				return p.crimes, fmt.Errorf("error parsing "+
+					"row #%d: %s", i+1, err.Error())
+			}
+
+			continue
+		}
+
+		c.ReportID = reportID
+		p.crimes = append(p.crimes, *c)
+	}
+
+	// Success
+	p.parsedCrimes = true
+	return p.crimes, nil
+}
+
+// parseRow converts one row of a crime log into a Crime. An error is returned
+// if one occurs, nil on success.
+func (p *TableParser) parseRow(ctx context.Context, row TableRow) (*models.Crime, error) {
+	columns := p.mapping.Columns
+
+	c := &models.Crime{
+		Descriptions: []string{},
+	}
+
+	// Check all fields present
+	for name, column := range columns.required() {
+		if len(row[column]) == 0 {
+			return nil, newRecordError(models.TypeTruncatedRecord,
+				"%s field missing", name)
+		}
+	}
+
+	// Report ID
+	super, sub, err := p.parseReportID(row[columns.ReportID])
+	if err != nil {
+		return nil, newRecordError(models.TypeBadReportID, "%s",
+			err.Error())
+	}
+	c.ReportSuperID = super
+	c.ReportSubID = sub
+
+	// Date reported
+	reported, err := p.parseDate(row[columns.Reported])
+	if err != nil {
+		return nil, newRecordError(models.TypeBadDate,
+			"error parsing reported field: %s", err.Error())
+	}
+	c.DateReported = *reported
+
+	// Date occurred, if no end the crime occurred at one time
+	field := row[columns.OccurredStart]
+
+	start, err := p.parseDate(field)
+	if err != nil {
+		return nil, newRecordError(models.TypeBadDate,
+			"error parsing occurred start field: %s", err.Error())
+	}
+
+	end := start
+
+	if endField := row[columns.OccurredEnd]; len(columns.OccurredEnd) > 0 && len(endField) > 0 {
+		field = fmt.Sprintf("%s - %s", field, endField)
+
+		end, err = p.parseDate(endField)
+		if err != nil {
+			return nil, newRecordError(models.TypeBadDate,
+				"error parsing occurred end field: %s",
+				err.Error())
+		}
+	}
+
+	setOccurred(c, field, *start, *end)
+
+	// Incidents
+	incidents := []string{row[columns.Incidents]}
+	if len(p.mapping.IncidentSeparator) > 0 {
+		incidents = strings.Split(row[columns.Incidents],
+			p.mapping.IncidentSeparator)
+	}
+
+	c.Incidents = []string{}
+	for _, incident := range incidents {
+		if incident = strings.TrimSpace(incident); len(incident) > 0 {
+			c.Incidents = append(c.Incidents, incident)
+		}
+	}
+
+	// Location
+	loc, err := p.geoCache.InsertIfNew(ctx, row[columns.Location])
+	if err != nil {
+		return nil, fmt.Errorf("error getting cached GeoLoc: %s",
+			err.Error())
+	}
+	c.GeoLocID = loc.ID
+
+	// Description
+	if len(columns.Description) > 0 && len(row[columns.Description]) > 0 {
+		c.Descriptions = []string{row[columns.Description]}
+	}
+
+	// Disposition
+	c.Remediation = row[columns.Disposition]
+
+	return c, nil
+}
+
+// parseReportID splits a report ID into its two parts. If the ID does not
+// contain the mapping's separator the second part is 0. An error is returned if
+// one occurs, nil on success.
+func (p TableParser) parseReportID(field string) (uint, uint, error) {
+	parts := strings.SplitN(field, p.mapping.ReportIDSeparator, 2)
+
+	super, err := strconv.ParseUint(strings.TrimSpace(parts[0]), 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error parsing report super ID into "+
+			"uint: %s", err.Error())
+	}
+
+	if len(parts) == 1 {
+		return uint(super), 0, nil
+	}
+
+	sub, err := strconv.ParseUint(strings.TrimSpace(parts[1]), 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error parsing report sub ID into "+
+			"uint: %s", err.Error())
+	}
+
+	return uint(super), uint(sub), nil
+}
+
+// minTableYear is the earliest year of a date in a crime log. Earlier dates are
+// typos, ex: 0217, or dates in a different format than the mapping's.
+const minTableYear int = 1990
+
+// maxTableFuture is how far after now a date in a crime log may be. Later
+// dates are typos, or dates in a different format than the mapping's.
+const maxTableFuture time.Duration = 24 * time.Hour
+
+// parseDate parses a date in the mapping's date format, in the parser's
+// location. An error is returned if one occurs, or the date is not plausible
+// for a crime log. Nil on success.
+func (p TableParser) parseDate(field string) (*time.Time, error) {
+	d, err := time.ParseInLocation(p.mapping.DateFormat, field, p.location)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check plausible
+	if d.Year() < minTableYear {
+		return nil, fmt.Errorf("date is before %d: %s", minTableYear,
+			field)
+	}
+
+	if d.After(time.Now().Add(maxTableFuture)) {
+		return nil, fmt.Errorf("date is in the future: %s", field)
+	}
+
+	// Interpret ambiguous times as their first occurrence
+	d = date.Local(d.Year(), d.Month(), d.Day(), d.Hour(), d.Minute(),
+		d.Second(), d.Location())
+
+	return &d, nil
+}
@@ -0,0 +1,499 @@
+package parsers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/viper"
+	"golang.org/x/net/html"
+
+	"github.com/Noah-Huppert/crime-map/geo"
+	"github.com/Noah-Huppert/crime-map/models"
+	"github.com/Noah-Huppert/crime-map/pdf"
+)
+
+// TableMapping describes which columns of a csv or html crime log hold each
+// crime field. Columns are identified by the text of their header cell.
+type TableMapping struct {
+	// University is the institution which publishes the crime logs
+	University models.UniversityType `mapstructure:"university"`
+
+	// DateFormat is the layout of dates in the crime log, in the format
+	// accepted by time.Parse. If empty DefaultTableDateFormat is used.
+	DateFormat string `mapstructure:"date_format"`
+
+	// IncidentSeparator separates multiple incidents in the incidents
+	// column. If empty the column holds one incident.
+	IncidentSeparator string `mapstructure:"incident_separator"`
+
+	// ReportIDSeparator separates the two parts of a report ID, ex: the
+	// year and number. If empty DefaultReportIDSeparator is used. If a
+	// report ID does not contain the separator the whole ID is used as
+	// the first part.
+	ReportIDSeparator string `mapstructure:"report_id_separator"`
+
+	// Columns holds the header of the column which holds each field
+	Columns TableColumns `mapstructure:"columns"`
+}
+
+// TableColumns holds the headers of the columns in a csv or html crime log
+// which hold each crime field
+type TableColumns struct {
+	// ReportID is the police report ID column
+	ReportID string `mapstructure:"report_id"`
+
+	// Reported is the date reported column
+	Reported string `mapstructure:"reported"`
+
+	// OccurredStart is the column holding when the crime started
+	OccurredStart string `mapstructure:"occurred_start"`
+
+	// OccurredEnd is the column holding when the crime ended. Optional,
+	// if empty crimes occurred at one time.
+	OccurredEnd string `mapstructure:"occurred_end"`
+
+	// Incidents is the column holding the type of crime
+	Incidents string `mapstructure:"incidents"`
+
+	// Location is the column holding where the crime occurred
+	Location string `mapstructure:"location"`
+
+	// Description is the column describing the crime. Optional.
+	Description string `mapstructure:"description"`
+
+	// Disposition is the column holding the crime's disposition
+	Disposition string `mapstructure:"disposition"`
+}
+
+// DefaultTableDateFormat is the layout of dates in table crime logs if the
+// TableMapping does not specify one
+const DefaultTableDateFormat string = "01/02/2006 15:04"
+
+// DefaultReportIDSeparator separates the parts of report IDs in table crime
+// logs if the TableMapping does not specify a separator
+const DefaultReportIDSeparator string = "-"
+
+// LoadTableMapping reads a TableMapping from a toml, yaml or json file. An
+// error is returned if one occurs, or the mapping is invalid. Nil on success.
+func LoadTableMapping(path string) (*TableMapping, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("error reading table mapping file: %s",
+			err.Error())
+	}
+
+	mapping := &TableMapping{}
+	if err := v.Unmarshal(mapping); err != nil {
+		return nil, fmt.Errorf("error parsing table mapping file: %s",
+			err.Error())
+	}
+
+	if errs := mapping.Validate(); len(errs) > 0 {
+		errsArr := []string{}
+		for _, err := range errs {
+			errsArr = append(errsArr, err.Error())
+		}
+
+		return nil, fmt.Errorf("invalid table mapping: %s",
+			strings.Join(errsArr, ", "))
+	}
+
+	return mapping, nil
+}
+
+// Validate checks the mapping names a known university and all required
+// columns. Default values are set for optional fields which are empty. The
+// problems found are returned, empty if valid.
+func (m *TableMapping) Validate() []error {
+	errs := []error{}
+
+	if _, err := models.NewUniversityType(string(m.University)); err != nil {
+		errs = append(errs, fmt.Errorf("university: %s", err.Error()))
+	}
+
+	if len(m.DateFormat) == 0 {
+		m.DateFormat = DefaultTableDateFormat
+	}
+
+	if len(m.ReportIDSeparator) == 0 {
+		m.ReportIDSeparator = DefaultReportIDSeparator
+	}
+
+	// Headers are compared without surrounding spaces
+	m.Columns.trim()
+
+	for name, header := range m.Columns.required() {
+		if len(header) == 0 {
+			errs = append(errs, fmt.Errorf("columns.%s must be "+
+				"set", name))
+		}
+	}
+
+	return errs
+}
+
+// trim removes surrounding spaces from each column header
+func (c *TableColumns) trim() {
+	for _, header := range []*string{&c.ReportID, &c.Reported,
+		&c.OccurredStart, &c.OccurredEnd, &c.Incidents, &c.Location,
+		&c.Description, &c.Disposition} {
+
+		*header = strings.TrimSpace(*header)
+	}
+}
+
+// required returns the headers of columns which must be present in a crime
+// log, keyed by the name of their mapping field
+func (c TableColumns) required() map[string]string {
+	return map[string]string{
+		"report_id":      c.ReportID,
+		"reported":       c.Reported,
+		"occurred_start": c.OccurredStart,
+		"incidents":      c.Incidents,
+		"location":       c.Location,
+		"disposition":    c.Disposition,
+	}
+}
+
+// mapped returns the headers of every column the mapping names, required and
+// optional, keyed by the name of their mapping field
+func (c TableColumns) mapped() map[string]string {
+	columns := c.required()
+
+	if len(c.OccurredEnd) > 0 {
+		columns["occurred_end"] = c.OccurredEnd
+	}
+
+	if len(c.Description) > 0 {
+		columns["description"] = c.Description
+	}
+
+	return columns
+}
+
+// TableRow holds the cells of one row in a csv or html crime log, keyed by the
+// header of their column
+type TableRow map[string]string
+
+// tableReadFn reads the rows of a table from a report file. The header row is
+// the first row returned. An error is returned if one occurs, nil on success.
+type tableReadFn func(file io.Reader, mapping TableMapping) ([][]string, error)
+
+// TableSource implements Source for report files which hold a table of crimes,
+// one crime per row. Such as csv exports and html pages. Tables do not have
+// pages, so the whole table is read when the source is opened.
+type TableSource struct {
+	// path is the location of the report file
+	path string
+
+	// format names the file format, used in errors
+	format string
+
+	// mapping describes the table's columns
+	mapping TableMapping
+
+	// read reads the table from the report file
+	read tableReadFn
+
+	// rows holds the table's rows, excluding the header. Nil until opened.
+	rows []TableRow
+}
+
+// NewCSVSource creates a TableSource which reads a csv file. The first record
+// of the file must be the header. The mapping must be valid, see
+// TableMapping.Validate.
+func NewCSVSource(path string, mapping TableMapping) *TableSource {
+	return &TableSource{
+		path:    path,
+		format:  "csv",
+		mapping: mapping,
+		read:    readCSV,
+	}
+}
+
+// NewHTMLSource creates a TableSource which reads an html page. The first
+// table on the page whose header row contains the mapped report ID column is
+// read.
+func NewHTMLSource(path string, mapping TableMapping) *TableSource {
+	return &TableSource{
+		path:    path,
+		format:  "html",
+		mapping: mapping,
+		read:    readHTML,
+	}
+}
+
+// Path implements Source.Path
+func (s TableSource) Path() string {
+	return s.path
+}
+
+// Open implements Source.Open by reading every row of the table
+func (s *TableSource) Open() error {
+	// Check mapping, so unmapped columns are not read as empty cells
+	if errs := s.mapping.Validate(); len(errs) > 0 {
+		errsArr := []string{}
+		for _, err := range errs {
+			errsArr = append(errsArr, err.Error())
+		}
+
+		return fmt.Errorf("invalid table mapping: %s",
+			strings.Join(errsArr, ", "))
+	}
+
+	// Read
+	file, err := os.Open(s.path)
+	if err != nil {
+		return fmt.Errorf("error opening %s file: %s", s.format,
+			err.Error())
+	}
+	defer file.Close()
+
+	records, err := s.read(file, s.mapping)
+	if err != nil {
+		return fmt.Errorf("error reading %s file: %s", s.format,
+			err.Error())
+	}
+
+	if len(records) == 0 {
+		return fmt.Errorf("error reading %s file: no header row",
+			s.format)
+	}
+
+	// Check mapped columns exist
+	header := records[0]
+
+	for name, column := range s.mapping.Columns.mapped() {
+		if !hasCell(header, column) {
+			return fmt.Errorf("error reading %s file: %s column "+
+				"not found: %s", s.format, name, column)
+		}
+	}
+
+	// Key cells by header
+	s.rows = []TableRow{}
+
+	for _, record := range records[1:] {
+		row := TableRow{}
+		empty := true
+
+		for i, cell := range record {
+			if i >= len(header) {
+				break
+			}
+
+			cell = strings.TrimSpace(cell)
+			row[strings.TrimSpace(header[i])] = cell
+
+			if len(cell) > 0 {
+				empty = false
+			}
+		}
+
+		// Skip blank rows
+		if !empty {
+			s.rows = append(s.rows, row)
+		}
+	}
+
+	return nil
+}
+
+// Close implements Source.Close. The file is closed by Open, so there is
+// nothing to do.
+func (s TableSource) Close() error {
+	return nil
+}
+
+// Pages implements Source.Pages. Tables have 1 page.
+func (s TableSource) Pages() uint {
+	return 1
+}
+
+// Diagnostics implements Source.Diagnostics. Tables are read entirely, or not
+// at all, so never have failed pages.
+func (s TableSource) Diagnostics() pdf.Diagnostics {
+	return pdf.Diagnostics{
+		Failed: []pdf.PageError{},
+	}
+}
+
+// Choose implements Source.Choose. Tables are always parsed with a
+// TableParser, as described by the mapping. So a parser name can not be
+// provided.
+func (s *TableSource) Choose(name string) (ParserChoice, error) {
+	if len(name) > 0 {
+		return ParserChoice{}, fmt.Errorf("parser can not be chosen "+
+			"for %s report files, columns are described by the "+
+			"table mapping", s.format)
+	}
+
+	if s.rows == nil {
+		return ParserChoice{}, fmt.Errorf("%s source must be opened "+
+			"before choosing a parser", s.format)
+	}
+
+	loc, err := s.mapping.University.Location()
+	if err != nil {
+		return ParserChoice{}, err
+	}
+
+	return ParserChoice{
+		University: s.mapping.University,
+		Location:   loc,
+		New: func(geoCache *geo.GeoCacheTx, mode Mode) Parser {
+			return NewTableParser(geoCache, s.rows, s.mapping, mode,
+				loc)
+		},
+	}, nil
+}
+
+// hasCell determines if a row has a cell with the provided text, ignoring
+// surrounding spaces
+func hasCell(row []string, text string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) == text {
+			return true
+		}
+	}
+
+	return false
+}
+
+// readCSV implements tableReadFn for csv files. Records may have different
+// numbers of fields.
+func readCSV(file io.Reader, mapping TableMapping) ([][]string, error) {
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	return reader.ReadAll()
+}
+
+// readHTML implements tableReadFn for html pages. The page is tokenized like a
+// browser would, so unclosed rows and cells, and scripts, are allowed. Tables
+// nested inside the table being read are included in the cell which holds
+// them.
+//
+// The first table whose first row contains the mapped report ID column is
+// read. An error is returned if there is none.
+func readHTML(file io.Reader, mapping TableMapping) ([][]string, error) {
+	tokenizer := html.NewTokenizer(file)
+
+	// rows holds the rows of the table being read
+	var rows [][]string
+
+	// depth is the number of tables the tokenizer is inside of, only rows
+	// in the outermost table are read
+	depth := 0
+
+	// cell holds the text of the cell being read, nil if not in a cell
+	var cell *bytes.Buffer
+
+	// hidden indicates if the tokenizer is inside an element whose text
+	// is not displayed, ex: a script
+	hidden := false
+
+	// endCell adds the cell being read to the last row
+	endCell := func() {
+		if cell == nil {
+			return
+		}
+
+		if len(rows) > 0 {
+			rows[len(rows)-1] = append(rows[len(rows)-1],
+				strings.Join(strings.Fields(cell.String()), " "))
+		}
+
+		cell = nil
+	}
+
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			if err := tokenizer.Err(); err != io.EOF {
+				return nil, fmt.Errorf("error parsing html: %s",
+					err.Error())
+			}
+
+			break
+		}
+
+		// Separate text in different elements of a cell, ex: lines
+		// split by <br>
+		if tokenType != html.TextToken && cell != nil {
+			cell.WriteString(" ")
+		}
+
+		switch tokenType {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, _ := tokenizer.TagName()
+
+			switch string(name) {
+			case "script", "style":
+				hidden = tokenType == html.StartTagToken
+			case "table":
+				if tokenType == html.SelfClosingTagToken {
+					continue
+				}
+
+				depth++
+				if depth == 1 {
+					rows = [][]string{}
+				}
+			case "tr":
+				if depth == 1 {
+					endCell()
+					rows = append(rows, []string{})
+				}
+			case "td", "th":
+				if depth == 1 {
+					endCell()
+					cell = &bytes.Buffer{}
+				}
+			}
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+
+			switch string(name) {
+			case "script", "style":
+				hidden = false
+			case "table":
+				if depth == 0 {
+					continue
+				}
+
+				depth--
+				if depth > 0 {
+					continue
+				}
+
+				endCell()
+
+				// Check if table holds crimes
+				if len(rows) > 0 && hasCell(rows[0],
+					mapping.Columns.ReportID) {
+					return rows, nil
+				}
+			case "td", "th", "tr":
+				if depth == 1 {
+					endCell()
+				}
+			}
+		case html.TextToken:
+			if cell != nil && !hidden {
+				cell.Write(tokenizer.Text())
+			}
+		}
+	}
+
+	return nil, errors.New("no table with a header row containing the " +
+		"report ID column found")
+}
@@ -0,0 +1,232 @@
+package parsers
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Noah-Huppert/crime-map/models"
+)
+
+// newTestTableMapping creates a TableMapping which maps every column
+func newTestTableMapping() TableMapping {
+	return TableMapping{
+		University: models.UniversityDrexel,
+		Columns: TableColumns{
+			ReportID:      "Case #",
+			Reported:      "Date Reported",
+			OccurredStart: "Occurred From",
+			OccurredEnd:   "Occurred To",
+			Incidents:     "Offense",
+			Location:      "Location",
+			Description:   "Synopsis",
+			Disposition:   "Disposition",
+		},
+	}
+}
+
+// TestTableMappingValidate checks mappings with an unknown university, or
+// without a required column, are invalid
+func TestTableMappingValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(m *TableMapping)
+		errs   int
+	}{
+		{
+			name:   "valid",
+			modify: func(m *TableMapping) {},
+			errs:   0,
+		},
+		{
+			name: "optional columns empty",
+			modify: func(m *TableMapping) {
+				m.Columns.OccurredEnd = ""
+				m.Columns.Description = ""
+			},
+			errs: 0,
+		},
+		{
+			name: "unknown university",
+			modify: func(m *TableMapping) {
+				m.University = "Unknown University"
+			},
+			errs: 1,
+		},
+		{
+			name: "empty reported column",
+			modify: func(m *TableMapping) {
+				m.Columns.Reported = ""
+			},
+			errs: 1,
+		},
+		{
+			name: "blank reported column",
+			modify: func(m *TableMapping) {
+				m.Columns.Reported = "  \t"
+			},
+			errs: 1,
+		},
+		{
+			name: "no columns",
+			modify: func(m *TableMapping) {
+				m.Columns = TableColumns{}
+			},
+			errs: 6,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mapping := newTestTableMapping()
+			test.modify(&mapping)
+
+			if errs := mapping.Validate(); len(errs) != test.errs {
+				t.Fatalf("expected %d errors, got: %v", test.errs,
+					errs)
+			}
+		})
+	}
+}
+
+// TestTableMappingValidateDefaults checks Validate fills in optional settings,
+// and removes spaces around column headers
+func TestTableMappingValidateDefaults(t *testing.T) {
+	mapping := newTestTableMapping()
+	mapping.Columns.Reported = " Date Reported "
+
+	if errs := mapping.Validate(); len(errs) > 0 {
+		t.Fatalf("expected no errors, got: %v", errs)
+	}
+
+	if mapping.DateFormat != DefaultTableDateFormat {
+		t.Errorf("expected date format %q, got %q",
+			DefaultTableDateFormat, mapping.DateFormat)
+	}
+
+	if mapping.ReportIDSeparator != DefaultReportIDSeparator {
+		t.Errorf("expected report ID separator %q, got %q",
+			DefaultReportIDSeparator, mapping.ReportIDSeparator)
+	}
+
+	if mapping.Columns.Reported != "Date Reported" {
+		t.Errorf("expected reported column %q, got %q",
+			"Date Reported", mapping.Columns.Reported)
+	}
+}
+
+// TestTableParserRange checks the range covers the days crimes were reported,
+// and implausible or unparsable dates are reported
+func TestTableParserRange(t *testing.T) {
+	mapping := newTestTableMapping()
+	if errs := mapping.Validate(); len(errs) > 0 {
+		t.Fatalf("invalid table mapping: %v", errs)
+	}
+
+	tests := []struct {
+		name     string
+		mode     Mode
+		reported []string
+		start    string
+		end      string
+		err      string
+	}{
+		{
+			name: "range",
+			mode: ModeStrict,
+			reported: []string{"10/12/2017 08:15",
+				"10/09/2017 23:59", "10/10/2017 00:00"},
+			start: "10/09/2017 00:00",
+			end:   "10/12/2017 00:00",
+		},
+		{
+			name: "lenient skips bad dates",
+			mode: ModeLenient,
+			reported: []string{"10/12/2017 08:15", "not a date",
+				"10/11/0217 12:00"},
+			start: "10/12/2017 00:00",
+			end:   "10/12/2017 00:00",
+		},
+		{
+			name:     "strict bad date",
+			mode:     ModeStrict,
+			reported: []string{"10/12/2017 08:15", "not a date"},
+			err:      "error parsing reported field, row #2",
+		},
+		{
+			name:     "strict implausible year",
+			mode:     ModeStrict,
+			reported: []string{"10/11/0217 12:00"},
+			err:      "date is before 1990",
+		},
+		{
+			name:     "strict future date",
+			mode:     ModeStrict,
+			reported: []string{"10/11/2917 12:00"},
+			err:      "date is in the future",
+		},
+		{
+			name:     "no rows",
+			mode:     ModeLenient,
+			reported: []string{},
+			err:      "crime log has no rows",
+		},
+		{
+			name:     "no parsable dates",
+			mode:     ModeLenient,
+			reported: []string{"not a date", "10/11/0217 12:00"},
+			err:      "no reported date could be parsed",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rows := []TableRow{}
+			for _, reported := range test.reported {
+				rows = append(rows, TableRow{
+					mapping.Columns.Reported: reported,
+				})
+			}
+
+			p := NewTableParser(nil, rows, mapping, test.mode,
+				time.UTC)
+
+			start, end, err := p.Range()
+
+			if len(test.err) > 0 {
+				if err == nil || !strings.Contains(err.Error(),
+					test.err) {
+
+					t.Fatalf("expected error containing %q, "+
+						"got: %v", test.err, err)
+				}
+
+				return
+			} else if err != nil {
+				t.Fatalf("error parsing range: %s", err.Error())
+			}
+
+			for _, check := range []struct {
+				name string
+				want string
+				got  *time.Time
+			}{
+				{"start", test.start, start},
+				{"end", test.end, end},
+			} {
+				want, err := time.ParseInLocation(
+					DefaultTableDateFormat, check.want,
+					time.UTC)
+				if err != nil {
+					t.Fatalf("error parsing expected %s: %s",
+						check.name, err.Error())
+				}
+
+				if !check.got.Equal(want) {
+					t.Errorf("expected %s %s, got %s",
+						check.name, want, check.got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package pdf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PageError records why text could not be extracted from a pdf page
+type PageError struct {
+	// Page is the number of the page, starting at 1
+	Page uint
+
+	// Reason describes the error which occurred
+	Reason string
+}
+
+// String converts a PageError into a string to view
+func (e PageError) String() string {
+	return fmt.Sprintf("page #%d: %s", e.Page, e.Reason)
+}
+
+// Diagnostics records problems found while reading a pdf. A page which can not
+// be read is skipped, and recorded here, so the rest of the document can still
+// be parsed.
+type Diagnostics struct {
+	// Encrypted indicates if the pdf was encrypted
+	Encrypted bool
+
+	// Password indicates which password decrypted the pdf, 0 for the
+	// empty password, otherwise the index of the candidate password
+	// plus 1. 0 if the pdf was not encrypted.
+	Password int
+
+	// Failed holds the pages which text could not be extracted from, in
+	// page order
+	Failed []PageError
+}
+
+// Partial indicates if text could not be extracted from some pages
+func (d Diagnostics) Partial() bool {
+	return len(d.Failed) > 0
+}
+
+// String converts Diagnostics into a string to view, with one line for each
+// failed page. Empty if no pages failed.
+func (d Diagnostics) String() string {
+	lines := []string{}
+	for _, e := range d.Failed {
+		lines = append(lines, e.String())
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,285 @@
+package pdf
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	pdfcontent "github.com/unidoc/unidoc/pdf/contentstream"
+	pdf "github.com/unidoc/unidoc/pdf/model"
+)
+
+// Page holds the text runs drawn on one pdf page
+type Page struct {
+	// Number is the number of the page, starting at 1
+	Number uint
+
+	// Runs holds the text runs on the page, in the order they are drawn
+	Runs []TextRun
+}
+
+// PageSource provides the pages of a document one at a time
+type PageSource interface {
+	// Next returns the next page. io.EOF is returned once there are no
+	// more pages. Another error is returned if one occurs, nil on success.
+	Next() (*Page, error)
+
+	// Diagnostics returns the problems found reading pages so far
+	Diagnostics() Diagnostics
+}
+
+// PageReader decodes the pages of a pdf file one at a time, so only one page is
+// held in memory. Pages which can not be decoded are skipped, and recorded in
+// the Pdf's Diagnostics. Implements PageSource. Must be closed once finished
+// with.
+type PageReader struct {
+	// pdf is the Pdf being read
+	pdf *Pdf
+
+	// file is the open pdf file
+	file *os.File
+
+	// reader reads pdf objects from the file
+	reader *pdf.PdfReader
+
+	// pages is the number of pages in the pdf
+	pages uint
+
+	// next is the number of the next page to decode
+	next uint
+
+	// peeked holds the page returned by Peek, nil if Peek has not been
+	// called since the last call to Next
+	peeked *Page
+}
+
+// Open opens the pdf file for reading one page at a time. Sets the number of
+// pages the Pdf contains. An error is returned if one occurs, nil on success.
+func (p *Pdf) Open() (*PageReader, error) {
+	// Open file
+	file, err := os.Open(p.path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening pdf file: %s", err.Error())
+	}
+
+	// Create pdf reader for file
+	pdfReader, err := pdf.NewPdfReader(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("error creating pdf reader: %s", err.Error())
+	}
+
+	// Reset diagnostics from any previous read
+	p.diag = Diagnostics{
+		Failed: []PageError{},
+	}
+
+	reader := &PageReader{
+		pdf:    p,
+		file:   file,
+		reader: pdfReader,
+		next:   1,
+	}
+
+	if err = reader.init(); err != nil {
+		reader.Close()
+		return nil, err
+	}
+
+	p.pages = reader.pages
+	p.opened = true
+
+	return reader, nil
+}
+
+// init decrypts the pdf and counts its pages. An error is returned if one
+// occurs, nil on success.
+func (r *PageReader) init() error {
+	// Determine if pdf is encrypted
+	isEncrypted, err := r.reader.IsEncrypted()
+	if err != nil {
+		return fmt.Errorf("error determining pdf file "+
+			"encryption status: %s", err.Error())
+	}
+
+	// If encrypted, try decrypting
+	if isEncrypted {
+		r.pdf.diag.Encrypted = true
+
+		if err = r.decrypt(); err != nil {
+			return err
+		}
+	}
+
+	// Get number of pages
+	numPages, err := r.reader.GetNumPages()
+	if err != nil {
+		return fmt.Errorf("error getting number of pages in pdf: %s",
+			err.Error())
+	}
+
+	// Check we can cast numPages into uint
+	if numPages < 0 {
+		return fmt.Errorf("error parsing number of pages "+
+			" into uint, below 0, val: %d", numPages)
+	}
+	r.pages = uint(numPages)
+
+	return nil
+}
+
+// decrypt tries the empty password, then each candidate password, until one
+// decrypts the pdf. An error is returned if none do, nil on success.
+func (r *PageReader) decrypt() error {
+	passwords := append([]string{""}, r.pdf.passwords...)
+
+	for i, password := range passwords {
+		// Attempt
+		auth, err := r.reader.Decrypt([]byte(password))
+		if err != nil {
+			return fmt.Errorf("error decrypting pdf "+
+				"file: %s", err.Error())
+		}
+
+		// Check if successful
+		if auth {
+			r.pdf.diag.Password = i
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unable to decrypt pdf file with empty password "+
+		"or %d candidate passwords", len(r.pdf.passwords))
+}
+
+// Diagnostics implements PageSource.Diagnostics
+func (r PageReader) Diagnostics() Diagnostics {
+	return r.pdf.diag
+}
+
+// Pages returns the number of pages in the pdf
+func (r PageReader) Pages() uint {
+	return r.pages
+}
+
+// Next implements PageSource.Next by decoding the next page's text runs
+func (r *PageReader) Next() (*Page, error) {
+	// Return peeked page
+	if r.peeked != nil {
+		page := r.peeked
+		r.peeked = nil
+
+		return page, nil
+	}
+
+	// Decode, skipping pages which fail
+	for {
+		pageNum, ops, err := r.nextOps()
+		if err == io.EOF {
+			return nil, err
+		} else if err != nil {
+			continue
+		}
+
+		runs, err := extractRuns(pageNum, ops)
+		if err != nil {
+			r.fail(pageNum, fmt.Errorf("error extracting text "+
+				"runs: %s", err.Error()))
+			continue
+		}
+
+		return &Page{
+			Number: pageNum,
+			Runs:   runs,
+		}, nil
+	}
+}
+
+// fail records that a page could not be read
+func (r *PageReader) fail(pageNum uint, err error) {
+	r.pdf.diag.Failed = append(r.pdf.diag.Failed, PageError{
+		Page:   pageNum,
+		Reason: err.Error(),
+	})
+}
+
+// Peek returns the next page without advancing. The following call to Next
+// returns the same page. io.EOF is returned once there are no more pages.
+// Another error is returned if one occurs, nil on success.
+func (r *PageReader) Peek() (*Page, error) {
+	if r.peeked != nil {
+		return r.peeked, nil
+	}
+
+	page, err := r.Next()
+	if err != nil {
+		return nil, err
+	}
+
+	r.peeked = page
+
+	return page, nil
+}
+
+// nextOps parses the content stream operations of the next page. The page's
+// number is returned along with its operations. io.EOF is returned once there
+// are no more pages. If the page can not be parsed it is recorded as failed,
+// and the error is returned. Nil on success.
+func (r *PageReader) nextOps() (uint, pdfcontent.ContentStreamOperations, error) {
+	// Check if no more pages
+	if r.next > r.pages {
+		return 0, nil, io.EOF
+	}
+
+	pageNum := r.next
+	r.next++
+
+	ops, err := r.pageOps(pageNum)
+	if err != nil {
+		r.fail(pageNum, err)
+		return pageNum, nil, err
+	}
+
+	return pageNum, ops, nil
+}
+
+// pageOps parses the content stream operations of a page. Damaged pages can
+// cause the pdf library to panic, this is recovered from and returned as an
+// error. An error is returned if one occurs, nil on success.
+func (r *PageReader) pageOps(pageNum uint) (ops pdfcontent.ContentStreamOperations, err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("panic reading page: %v", rec)
+		}
+	}()
+
+	// Get page
+	page, err := r.reader.GetPage(int(pageNum))
+	if err != nil {
+		return nil, fmt.Errorf("error getting pdf page: %s",
+			err.Error())
+	}
+
+	// Get page contents
+	streams, err := page.GetAllContentStreams()
+	if err != nil {
+		return nil, fmt.Errorf("error getting pdf content streams: %s",
+			err.Error())
+	}
+
+	// Parse contents
+	parser := pdfcontent.NewContentStreamParser(streams)
+	parsed, err := parser.Parse()
+	if err != nil {
+		return nil, fmt.Errorf("error parsing content "+
+			"stream: %s", err.Error())
+	}
+
+	return *parsed, nil
+}
+
+// Close closes the pdf file. An error is returned if one occurs, nil on
+// success.
+func (r *PageReader) Close() error {
+	return r.file.Close()
+}
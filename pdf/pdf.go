@@ -5,8 +5,7 @@ import (
 	"fmt"
 	pdfcontent "github.com/unidoc/unidoc/pdf/contentstream"
 	pdfcore "github.com/unidoc/unidoc/pdf/core"
-	pdf "github.com/unidoc/unidoc/pdf/model"
-	"os"
+	"io"
 	"strings"
 )
 
@@ -15,6 +14,13 @@ type Pdf struct {
 	// path is the location of the pdf file
 	path string
 
+	// passwords holds candidate passwords which are tried, after the empty
+	// password, if the pdf is encrypted
+	passwords []string
+
+	// diag records problems found while reading the pdf
+	diag Diagnostics
+
 	// parsed indicates whether or not fields have been extracted from the
 	// pdf file
 	parsed bool
@@ -23,16 +29,34 @@ type Pdf struct {
 	// they occurred
 	fields []string
 
+	// runsParsed indicates whether or not text runs have been extracted
+	// from the pdf file
+	runsParsed bool
+
+	// runs holds all the text runs present in the pdf, in the order they
+	// are drawn
+	runs []TextRun
+
+	// opened indicates whether or not the pdf file has been opened, and
+	// the pages field set
+	opened bool
+
 	// pages holds the number of pages a Pdf contains
 	pages uint
 }
 
-// NewPdf creates a new Pdf struct with the given path
-func NewPdf(path string) *Pdf {
+// NewPdf creates a new Pdf struct with the given path. If the pdf is encrypted
+// the empty password is tried, then each of the provided passwords.
+func NewPdf(path string, passwords []string) *Pdf {
 	return &Pdf{
-		path:   path,
+		path:      path,
+		passwords: passwords,
+		diag: Diagnostics{
+			Failed: []PageError{},
+		},
 		parsed: false,
 		fields: []string{},
+		runs:   []TextRun{},
 	}
 }
 
@@ -41,8 +65,11 @@ func (p Pdf) String() string {
 	return fmt.Sprintf("path: %s\n"+
 		"parsed: %t\n"+
 		"fields: %s\n"+
+		"runsParsed: %t\n"+
+		"runs: %d\n"+
 		"pages: %d",
-		p.path, p.parsed, strings.Join(p.fields, ", "), p.pages)
+		p.path, p.parsed, strings.Join(p.fields, ", "), p.runsParsed,
+		len(p.runs), p.pages)
 }
 
 // IsParsed indicates if the specified pdf file has been processed yet
@@ -56,10 +83,22 @@ func (p Pdf) Fields() ([]string, bool) {
 	return p.fields, p.IsParsed()
 }
 
+// Runs returns the text runs the Pdf contains. Along with a boolean, which
+// indicates if the text runs have been extracted yet.
+func (p Pdf) Runs() ([]TextRun, bool) {
+	return p.runs, p.runsParsed
+}
+
+// Diagnostics returns the problems found while reading the pdf. Pages which
+// failed are skipped by Parse, ParseRuns and PageReader.
+func (p Pdf) Diagnostics() Diagnostics {
+	return p.diag
+}
+
 // Pages returns the number of pages the Pdf contains. Along with a boolean
-// which indicates if the pdf file has been parsed yet.
+// which indicates if the pdf file has been opened yet.
 func (p Pdf) Pages() (uint, bool) {
-	return p.pages, p.IsParsed()
+	return p.pages, p.opened
 }
 
 // Parse opens the pdf file and extracts all text fields present. These fields
@@ -70,97 +109,90 @@ func (p *Pdf) Parse() ([]string, error) {
 		return p.fields, errors.New("pdf file already parsed")
 	}
 
-	// Open file
-	file, err := os.Open(p.path)
+	// Open
+	reader, err := p.Open()
 	if err != nil {
-		return p.fields, fmt.Errorf("error opening pdf file: %s", err.Error())
+		return p.fields, err
 	}
+	defer reader.Close()
+
+	// Loop through pages, skipping pages which fail
+	for {
+		pageNum, ops, err := reader.nextOps()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			continue
+		}
 
-	defer file.Close()
+		// Loop through text
+		fields, err := pageFields(ops)
+		if err != nil {
+			reader.fail(pageNum, err)
+			continue
+		}
 
-	// Create pdf reader for file
-	pdfReader, err := pdf.NewPdfReader(file)
-	if err != nil {
-		return p.fields, fmt.Errorf("error creating pdf reader: %s", err.Error())
+		p.fields = append(p.fields, fields...)
 	}
 
-	// Determine if pdf is encrypted
-	isEncrypted, err := pdfReader.IsEncrypted()
-	if err != nil {
-		return p.fields, fmt.Errorf("error determining pdf file "+
-			"encryption status: %s", err.Error())
-	}
+	// Done
+	p.parsed = true
+	return p.fields, nil
+}
 
-	// If encrypted, try decrypting with empty password
-	if isEncrypted {
-		// Attempt
-		auth, err := pdfReader.Decrypt([]byte(""))
-		if err != nil {
-			return p.fields, fmt.Errorf("error decrypting pdf "+
-				"file: %s", err.Error())
-		}
+// pageFields extracts the text fields from a page's content stream operations.
+// An error is returned if one occurs, nil on success.
+func pageFields(ops pdfcontent.ContentStreamOperations) ([]string, error) {
+	fields := []string{}
+
+	for _, op := range ops {
+		// Check text field
+		if op.Operand == "Tj" && len(op.Params) == 1 {
+			val, ok := op.Params[0].(*pdfcore.PdfObjectString)
+			if !ok {
+				return nil, errors.New("error " +
+					"casting pdf text field to string")
+			}
 
-		// Verify successful decryption
-		if !auth {
-			return p.fields, errors.New("unable to decrypt pdf " +
-				"file with empty password")
+			fields = append(fields, string(*val))
 		}
 	}
 
-	// Get number of pages
-	numPages, err := pdfReader.GetNumPages()
-	if err != nil {
-		return p.fields, fmt.Errorf("error getting number of pages in pdf: %s",
-			err.Error())
+	return fields, nil
+}
+
+// ParseRuns opens the pdf file and extracts all text runs present, with their
+// positions. The runs are returned in the order they are drawn. Along with an
+// error if one occurs, or nil on success.
+//
+// All text runs are held in memory. To process large files one page at a time
+// use Open instead.
+func (p *Pdf) ParseRuns() ([]TextRun, error) {
+	// If already parsed, error
+	if p.runsParsed {
+		return p.runs, errors.New("pdf file runs already parsed")
 	}
 
-	// Check we can cast numPages into uint
-	if numPages < 0 {
-		return p.fields, fmt.Errorf("error parsing number of pages "+
-			" into uint, below 0, val: %d", numPages)
+	// Open
+	reader, err := p.Open()
+	if err != nil {
+		return p.runs, err
 	}
-	p.pages = uint(numPages)
+	defer reader.Close()
 
 	// Loop through pages
-	for pageNum := 1; pageNum <= numPages; pageNum++ {
-		// Get page
-		page, err := pdfReader.GetPage(pageNum)
-		if err != nil {
-			return p.fields, fmt.Errorf("error getting pdf page #%d: %s",
-				pageNum, err.Error())
-		}
-
-		// Get page contents
-		streams, err := page.GetAllContentStreams()
-		if err != nil {
-			return p.fields, fmt.Errorf("error getting pdf content streams: %s",
-				err.Error())
-		}
-
-		// Parse contents
-		parser := pdfcontent.NewContentStreamParser(streams)
-		ops, err := parser.Parse()
-		if err != nil {
-			return p.fields, fmt.Errorf("error parsing content "+
-				"stream: %s", err.Error())
+	for {
+		page, err := reader.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return p.runs, err
 		}
 
-		// Loop through text
-		for _, op := range *ops {
-			// Check text field
-			if op.Operand == "Tj" && len(op.Params) == 1 {
-				val, ok := op.Params[0].(*pdfcore.PdfObjectString)
-				if !ok {
-					return p.fields, errors.New("error " +
-						"casting pdf text field to string")
-				}
-
-				p.fields = append(p.fields, string(*val))
-			}
-		}
+		p.runs = append(p.runs, page.Runs...)
 	}
 
 	// Done
-	p.parsed = true
-	return p.fields, nil
+	p.runsParsed = true
+	return p.runs, nil
 }
@@ -0,0 +1,396 @@
+package pdf
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// TableColumn describes a labelled column in a table drawn on a pdf
+type TableColumn struct {
+	// Name identifies the column in a TableRecord
+	Name string
+
+	// Labels holds the text the column's label may be drawn with. Matched
+	// after surrounding spaces are removed.
+	Labels []string
+}
+
+// TableLayout describes how a table is drawn on a pdf, so text runs can be
+// grouped into records.
+//
+// Two styles of tables are supported. Tables with a header line of labels,
+// where values are drawn below their label. And forms, where each record
+// repeats its labels, and values are drawn to the right of their label. Both
+// styles may be mixed.
+type TableLayout struct {
+	// Columns holds the table's columns. A new record starts each time a
+	// value is found for the first column, if the current record already
+	// has one.
+	Columns []TableColumn
+
+	// Furniture holds expressions which match text drawn on each page
+	// which is not part of the table, ex: page headers and footers. Lines
+	// which contain a text run matching one of these are ignored.
+	Furniture []*regexp.Regexp
+
+	// LineTolerance is the largest vertical distance, in points, between
+	// text runs on the same line. If 0 half the font size of the larger
+	// text run is used.
+	LineTolerance float64
+}
+
+// TableRecord holds the values of one record in a table
+type TableRecord struct {
+	// Page is the number of the page the record starts on, starting at 1
+	Page uint
+
+	// Fields holds each column's value, keyed by TableColumn.Name. Values
+	// drawn on multiple lines hold one item per line.
+	Fields map[string][]string
+}
+
+// Lines returns the lines of a column's value. Nil if the record does not have
+// a value for the column.
+func (r TableRecord) Lines(name string) []string {
+	return r.Fields[name]
+}
+
+// Get returns a column's value, with lines joined by spaces. Empty if the
+// record does not have a value for the column.
+func (r TableRecord) Get(name string) string {
+	return strings.Join(r.Fields[name], " ")
+}
+
+// String converts a TableRecord into a string to view
+func (r TableRecord) String() string {
+	names := []string{}
+	for name := range r.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fields := []string{}
+	for _, name := range names {
+		fields = append(fields, fmt.Sprintf("%s: %s", name,
+			strings.Join(r.Fields[name], " | ")))
+	}
+
+	return fmt.Sprintf("page: %d, %s", r.Page, strings.Join(fields, ", "))
+}
+
+// TextLine is a group of text runs drawn on the same line of a page
+type TextLine struct {
+	// Page is the number of the page the line is on, starting at 1
+	Page uint
+
+	// Y is the vertical position of the line's first text run
+	Y float64
+
+	// Runs holds the line's text runs, from left to right
+	Runs []TextRun
+}
+
+// Texts returns the text of each run in the line, from left to right
+func (l TextLine) Texts() []string {
+	texts := []string{}
+	for _, run := range l.Runs {
+		texts = append(texts, run.Text)
+	}
+
+	return texts
+}
+
+// GroupLines sorts text runs into lines, in reading order. Runs whose vertical
+// positions differ by no more than tolerance are on the same line. If
+// tolerance is 0 half the font size of the larger run is used.
+func GroupLines(runs []TextRun, tolerance float64) []TextLine {
+	// Sort top to bottom, left to right
+	sorted := make([]TextRun, len(runs))
+	copy(sorted, runs)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Page != sorted[j].Page {
+			return sorted[i].Page < sorted[j].Page
+		}
+
+		return sorted[i].Y > sorted[j].Y
+	})
+
+	// Group
+	lines := []TextLine{}
+
+	for _, run := range sorted {
+		// Check if on the same line as the last run
+		if len(lines) > 0 {
+			line := &lines[len(lines)-1]
+
+			if line.Page == run.Page && sameLine(line.Y, run, line.Runs, tolerance) {
+				line.Runs = append(line.Runs, run)
+				continue
+			}
+		}
+
+		lines = append(lines, TextLine{
+			Page: run.Page,
+			Y:    run.Y,
+			Runs: []TextRun{run},
+		})
+	}
+
+	// Order runs in each line left to right
+	for _, line := range lines {
+		sort.SliceStable(line.Runs, func(i, j int) bool {
+			return line.Runs[i].X < line.Runs[j].X
+		})
+	}
+
+	return lines
+}
+
+// sameLine determines if a text run is on the line at vertical position y,
+// which already holds the provided runs
+func sameLine(y float64, run TextRun, runs []TextRun, tolerance float64) bool {
+	if tolerance == 0 {
+		size := run.FontSize
+		for _, r := range runs {
+			size = math.Max(size, r.FontSize)
+		}
+
+		tolerance = size / 2
+	}
+
+	return math.Abs(y-run.Y) <= tolerance
+}
+
+// tableCell is a region of a line which holds a column's value
+type tableCell struct {
+	// column is the index of the column in TableLayout.Columns
+	column int
+
+	// start is the horizontal position the cell starts at
+	start float64
+}
+
+// ReconstructTable groups text runs into the records of a table. See
+// TableReader for how text runs are assigned to columns.
+//
+// The records are returned in reading order. Along with the text runs which
+// could not be assigned to a column, which are not furniture.
+func ReconstructTable(runs []TextRun, layout TableLayout) ([]TableRecord, []TextRun) {
+	tables := NewTableReader(layout)
+
+	records, unassigned := tables.AddRuns(runs)
+	records = append(records, tables.Flush()...)
+
+	return records, unassigned
+}
+
+// TableReader groups text runs into the records of a table, one page at a time.
+// Records may continue from one page onto the next.
+//
+// Each value text run is assigned to the column of the closest label to its
+// left on the same line. If there is none, it is assigned to the column whose
+// label is above it, in the last line which held labels.
+type TableReader struct {
+	// layout describes the table
+	layout TableLayout
+
+	// record holds the record being reconstructed, nil before the first
+	// value is found
+	record *TableRecord
+
+	// header holds the cells of the last line which held labels
+	header []tableCell
+}
+
+// NewTableReader creates a TableReader for a table with the provided layout
+func NewTableReader(layout TableLayout) *TableReader {
+	return &TableReader{
+		layout: layout,
+		header: []tableCell{},
+	}
+}
+
+// AddRuns groups more text runs into records, usually a page's worth. The
+// records which were completed are returned, in reading order. The last record
+// may continue in the next text runs, so it is held until more runs are added
+// or Flush is called. Text runs which could not be assigned to a column, which
+// are not furniture, are also returned.
+func (t *TableReader) AddRuns(runs []TextRun) ([]TableRecord, []TextRun) {
+	records := []TableRecord{}
+	unassigned := []TextRun{}
+
+	for _, line := range GroupLines(runs, t.layout.LineTolerance) {
+		// Skip furniture
+		if t.layout.isFurniture(line) {
+			continue
+		}
+
+		// Find labels
+		cells := []tableCell{}
+		values := []TextRun{}
+
+		for _, run := range line.Runs {
+			column, value, ok := t.layout.matchLabel(run)
+			if !ok {
+				values = append(values, run)
+				continue
+			}
+
+			cells = append(cells, tableCell{
+				column: column,
+				start:  run.X,
+			})
+
+			// Keep text drawn in the same run as the label
+			if len(value.Text) > 0 {
+				values = append(values, value)
+			}
+		}
+
+		if len(cells) > 0 {
+			t.header = cells
+		}
+
+		// Assign values to columns. Values in the same cell of a line are
+		// joined.
+		lineValues := map[int]string{}
+		lineOrder := []int{}
+
+		for _, run := range values {
+			column, ok := findCell(cells, run, false)
+			if !ok {
+				column, ok = findCell(t.header, run, true)
+			}
+
+			if !ok {
+				unassigned = append(unassigned, run)
+				continue
+			}
+
+			if _, ok := lineValues[column]; ok {
+				lineValues[column] += " " + run.Text
+			} else {
+				lineValues[column] = run.Text
+				lineOrder = append(lineOrder, column)
+			}
+		}
+
+		// Check if line starts a new record
+		_, hasFirst := lineValues[0]
+
+		if len(lineOrder) > 0 && (t.record == nil || (hasFirst &&
+			len(t.record.Fields[t.layout.Columns[0].Name]) > 0)) {
+			if t.record != nil {
+				records = append(records, *t.record)
+			}
+
+			t.record = &TableRecord{
+				Page:   line.Page,
+				Fields: map[string][]string{},
+			}
+		}
+
+		// Add values to record
+		for _, column := range lineOrder {
+			name := t.layout.Columns[column].Name
+			t.record.Fields[name] = append(t.record.Fields[name],
+				lineValues[column])
+		}
+	}
+
+	return records, unassigned
+}
+
+// Flush returns the record being reconstructed, once there are no more text
+// runs. Empty if no records were found.
+func (t *TableReader) Flush() []TableRecord {
+	if t.record == nil {
+		return []TableRecord{}
+	}
+
+	record := *t.record
+	t.record = nil
+
+	return []TableRecord{record}
+}
+
+// isFurniture determines if a line holds text which is not part of the table
+func (l TableLayout) isFurniture(line TextLine) bool {
+	for _, run := range line.Runs {
+		text := strings.TrimSpace(run.Text)
+
+		for _, expr := range l.Furniture {
+			if expr.MatchString(text) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// matchLabel determines if a text run is a column label. If so the index of
+// the column is returned, along with a text run holding any text drawn after
+// the label in the same run, and true.
+func (l TableLayout) matchLabel(run TextRun) (int, TextRun, bool) {
+	text := strings.TrimSpace(run.Text)
+
+	for i, column := range l.Columns {
+		for _, label := range column.Labels {
+			// Check entire run is label
+			if text == label {
+				return i, TextRun{}, true
+			}
+
+			// Check run starts with label
+			if !strings.HasPrefix(text, label+" ") {
+				continue
+			}
+
+			// Estimate where the rest of the text starts
+			value := run
+			value.Text = strings.TrimSpace(text[len(label):])
+			value.X += float64(len(label)+1) * glyphWidth *
+				run.FontSize
+
+			return i, value, true
+		}
+	}
+
+	return 0, TextRun{}, false
+}
+
+// findCell finds the column a value text run belongs to in a line of cells.
+// This is the cell which starts closest to the left of the run. If clamp is
+// true runs to the left of every cell belong to the leftmost cell. False is
+// returned if no cell is found.
+func findCell(cells []tableCell, run TextRun, clamp bool) (int, bool) {
+	if len(cells) == 0 {
+		return 0, false
+	}
+
+	found := -1
+	for i, cell := range cells {
+		if cell.start <= run.X && (found < 0 || cell.start > cells[found].start) {
+			found = i
+		}
+	}
+
+	// Left of every cell
+	if found < 0 && !clamp {
+		return 0, false
+	} else if found < 0 {
+		found = 0
+		for i, cell := range cells {
+			if cell.start < cells[found].start {
+				found = i
+			}
+		}
+	}
+
+	return cells[found].column, true
+}
@@ -0,0 +1,464 @@
+package pdf
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	pdfcontent "github.com/unidoc/unidoc/pdf/contentstream"
+	pdfcore "github.com/unidoc/unidoc/pdf/core"
+)
+
+const (
+	// glyphWidth is the estimated width of a glyph, as a fraction of the
+	// font size. Font metrics are not read, so this is used to advance the
+	// position after text is shown. Only the position of text shown
+	// without an explicit position operator is affected.
+	glyphWidth float64 = 0.5
+
+	// spaceGap is the smallest TJ array adjustment, as a fraction of the
+	// font size, which is treated as a space between words
+	spaceGap float64 = 0.2
+
+	// runGap is the smallest TJ array adjustment, as a fraction of the
+	// font size, which separates two text runs. Table generators often
+	// draw a whole row with one TJ array, using large adjustments to move
+	// between columns.
+	runGap float64 = 1.0
+)
+
+// TextRun is a piece of text drawn at one position on a pdf page
+type TextRun struct {
+	// Page is the number of the page the text is on, starting at 1
+	Page uint
+
+	// X is the horizontal position the text starts at, in points from the
+	// left of the page
+	X float64
+
+	// Y is the vertical position of the text's baseline, in points from
+	// the bottom of the page
+	Y float64
+
+	// FontSize is the size of the text, in points, once scaled by the
+	// page's transformations
+	FontSize float64
+
+	// Text holds the characters drawn
+	Text string
+}
+
+// String converts a TextRun into a string to view
+func (r TextRun) String() string {
+	return fmt.Sprintf("page: %d, x: %.2f, y: %.2f, fontSize: %.2f, "+
+		"text: %s", r.Page, r.X, r.Y, r.FontSize, r.Text)
+}
+
+// matrix is a pdf transformation matrix, [a b c d e f], which represents:
+//
+//	a b 0
+//	c d 0
+//	e f 1
+type matrix [6]float64
+
+// identity is the transformation matrix which does nothing
+var identity = matrix{1, 0, 0, 1, 0, 0}
+
+// translate creates a matrix which moves by tx and ty
+func translate(tx, ty float64) matrix {
+	return matrix{1, 0, 0, 1, tx, ty}
+}
+
+// mult returns the matrix m × n, which applies m then n
+func (m matrix) mult(n matrix) matrix {
+	return matrix{
+		m[0]*n[0] + m[1]*n[2],
+		m[0]*n[1] + m[1]*n[3],
+		m[2]*n[0] + m[3]*n[2],
+		m[2]*n[1] + m[3]*n[3],
+		m[4]*n[0] + m[5]*n[2] + n[4],
+		m[4]*n[1] + m[5]*n[3] + n[5],
+	}
+}
+
+// graphicsState holds the parts of the pdf graphics state which affect where
+// text is drawn. Saved and restored by the q and Q operators.
+type graphicsState struct {
+	// ctm is the current transformation matrix
+	ctm matrix
+
+	// charSpace is the extra space added after each glyph, set by Tc
+	charSpace float64
+
+	// wordSpace is the extra space added after each space, set by Tw
+	wordSpace float64
+
+	// hScale is the horizontal scaling, as a fraction, set by Tz
+	hScale float64
+
+	// leading is the distance between lines, set by TL
+	leading float64
+
+	// fontSize is the text font size, set by Tf
+	fontSize float64
+
+	// rise is the distance the baseline is moved up, set by Ts
+	rise float64
+}
+
+// textExtractor interprets the operations in a page's content stream to find
+// where text is drawn
+type textExtractor struct {
+	// page is the number of the page being interpreted
+	page uint
+
+	// gs is the current graphics state
+	gs graphicsState
+
+	// stack holds graphics states saved by the q operator
+	stack []graphicsState
+
+	// tm is the text matrix
+	tm matrix
+
+	// tlm is the text line matrix, the text matrix at the start of the
+	// current line
+	tlm matrix
+
+	// runs holds the text runs found so far
+	runs []TextRun
+}
+
+// extractRuns finds all the text runs drawn by a page's content stream
+// operations. An error is returned if one occurs, nil on success.
+func extractRuns(page uint, ops pdfcontent.ContentStreamOperations) ([]TextRun, error) {
+	e := textExtractor{
+		page: page,
+		gs: graphicsState{
+			ctm:    identity,
+			hScale: 1,
+		},
+		tm:   identity,
+		tlm:  identity,
+		runs: []TextRun{},
+	}
+
+	for _, op := range ops {
+		if err := e.apply(op); err != nil {
+			return e.runs, fmt.Errorf("error interpreting \"%s\" "+
+				"operator: %s", op.Operand, err.Error())
+		}
+	}
+
+	return e.runs, nil
+}
+
+// apply updates the extractor's state with one content stream operation. An
+// error is returned if the operation's operands are invalid, nil on success.
+func (e *textExtractor) apply(op *pdfcontent.ContentStreamOperation) error {
+	switch op.Operand {
+	// Graphics state
+	case "q":
+		e.stack = append(e.stack, e.gs)
+	case "Q":
+		// Ignore unbalanced restores, some generators emit them
+		if len(e.stack) > 0 {
+			e.gs = e.stack[len(e.stack)-1]
+			e.stack = e.stack[:len(e.stack)-1]
+		}
+	case "cm":
+		m, err := toMatrix(op.Params)
+		if err != nil {
+			return err
+		}
+
+		e.gs.ctm = m.mult(e.gs.ctm)
+
+	// Text objects
+	case "BT":
+		e.tm = identity
+		e.tlm = identity
+	case "ET":
+		// Nothing to reset
+
+	// Text state
+	case "Tc":
+		return setFloat(op.Params, &e.gs.charSpace)
+	case "Tw":
+		return setFloat(op.Params, &e.gs.wordSpace)
+	case "Tz":
+		var scale float64
+		if err := setFloat(op.Params, &scale); err != nil {
+			return err
+		}
+
+		e.gs.hScale = scale / 100
+	case "TL":
+		return setFloat(op.Params, &e.gs.leading)
+	case "Ts":
+		return setFloat(op.Params, &e.gs.rise)
+	case "Tf":
+		// Font name is not needed, only size
+		if len(op.Params) != 2 {
+			return fmt.Errorf("expected 2 operands, found %d",
+				len(op.Params))
+		}
+
+		size, err := toFloat(op.Params[1])
+		if err != nil {
+			return err
+		}
+
+		e.gs.fontSize = size
+
+	// Text positioning
+	case "Td", "TD":
+		nums, err := toFloats(op.Params, 2)
+		if err != nil {
+			return err
+		}
+
+		if op.Operand == "TD" {
+			e.gs.leading = -nums[1]
+		}
+
+		e.nextLine(nums[0], nums[1])
+	case "Tm":
+		m, err := toMatrix(op.Params)
+		if err != nil {
+			return err
+		}
+
+		e.tm = m
+		e.tlm = m
+	case "T*":
+		e.nextLine(0, -e.gs.leading)
+
+	// Text showing
+	case "Tj":
+		if len(op.Params) != 1 {
+			return fmt.Errorf("expected 1 operand, found %d",
+				len(op.Params))
+		}
+
+		return e.show(op.Params[0])
+	case "'":
+		if len(op.Params) != 1 {
+			return fmt.Errorf("expected 1 operand, found %d",
+				len(op.Params))
+		}
+
+		e.nextLine(0, -e.gs.leading)
+
+		return e.show(op.Params[0])
+	case "\"":
+		if len(op.Params) != 3 {
+			return fmt.Errorf("expected 3 operands, found %d",
+				len(op.Params))
+		}
+
+		nums, err := toFloats(op.Params[:2], 2)
+		if err != nil {
+			return err
+		}
+
+		e.gs.wordSpace = nums[0]
+		e.gs.charSpace = nums[1]
+		e.nextLine(0, -e.gs.leading)
+
+		return e.show(op.Params[2])
+	case "TJ":
+		if len(op.Params) != 1 {
+			return fmt.Errorf("expected 1 operand, found %d",
+				len(op.Params))
+		}
+
+		arr, ok := op.Params[0].(*pdfcore.PdfObjectArray)
+		if !ok {
+			return fmt.Errorf("expected array operand, found %T",
+				op.Params[0])
+		}
+
+		return e.showArray(*arr)
+	}
+
+	return nil
+}
+
+// nextLine moves to the start of the next line, offset by tx and ty from the
+// start of the current line
+func (e *textExtractor) nextLine(tx, ty float64) {
+	e.tlm = translate(tx, ty).mult(e.tlm)
+	e.tm = e.tlm
+}
+
+// show adds a text run for a string operand, then moves past it. An error is
+// returned if the operand is not a string, nil on success.
+func (e *textExtractor) show(obj pdfcore.PdfObject) error {
+	str, ok := obj.(*pdfcore.PdfObjectString)
+	if !ok {
+		return fmt.Errorf("expected string operand, found %T", obj)
+	}
+
+	run := e.startRun()
+	run.Text = string(*str)
+	e.advance(run.Text)
+
+	e.addRun(run)
+
+	return nil
+}
+
+// showArray adds text runs for a TJ array operand. Strings are joined into one
+// run unless an adjustment is large enough to separate them. An error is
+// returned if an element is not a string or number, nil on success.
+func (e *textExtractor) showArray(arr pdfcore.PdfObjectArray) error {
+	run := e.startRun()
+
+	for _, obj := range arr {
+		// Show strings
+		if str, ok := obj.(*pdfcore.PdfObjectString); ok {
+			// Start a new run if the last one was ended by an
+			// adjustment
+			if len(run.Text) == 0 {
+				run = e.startRun()
+			}
+
+			run.Text += string(*str)
+			e.advance(string(*str))
+
+			continue
+		}
+
+		// Adjust position, in thousandths of text space units. Positive
+		// adjustments move left.
+		adj, err := toFloat(obj)
+		if err != nil {
+			return fmt.Errorf("expected string or number in "+
+				"array, found %T", obj)
+		}
+
+		gap := -adj / 1000
+		e.tm = translate(gap*e.gs.fontSize*e.gs.hScale, 0).mult(e.tm)
+
+		// Check if adjustment separates words or runs
+		if gap >= runGap {
+			e.addRun(run)
+			run.Text = ""
+		} else if gap >= spaceGap && len(run.Text) > 0 {
+			run.Text += " "
+		}
+	}
+
+	e.addRun(run)
+
+	return nil
+}
+
+// startRun creates a text run at the current text position
+func (e *textExtractor) startRun() TextRun {
+	// Text rendering matrix, see section 9.4.4 of the pdf spec
+	trm := matrix{
+		e.gs.fontSize * e.gs.hScale, 0,
+		0, e.gs.fontSize,
+		0, e.gs.rise,
+	}.mult(e.tm).mult(e.gs.ctm)
+
+	// Scale font size by the vertical scale of the text space
+	space := e.tm.mult(e.gs.ctm)
+
+	return TextRun{
+		Page:     e.page,
+		X:        trm[4],
+		Y:        trm[5],
+		FontSize: e.gs.fontSize * math.Hypot(space[2], space[3]),
+	}
+}
+
+// addRun saves a text run, unless it is empty. Trailing spaces, which are
+// added when a TJ array adjustment separates words, are removed.
+func (e *textExtractor) addRun(run TextRun) {
+	run.Text = strings.TrimRight(run.Text, " ")
+	if len(run.Text) == 0 {
+		return
+	}
+
+	e.runs = append(e.runs, run)
+}
+
+// advance moves the text matrix past the provided text. Glyph widths are
+// estimated, see glyphWidth.
+func (e *textExtractor) advance(text string) {
+	tx := 0.0
+
+	for _, b := range []byte(text) {
+		tx += glyphWidth*e.gs.fontSize + e.gs.charSpace
+
+		if b == ' ' {
+			tx += e.gs.wordSpace
+		}
+	}
+
+	e.tm = translate(tx*e.gs.hScale, 0).mult(e.tm)
+}
+
+// toFloat converts a numeric pdf object into a float64. An error is returned if
+// the object is not a number, nil on success.
+func toFloat(obj pdfcore.PdfObject) (float64, error) {
+	switch num := obj.(type) {
+	case *pdfcore.PdfObjectFloat:
+		return float64(*num), nil
+	case *pdfcore.PdfObjectInteger:
+		return float64(*num), nil
+	default:
+		return 0, fmt.Errorf("expected number operand, found %T", obj)
+	}
+}
+
+// toFloats converts n numeric pdf objects into float64s. An error is returned
+// if there are not n objects, or one is not a number, nil on success.
+func toFloats(objs []pdfcore.PdfObject, n int) ([]float64, error) {
+	if len(objs) != n {
+		return nil, fmt.Errorf("expected %d operands, found %d", n,
+			len(objs))
+	}
+
+	nums := make([]float64, n)
+	for i, obj := range objs {
+		num, err := toFloat(obj)
+		if err != nil {
+			return nil, err
+		}
+
+		nums[i] = num
+	}
+
+	return nums, nil
+}
+
+// toMatrix converts 6 numeric pdf objects into a matrix. An error is returned
+// if one occurs, nil on success.
+func toMatrix(objs []pdfcore.PdfObject) (matrix, error) {
+	nums, err := toFloats(objs, 6)
+	if err != nil {
+		return identity, err
+	}
+
+	var m matrix
+	copy(m[:], nums)
+
+	return m, nil
+}
+
+// setFloat converts a single numeric operand and stores it in dest. An error is
+// returned if one occurs, nil on success.
+func setFloat(objs []pdfcore.PdfObject, dest *float64) error {
+	nums, err := toFloats(objs, 1)
+	if err != nil {
+		return err
+	}
+
+	*dest = nums[0]
+
+	return nil
+}
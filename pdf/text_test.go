@@ -0,0 +1,315 @@
+package pdf
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	pdfcontent "github.com/unidoc/unidoc/pdf/contentstream"
+	pdfcore "github.com/unidoc/unidoc/pdf/core"
+)
+
+// posTolerance is the largest difference, in points, between an expected and
+// extracted position or font size which is considered equal
+const posTolerance float64 = 0.001
+
+// op builds a content stream operation. Params may be float64, int, string or
+// []interface{} values, which are converted into numbers, strings and arrays.
+func op(operand string, params ...interface{}) *pdfcontent.ContentStreamOperation {
+	objs := []pdfcore.PdfObject{}
+
+	for _, param := range params {
+		objs = append(objs, toObject(param))
+	}
+
+	return &pdfcontent.ContentStreamOperation{
+		Operand: operand,
+		Params:  objs,
+	}
+}
+
+// toObject converts a value into a pdf object, see op
+func toObject(v interface{}) pdfcore.PdfObject {
+	switch val := v.(type) {
+	case float64:
+		obj := pdfcore.PdfObjectFloat(val)
+		return &obj
+	case int:
+		obj := pdfcore.PdfObjectInteger(val)
+		return &obj
+	case string:
+		obj := pdfcore.PdfObjectString(val)
+		return &obj
+	case []interface{}:
+		arr := pdfcore.PdfObjectArray{}
+		for _, elem := range val {
+			arr = append(arr, toObject(elem))
+		}
+
+		return &arr
+	default:
+		panic(fmt.Sprintf("can not convert %T into pdf object", v))
+	}
+}
+
+// font is the Tf operation which sets the font size to 10. The font name is
+// not read, so any object will do.
+var font = op("Tf", "F1", 10)
+
+// TestExtractRuns checks text runs are placed by the text positioning
+// operators, and TJ array adjustments
+func TestExtractRuns(t *testing.T) {
+	tests := []struct {
+		name string
+		ops  pdfcontent.ContentStreamOperations
+		want []TextRun
+	}{
+		{
+			name: "Td",
+			ops: pdfcontent.ContentStreamOperations{
+				op("BT"), font, op("Td", 72, 700),
+				op("Tj", "Hello"), op("ET"),
+			},
+			want: []TextRun{
+				{X: 72, Y: 700, FontSize: 10, Text: "Hello"},
+			},
+		},
+		{
+			// Lines start from the last line, not the end of
+			// the text shown
+			name: "TD and T*",
+			ops: pdfcontent.ContentStreamOperations{
+				op("BT"), font, op("Td", 72, 700),
+				op("Tj", "A"), op("TD", 0, -12),
+				op("Tj", "B"), op("T*"), op("Tj", "C"),
+				op("Td", 50.5, 0), op("Tj", "D"), op("ET"),
+			},
+			want: []TextRun{
+				{X: 72, Y: 700, FontSize: 10, Text: "A"},
+				{X: 72, Y: 688, FontSize: 10, Text: "B"},
+				{X: 72, Y: 676, FontSize: 10, Text: "C"},
+				{X: 122.5, Y: 676, FontSize: 10, Text: "D"},
+			},
+		},
+		{
+			name: "TL and '",
+			ops: pdfcontent.ContentStreamOperations{
+				op("BT"), font, op("TL", 14),
+				op("Td", 72, 700), op("'", "A"),
+				op("\"", 0, 0, "B"), op("ET"),
+			},
+			want: []TextRun{
+				{X: 72, Y: 686, FontSize: 10, Text: "A"},
+				{X: 72, Y: 672, FontSize: 10, Text: "B"},
+			},
+		},
+		{
+			// Td is relative to the line matrix set by Tm
+			name: "Tm",
+			ops: pdfcontent.ContentStreamOperations{
+				op("BT"), font, op("Tm", 1, 0, 0, 1, 100, 500),
+				op("Tj", "A"), op("Td", 10, -10),
+				op("Tj", "B"), op("ET"),
+			},
+			want: []TextRun{
+				{X: 100, Y: 500, FontSize: 10, Text: "A"},
+				{X: 110, Y: 490, FontSize: 10, Text: "B"},
+			},
+		},
+		{
+			// Font size 1 scaled by the text matrix, a common way
+			// of setting the size
+			name: "Tm font size scaling",
+			ops: pdfcontent.ContentStreamOperations{
+				op("BT"), op("Tf", "F1", 1),
+				op("Tm", 12, 0, 0, 12, 100, 500),
+				op("Tj", "A"), op("Td", 0, -1), op("Tj", "B"),
+				op("ET"),
+			},
+			want: []TextRun{
+				{X: 100, Y: 500, FontSize: 12, Text: "A"},
+				{X: 100, Y: 488, FontSize: 12, Text: "B"},
+			},
+		},
+		{
+			// Transformation matrix scales position and size,
+			// and is restored by Q
+			name: "cm, q and Q",
+			ops: pdfcontent.ContentStreamOperations{
+				font, op("q"), op("cm", 2, 0, 0, 2, 0, 0),
+				op("BT"), op("Td", 10, 20), op("Tj", "A"),
+				op("ET"), op("Q"), op("BT"), op("Td", 10, 20),
+				op("Tj", "B"), op("ET"),
+			},
+			want: []TextRun{
+				{X: 20, Y: 40, FontSize: 20, Text: "A"},
+				{X: 10, Y: 20, FontSize: 10, Text: "B"},
+			},
+		},
+		{
+			name: "Ts rise",
+			ops: pdfcontent.ContentStreamOperations{
+				op("BT"), font, op("Ts", 3), op("Td", 72, 700),
+				op("Tj", "A"), op("ET"),
+			},
+			want: []TextRun{
+				{X: 72, Y: 703, FontSize: 10, Text: "A"},
+			},
+		},
+		{
+			// Each glyph is estimated to be 5 wide. Small
+			// adjustments are kerning, -300 is a space, and -2000
+			// starts a new run.
+			name: "TJ kerning",
+			ops: pdfcontent.ContentStreamOperations{
+				op("BT"), font, op("Td", 72, 700),
+				op("TJ", []interface{}{"Hel", -20, "lo", -300,
+					"World", -2000, "Next"}),
+				op("ET"),
+			},
+			want: []TextRun{
+				{X: 72, Y: 700, FontSize: 10,
+					Text: "Hello World"},
+				{X: 145.2, Y: 700, FontSize: 10, Text: "Next"},
+			},
+		},
+		{
+			// Positive adjustments move left, never separating
+			name: "TJ positive adjustment",
+			ops: pdfcontent.ContentStreamOperations{
+				op("BT"), font, op("Td", 72, 700),
+				op("TJ", []interface{}{"A", 2000, "B"}),
+				op("Tj", "C"), op("ET"),
+			},
+			want: []TextRun{
+				{X: 72, Y: 700, FontSize: 10, Text: "AB"},
+				{X: 62, Y: 700, FontSize: 10, Text: "C"},
+			},
+		},
+		{
+			// Leading adjustments move the start of the run
+			name: "TJ leading adjustment",
+			ops: pdfcontent.ContentStreamOperations{
+				op("BT"), font, op("Td", 72, 700),
+				op("TJ", []interface{}{-1500, "A", -250.0,
+					"B"}),
+				op("ET"),
+			},
+			want: []TextRun{
+				{X: 87, Y: 700, FontSize: 10, Text: "A B"},
+			},
+		},
+		{
+			// Character and word spacing are added after each
+			// glyph, then horizontal scaling is applied to the
+			// advance and adjustments
+			name: "TJ with Tc, Tw and Tz",
+			ops: pdfcontent.ContentStreamOperations{
+				op("BT"), font, op("Tc", 1), op("Tw", 2),
+				op("Tz", 50), op("Td", 72, 700),
+				op("TJ", []interface{}{"A B", -2000, "C"}),
+				op("ET"),
+			},
+			want: []TextRun{
+				{X: 72, Y: 700, FontSize: 10, Text: "A B"},
+				{X: 92, Y: 700, FontSize: 10, Text: "C"},
+			},
+		},
+		{
+			// BT resets the text matrix, but not the text state
+			name: "BT resets position",
+			ops: pdfcontent.ContentStreamOperations{
+				op("BT"), font, op("Td", 72, 700),
+				op("Tj", "A"), op("ET"), op("BT"),
+				op("Tj", "B"), op("ET"),
+			},
+			want: []TextRun{
+				{X: 72, Y: 700, FontSize: 10, Text: "A"},
+				{X: 0, Y: 0, FontSize: 10, Text: "B"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			runs, err := extractRuns(3, test.ops)
+			if err != nil {
+				t.Fatalf("error extracting runs: %s", err.Error())
+			}
+
+			if len(runs) != len(test.want) {
+				t.Fatalf("expected %d runs, got %d: %v",
+					len(test.want), len(runs), runs)
+			}
+
+			for i, want := range test.want {
+				want.Page = 3
+
+				if !runsEqual(runs[i], want) {
+					t.Errorf("run %d: expected {%s}, got {%s}",
+						i, want, runs[i])
+				}
+			}
+		})
+	}
+}
+
+// TestExtractRunsInvalid checks operations with invalid operands return errors
+func TestExtractRunsInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		op   *pdfcontent.ContentStreamOperation
+	}{
+		{"Td missing operand", op("Td", 72)},
+		{"Tm string operand", op("Tm", 1, 0, 0, 1, 0, "a")},
+		{"Tj number operand", op("Tj", 12)},
+		{"TJ not array", op("TJ", "text")},
+		{"TJ array with array", op("TJ", []interface{}{"A",
+			[]interface{}{}})},
+		{"Tf missing size", op("Tf", "F1")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ops := pdfcontent.ContentStreamOperations{op("BT"),
+				test.op}
+
+			if _, err := extractRuns(1, ops); err == nil {
+				t.Fatalf("expected error")
+			}
+		})
+	}
+}
+
+// TestMatrixMult checks matrices are multiplied in the order the pdf spec
+// applies them
+func TestMatrixMult(t *testing.T) {
+	// Scale then move, the move is not scaled
+	got := matrix{2, 0, 0, 2, 0, 0}.mult(translate(10, 20))
+	want := matrix{2, 0, 0, 2, 10, 20}
+
+	if got != want {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	// Move then scale, the move is scaled
+	got = translate(10, 20).mult(matrix{2, 0, 0, 2, 0, 0})
+	want = matrix{2, 0, 0, 2, 20, 40}
+
+	if got != want {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	if identity.mult(want) != want {
+		t.Fatalf("expected identity to do nothing")
+	}
+}
+
+// runsEqual indicates if two text runs are the same, allowing for floating
+// point error in positions and font sizes
+func runsEqual(a TextRun, b TextRun) bool {
+	return a.Page == b.Page && a.Text == b.Text &&
+		math.Abs(a.X-b.X) < posTolerance &&
+		math.Abs(a.Y-b.Y) < posTolerance &&
+		math.Abs(a.FontSize-b.FontSize) < posTolerance
+}